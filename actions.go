@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Custom actions are shell commands from the config bound to keys. The
+// command template's placeholders are replaced with shell-quoted values:
+//
+//	{path}    full path of the selected entry
+//	{name}    its base name
+//	{dir}     the directory containing it
+//	{marked}  full paths of the marked entries, or the selection if none are
+//
+// Foreground actions suspend the TUI like the editor does; background
+// actions run while browsing and their output opens in the pager, or waits
+// behind a footer notice while a viewer or overlay is open.
+
+// actionOutputMax caps the output kept from a background action.
+const actionOutputMax = 1 << 20
+
+// actionDoneMsg is sent when an action's command exits.
+type actionDoneMsg struct {
+	action    actionConfig
+	dir       string // directory the action ran in
+	output    []byte // combined stdout and stderr of background actions
+	truncated bool
+	err       error
+}
+
+// actionOutput is a finished background action's output, kept until it is
+// viewed.
+type actionOutput struct {
+	label  string
+	title  string
+	data   []byte
+	failed bool
+}
+
+// actionFor returns the configured action bound to k.
+func (m Model) actionFor(k string) (actionConfig, bool) {
+	for _, a := range m.actions {
+		if a.Key == k {
+			return a, true
+		}
+	}
+	return actionConfig{}, false
+}
+
+// runAction starts a on the selection.
+func (m Model) runAction(a actionConfig) (Model, tea.Cmd) {
+	if m.vfs != nil {
+		m.err = fmt.Errorf("actions are not available inside %s", filepath.Base(m.vfs.path))
+		return m, nil
+	}
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	e := m.filtered[m.cursor]
+	switch {
+	case a.On == "file" && e.IsDir:
+		m.err = fmt.Errorf("%s works on files", a.Label)
+		return m, nil
+	case a.On == "dir" && !e.IsDir:
+		m.err = fmt.Errorf("%s works on directories", a.Label)
+		return m, nil
+	}
+	m.err = nil
+
+	full := filepath.Join(m.path, m.selectedRel())
+	marked := m.markedPaths()
+	if len(marked) == 0 {
+		marked = []string{full}
+	} else if strings.Contains(a.Command, "{marked}") {
+		clear(m.marked)
+	}
+	c := shellCommand(expandTemplate(a.Command, full, marked))
+	c.Dir = m.path
+	dir := m.path
+
+	if a.Mode == actionForeground {
+		return m, tea.ExecProcess(c, func(err error) tea.Msg {
+			return actionDoneMsg{action: a, dir: dir, err: err}
+		})
+	}
+	m.running++
+	return m, func() tea.Msg {
+		var out cappedBuffer
+		c.Stdout, c.Stderr = &out, &out
+		err := c.Run()
+		return actionDoneMsg{action: a, dir: dir, output: out.Bytes(), truncated: out.truncated, err: err}
+	}
+}
+
+// applyActionDone shows a background action's output, or keeps it for the
+// action output key when something else has the keyboard, and rescans the
+// directory if the action asked for it.
+func (m Model) applyActionDone(msg actionDoneMsg) (Model, tea.Cmd) {
+	a := msg.action
+	if msg.err != nil {
+		m.err = fmt.Errorf("%s: %w", a.Label, msg.err)
+	}
+	if a.Mode == actionBackground {
+		m.running = max(m.running-1, 0)
+		title := a.Label + " — done"
+		if msg.err != nil {
+			title = a.Label + " — " + msg.err.Error()
+		}
+		out := msg.output
+		if len(out) == 0 {
+			out = []byte("(no output)\n")
+		}
+		if msg.truncated {
+			out = append(out, "\n… output truncated\n"...)
+		}
+		m.output = &actionOutput{label: a.Label, title: title, data: out, failed: msg.err != nil}
+		var cmds []tea.Cmd
+		if !m.overlayOpen() {
+			var cmd tea.Cmd
+			m, cmd = m.showActionOutput()
+			cmds = append(cmds, cmd)
+		}
+		if a.Refresh {
+			var cmd tea.Cmd
+			m, cmd = m.refreshAfterAction(msg.dir)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	}
+	if a.Refresh {
+		return m.refreshAfterAction(msg.dir)
+	}
+	return m, nil
+}
+
+// showActionOutput opens the kept output of the last background action in
+// the pager.
+func (m Model) showActionOutput() (Model, tea.Cmd) {
+	if m.output == nil {
+		m.err = errors.New("no action output to show")
+		return m, nil
+	}
+	m.pager = outputPager(m.output.title, m.output.data)
+	m.output = nil
+	return m, m.pager.indexCmd(0)
+}
+
+// actionNotice is the footer notice for output waiting to be viewed, or "".
+func actionNotice(m Model) string {
+	if m.output == nil {
+		return ""
+	}
+	notice, st := m.output.label+" done", footerKeyStyle
+	if m.output.failed {
+		notice, st = m.output.label+" failed", errorStyle
+	}
+	if l := shortLabel(m.keys.ActionOutput); l != "" {
+		notice += " — " + l + " to view"
+	}
+	return st.Render(notice)
+}
+
+// refreshAfterAction drops the cached listing of dir and rescans it if it is
+// still shown.
+func (m Model) refreshAfterAction(dir string) (Model, tea.Cmd) {
+	m.cache.Delete(dir)
+	if dir != m.path || m.vfs != nil {
+		return m, nil
+	}
+	ctx := m.startScan()
+	m.loading = true
+	return m, tea.Batch(scanDirectory(ctx, m.path, m.scanProg), m.spinner.Tick)
+}
+
+// expandTemplate replaces the placeholders in command with shell-quoted values.
+func expandTemplate(command, path string, marked []string) string {
+	quoted := make([]string, len(marked))
+	for i, p := range marked {
+		quoted[i] = shellQuote(p)
+	}
+	return strings.NewReplacer(
+		"{path}", shellQuote(path),
+		"{name}", shellQuote(filepath.Base(path)),
+		"{dir}", shellQuote(filepath.Dir(path)),
+		"{marked}", strings.Join(quoted, " "),
+	).Replace(command)
+}
+
+// shellQuote quotes s as a single word for the shell run by shellCommand.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellCommand runs command through the platform shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// toggleMark marks or unmarks the selected entry and moves down.
+func (m Model) toggleMark() Model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	full := filepath.Join(m.path, m.selectedRel())
+	if m.marked[full] {
+		delete(m.marked, full)
+	} else {
+		m.marked[full] = true
+	}
+	return m.moveCursor(1)
+}
+
+// markedPaths returns the marked entries' full paths in order.
+func (m Model) markedPaths() []string {
+	paths := make([]string, 0, len(m.marked))
+	for p := range m.marked {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// cappedBuffer keeps the first actionOutputMax bytes written to it.
+type cappedBuffer struct {
+	bytes.Buffer
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := actionOutputMax - b.Len(); len(p) > room {
+		b.Buffer.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
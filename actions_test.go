@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX quoting")
+	}
+	got := expandTemplate("du -sh {path} {name} {dir} -- {marked}", "/data/it's here",
+		[]string{"/a", "/b c"})
+	want := `du -sh '/data/it'\''s here' 'it'\''s here' '/data' -- '/a' '/b c'`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	var b cappedBuffer
+	b.Write(make([]byte, actionOutputMax-1))
+	if n, err := b.Write([]byte("xyz")); n != 3 || err != nil {
+		t.Errorf("write reported %d, %v", n, err)
+	}
+	if b.Len() != actionOutputMax || !b.truncated {
+		t.Errorf("kept %d bytes, truncated=%v", b.Len(), b.truncated)
+	}
+}
+
+func TestBackgroundAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("hi"), 0o644)
+	m := NewModel(dir)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model)
+	m.actions = []actionConfig{{
+		Key: "X", Label: "list", Command: "echo {marked}; touch new.txt",
+		Mode: actionBackground, On: "file", Refresh: true,
+	}}
+
+	// Mark both files, then run the action on them
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	next, _ = next.(Model).Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = next.(Model)
+	if len(m.marked) != 2 || !strings.Contains(m.View(), "2 MARKED") {
+		t.Fatalf("marked %v", m.marked)
+	}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	m = next.(Model)
+	if cmd == nil || m.running != 1 || len(m.marked) != 0 {
+		t.Fatalf("action not started: running=%d marked=%v", m.running, m.marked)
+	}
+
+	done, ok := cmd().(actionDoneMsg)
+	if !ok || done.err != nil {
+		t.Fatalf("got %#v", done)
+	}
+	m, cmd = m.applyActionDone(done)
+	if m.running != 0 || m.pager == nil || !m.loading || cmd == nil {
+		t.Fatalf("output not shown or no rescan: running=%d loading=%v", m.running, m.loading)
+	}
+	m = indexAll(t, m, m.pager.indexCmd(0))
+	want := filepath.Join(dir, "a.txt") + " " + filepath.Join(dir, "b.txt")
+	if m.pager.line(0) != want {
+		t.Errorf("output %q, want %q", m.pager.line(0), want)
+	}
+	if !strings.Contains(renderPager(m), "list — done") {
+		t.Error("pager title missing")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestActionOutputWaitsForOverlay(t *testing.T) {
+	m := makeTestModel(3)
+	m.keys = DefaultKeyMap()
+	m.running = 1
+	m.helpMode = true
+	done := actionDoneMsg{action: actionConfig{Label: "probe", Mode: actionBackground}, output: []byte("ok\n")}
+
+	// The help overlay keeps the keyboard; the output waits behind a notice
+	m, _ = m.applyActionDone(done)
+	if m.pager != nil || !m.helpMode || m.running != 0 {
+		t.Fatalf("output took over the overlay: pager=%v help=%v", m.pager != nil, m.helpMode)
+	}
+	m.helpMode = false
+	if !strings.Contains(renderFooter(m), "probe done — A to view") {
+		t.Errorf("notice missing from footer: %q", renderFooter(m))
+	}
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	m = next.(Model)
+	if m.pager == nil || cmd == nil || m.output != nil {
+		t.Fatal("output key did not open the pager")
+	}
+	m = indexAll(t, m, cmd)
+	if m.pager.line(0) != "ok" {
+		t.Errorf("output %q", m.pager.line(0))
+	}
+}
+
+func TestActionSelectionKind(t *testing.T) {
+	m := makeTestModel(3) // entry 0 is a directory
+	m.marked = map[string]bool{}
+	m.actions = []actionConfig{{Key: "X", Label: "probe", Command: "true", On: "file"}}
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	m = next.(Model)
+	if cmd != nil || m.err == nil || !strings.Contains(m.err.Error(), "works on files") {
+		t.Errorf("file action ran on a directory: %v", m.err)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mohsinkaleem/dirgo/scan"
+	"github.com/ulikunitz/xz"
+)
+
+// archiveKind identifies an archive format that can be browsed as a directory.
+type archiveKind int
+
+const (
+	archiveNone archiveKind = iota
+	archiveZip
+	archiveTar
+	archiveTarGz
+	archiveTarXz
+	archiveTarZst
+)
+
+// String returns the short format name shown in the header badge.
+func (k archiveKind) String() string {
+	switch k {
+	case archiveZip:
+		return "zip"
+	case archiveTar:
+		return "tar"
+	case archiveTarGz:
+		return "tar.gz"
+	case archiveTarXz:
+		return "tar.xz"
+	case archiveTarZst:
+		return "tar.zst"
+	default:
+		return ""
+	}
+}
+
+// detectArchive returns the archive kind implied by a file name's extension.
+func detectArchive(name string) archiveKind {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".tar.xz"), strings.HasSuffix(lower, ".txz"):
+		return archiveTarXz
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return archiveTarZst
+	default:
+		return archiveNone
+	}
+}
+
+// vfsView is a non-OS filesystem grafted onto a virtual root path, such as an
+// archive opened in place or an imported ncdu dump. Paths at or below the
+// root are scanned through fsys instead of the OS.
+type vfsView struct {
+	path     string // virtual root path
+	fsys     scan.FS
+	kind     string // badge label, e.g. "tar.gz" or "ncdu"
+	archive  bool   // DiskSize holds compressed bytes (show the compressed column)
+	packed   int64  // on-disk size of the container file; 0 if not applicable
+	size     int64  // total apparent size of the tree
+	detached bool   // no OS directory above the root (e.g. imported dumps)
+	reload   func(prog *scan.Progress) (*vfsView, error)
+}
+
+// prefix returns the root path with exactly one trailing separator.
+func (v *vfsView) prefix() string {
+	return strings.TrimSuffix(v.path, string(filepath.Separator)) + string(filepath.Separator)
+}
+
+// contains reports whether p is the virtual root or a path inside it.
+func (v *vfsView) contains(p string) bool {
+	return p == v.path || strings.HasPrefix(p, v.prefix())
+}
+
+// name converts a virtual path into an fs path relative to the root.
+func (v *vfsView) name(p string) string {
+	if p == v.path {
+		return "."
+	}
+	return filepath.ToSlash(strings.TrimPrefix(p, v.prefix()))
+}
+
+// vfsResultMsg is sent when a virtual filesystem has been loaded.
+type vfsResultMsg struct {
+	view *vfsView
+	scan scanResultMsg
+}
+
+// loadVFSCmd loads a virtual filesystem and lists the directory vpath inside it.
+func loadVFSCmd(ctx context.Context, load func(prog *scan.Progress) (*vfsView, error), vpath string, prog *scan.Progress) tea.Cmd {
+	return func() tea.Msg {
+		view, err := load(prog)
+		if err != nil {
+			return scanErrorMsg{err: err}
+		}
+		// Loading reported member counts; the listing scan reports them again
+		if prog != nil {
+			prog.Files.Store(0)
+			prog.Dirs.Store(0)
+			prog.Size.Store(0)
+		}
+		msg := scanFS(ctx, view.fsys, view.name(vpath), vpath, prog)
+		res, ok := msg.(scanResultMsg)
+		if !ok {
+			return msg
+		}
+		return vfsResultMsg{view: view, scan: res}
+	}
+}
+
+// archiveLoader returns a loader that reads the archive at archivePath.
+func archiveLoader(archivePath string) func(prog *scan.Progress) (*vfsView, error) {
+	var load func(prog *scan.Progress) (*vfsView, error)
+	load = func(prog *scan.Progress) (*vfsView, error) {
+		view, err := readArchive(archivePath, prog)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read archive: %w", err)
+		}
+		view.reload = load
+		return view, nil
+	}
+	return load
+}
+
+// readArchive reads every member header of the archive into an in-memory tree.
+func readArchive(archivePath string, prog *scan.Progress) (*vfsView, error) {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	kind := detectArchive(archivePath)
+	root := newMemDir(filepath.Base(archivePath))
+	root.modTime = info.ModTime()
+	switch kind {
+	case archiveZip:
+		err = readZipMembers(archivePath, root, prog)
+	case archiveNone:
+		err = fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
+	default:
+		err = readTarMembers(archivePath, kind, root, prog)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root.finalize()
+	return &vfsView{
+		path:    archivePath,
+		fsys:    memFS{root: root},
+		kind:    kind.String(),
+		archive: true,
+		packed:  info.Size(),
+		size:    root.size,
+	}, nil
+}
+
+func readZipMembers(archivePath string, root *memNode, prog *scan.Progress) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		mode := f.Mode()
+		member := &memNode{
+			isDir:     mode.IsDir() || strings.HasSuffix(f.Name, "/"),
+			isSymlink: mode&os.ModeSymlink != 0,
+			size:      int64(f.UncompressedSize64),
+			diskSize:  int64(f.CompressedSize64),
+			modTime:   f.Modified,
+		}
+		root.add(f.Name, member)
+		reportMember(prog, member)
+	}
+	return nil
+}
+
+// countingReader counts bytes read from the underlying compressed stream so
+// per-member compressed sizes can be estimated for tar archives.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func readTarMembers(archivePath string, kind archiveKind, root *memNode, prog *scan.Progress) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cr := &countingReader{r: f}
+	var r io.Reader
+	switch kind {
+	case archiveTarGz:
+		g, err := gzip.NewReader(cr)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+		r = g
+	case archiveTarXz:
+		x, err := xz.NewReader(cr)
+		if err != nil {
+			return err
+		}
+		r = x
+	case archiveTarZst:
+		d, err := zstd.NewReader(cr, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+		r = d
+	default:
+		r = cr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		before := cr.n
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		member := &memNode{
+			isDir:     hdr.Typeflag == tar.TypeDir,
+			isSymlink: hdr.Typeflag == tar.TypeSymlink,
+			modTime:   hdr.ModTime,
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			member.size = hdr.Size
+			// Tar streams must be read through to reach the next header anyway;
+			// the compressed bytes consumed meanwhile approximate this member's share.
+			if _, err := io.Copy(io.Discard, tr); err != nil {
+				return err
+			}
+			member.diskSize = cr.n - before
+		}
+		root.add(hdr.Name, member)
+		reportMember(prog, member)
+	}
+}
+
+// reportMember updates scan progress counters for one archive member.
+func reportMember(prog *scan.Progress, member *memNode) {
+	if prog == nil {
+		return
+	}
+	if member.isDir {
+		prog.Dirs.Add(1)
+		return
+	}
+	prog.Files.Add(1)
+	prog.Size.Add(member.size)
+}
+
+// formatRatio renders part/whole as a compact percentage, or "" if unknown.
+func formatRatio(part, whole int64) string {
+	if part <= 0 || whole <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)/float64(whole)*100)
+}
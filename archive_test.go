@@ -0,0 +1,128 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		want archiveKind
+	}{
+		{"release.zip", archiveZip},
+		{"src.tar", archiveTar},
+		{"src.tar.gz", archiveTarGz},
+		{"SRC.TGZ", archiveTarGz},
+		{"src.tar.xz", archiveTarXz},
+		{"src.tar.zst", archiveTarZst},
+		{"notes.gz", archiveNone},
+		{"main.go", archiveNone},
+	}
+	for _, tt := range tests {
+		if got := detectArchive(tt.name); got != tt.want {
+			t.Errorf("detectArchive(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadZipArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.zip")
+	f, _ := os.Create(path)
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{
+		"bin/app":           strings.Repeat("x", 4000),
+		"docs/readme.md":    "hello\n",
+		"docs/img/logo.svg": strings.Repeat("y", 100),
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	f.Close()
+
+	view, err := readArchive(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, ok := scanFS(context.Background(), view.fsys, ".", path, nil).(scanResultMsg)
+	if !ok {
+		t.Fatal("expected scanResultMsg for archive root")
+	}
+	if res.totalDirs != 2 || res.totalFiles != 0 || res.totalSize != 4106 {
+		t.Errorf("unexpected root totals: %+v", res)
+	}
+	if res.entries[0].Name != "bin" || res.entries[0].DiskSize <= 0 {
+		t.Errorf("expected bin first with compressed size, got %+v", res.entries[0])
+	}
+
+	docs, ok := scanFS(context.Background(), view.fsys, view.name(filepath.Join(path, "docs")), filepath.Join(path, "docs"), nil).(scanResultMsg)
+	if !ok {
+		t.Fatal("expected scanResultMsg for docs")
+	}
+	if docs.totalFiles != 1 || docs.totalDirs != 1 {
+		t.Errorf("unexpected docs totals: %+v", docs)
+	}
+	if _, ok := scanFS(context.Background(), view.fsys, "missing", filepath.Join(path, "missing"), nil).(scanErrorMsg); !ok {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestReadTarArchives(t *testing.T) {
+	src := makeTempDir(t, 2, 3, 4)
+	for _, format := range []archiveFormat{formatTarGz, formatTarZst} {
+		path := filepath.Join(t.TempDir(), "data"+format.ext())
+		if _, _, err := writeArchive(context.Background(), src, path, format, nil); err != nil {
+			t.Fatal(err)
+		}
+		view, err := readArchive(path, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", format.ext(), err)
+		}
+		// The archive root holds the single top-level directory that was packed.
+		top := view.fsys.(memFS).root.lookup(filepath.Base(src))
+		if top == nil || !top.isDir {
+			t.Fatalf("%s: missing top-level dir", format.ext())
+		}
+		if top.files != 14 || top.dirs != 3 {
+			t.Errorf("%s: expected 14 files / 3 dirs, got %d / %d", format.ext(), top.files, top.dirs)
+		}
+		if top.size != 2*19+12*8 {
+			t.Errorf("%s: unexpected uncompressed size %d", format.ext(), top.size)
+		}
+	}
+}
+
+func TestMemNodeRejectsEscapingPaths(t *testing.T) {
+	root := newMemDir("")
+	root.add("../etc/passwd", &memNode{size: 1})
+	root.add("/abs/file", &memNode{size: 2})
+	root.add("./rel/file", &memNode{size: 3})
+	root.finalize()
+	if root.lookup("etc") != nil {
+		t.Error("path escaping the archive root should be skipped")
+	}
+	if root.lookup("abs/file") == nil || root.lookup("rel/file") == nil {
+		t.Error("absolute and ./ paths should be normalised")
+	}
+	if root.size != 5 {
+		t.Errorf("expected size 5, got %d", root.size)
+	}
+}
+
+func TestVFSViewPaths(t *testing.T) {
+	v := &vfsView{path: filepath.FromSlash("/data/app.zip")}
+	if !v.contains(filepath.FromSlash("/data/app.zip/bin")) || v.contains(filepath.FromSlash("/data/app.zip2")) {
+		t.Error("contains mismatch")
+	}
+	if got := v.name(v.path); got != "." {
+		t.Errorf("root name = %q", got)
+	}
+	if got := v.name(filepath.FromSlash("/data/app.zip/bin/x")); got != "bin/x" {
+		t.Errorf("name = %q", got)
+	}
+}
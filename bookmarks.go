@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Bookmarks are directories saved under a single letter or digit: m then
+// the letter sets one, ' then the letter jumps to it. They live in
+// bookmarks.toml in the config directory:
+//
+//	[bookmarks.d]
+//	path = "/var/lib/docker"
+//	size = 48318382080
+
+// bookmark is a saved directory and its size when last scanned.
+type bookmark struct {
+	Path string `toml:"path"`
+	Size int64  `toml:"size,omitempty"`
+}
+
+// bookmarkFile is the TOML layout of the bookmarks file.
+type bookmarkFile struct {
+	Bookmarks map[string]bookmark `toml:"bookmarks"`
+}
+
+// bookmarkPrompt is what the next key does after m or '.
+type bookmarkPrompt int
+
+const (
+	bookmarkNone bookmarkPrompt = iota
+	bookmarkSet
+	bookmarkJump
+)
+
+// bookmarksPath is the file bookmarks are kept in.
+func bookmarksPath() string {
+	d := configDir()
+	if d == "" {
+		return ""
+	}
+	return filepath.Join(d, "bookmarks.toml")
+}
+
+// loadBookmarks reads the bookmarks file at path; a missing file has none.
+func loadBookmarks(path string) (map[string]bookmark, error) {
+	var f bookmarkFile
+	if _, err := toml.DecodeFile(path, &f); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if f.Bookmarks == nil {
+		f.Bookmarks = make(map[string]bookmark)
+	}
+	return f.Bookmarks, nil
+}
+
+// bookmarksMu serializes writes of the bookmarks file, which size updates
+// make in the background.
+var bookmarksMu sync.Mutex
+
+// saveBookmarks writes bookmarks to path, replacing the file atomically.
+func saveBookmarks(path string, bookmarks map[string]bookmark) error {
+	bookmarksMu.Lock()
+	defer bookmarksMu.Unlock()
+	return writeBookmarks(path, bookmarks)
+}
+
+// saveBookmarkSizes updates the sizes of bookmarks in the file at path
+// from sizes, by directory. The file is read again first, so bookmarks set
+// or removed meanwhile are kept as they are.
+func saveBookmarkSizes(path string, sizes map[string]int64) error {
+	bookmarksMu.Lock()
+	defer bookmarksMu.Unlock()
+	bookmarks, err := loadBookmarks(path)
+	if err != nil {
+		return err
+	}
+	for k, b := range bookmarks {
+		if size, ok := sizes[b.Path]; ok {
+			b.Size = size
+			bookmarks[k] = b
+		}
+	}
+	return writeBookmarks(path, bookmarks)
+}
+
+// writeBookmarks does the work of saveBookmarks; bookmarksMu must be held.
+func writeBookmarks(path string, bookmarks map[string]bookmark) error {
+	if path == "" {
+		return errors.New("no config directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# dirgo bookmarks: m<key> sets, '<key> jumps, B lists\n")
+	if err := toml.NewEncoder(&buf).Encode(bookmarkFile{Bookmarks: bookmarks}); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isBookmarkKey reports whether k can name a bookmark: one letter or digit.
+func isBookmarkKey(k string) bool {
+	r, n := utf8.DecodeRuneInString(k)
+	return n == len(k) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// bookmarkKeys returns the keys of bookmarks in order.
+func bookmarkKeys(bookmarks map[string]bookmark) []string {
+	keys := make([]string, 0, len(bookmarks))
+	for k := range bookmarks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateBookmarkPrompt takes the key after m or ' and sets or jumps to
+// that bookmark; escape cancels.
+func (m Model) updateBookmarkPrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	prompt := m.bookmarkPrompt
+	m.bookmarkPrompt = bookmarkNone
+	k := msg.String()
+	if key.Matches(msg, m.keys.Escape) {
+		return m, nil
+	}
+	if !isBookmarkKey(k) {
+		m.err = fmt.Errorf("bookmarks are named by a letter or digit, not %s", keyLabel(k))
+		return m, nil
+	}
+	if prompt == bookmarkSet {
+		return m.setBookmark(k), nil
+	}
+	b, ok := m.bookmarks[k]
+	if !ok {
+		m.err = fmt.Errorf("no bookmark %s", k)
+		return m, nil
+	}
+	return m.navigateTo(b.Path)
+}
+
+// setBookmark saves the current directory under k.
+func (m Model) setBookmark(k string) Model {
+	if m.vfs != nil {
+		m.err = fmt.Errorf("bookmarks are not available inside %s", filepath.Base(m.vfs.path))
+		return m
+	}
+	bookmarks := make(map[string]bookmark, len(m.bookmarks)+1)
+	for name, b := range m.bookmarks {
+		bookmarks[name] = b
+	}
+	bookmarks[k] = bookmark{Path: m.path, Size: m.totalSize}
+	if err := saveBookmarks(m.bookmarksPath, bookmarks); err != nil {
+		m.err = fmt.Errorf("saving bookmark: %w", err)
+		return m
+	}
+	m.bookmarks = bookmarks
+	m.err = nil
+	return m
+}
+
+// refreshBookmarkSizes updates the sizes of bookmarked directories that are
+// in the scan cache, and returns a command saving them if any changed.
+func (m Model) refreshBookmarkSizes() (Model, tea.Cmd) {
+	sizes := make(map[string]int64)
+	bookmarks := make(map[string]bookmark, len(m.bookmarks))
+	for k, b := range m.bookmarks {
+		if cached, ok := m.cache.Peek(b.Path); ok && cached.totalSize != b.Size {
+			b.Size = cached.totalSize
+			sizes[b.Path] = b.Size
+		}
+		bookmarks[k] = b
+	}
+	if len(sizes) == 0 {
+		return m, nil
+	}
+	m.bookmarks = bookmarks
+	path := m.bookmarksPath
+	return m, func() tea.Msg {
+		return bookmarkSizesSavedMsg{err: saveBookmarkSizes(path, sizes)}
+	}
+}
+
+// bookmarkSizesSavedMsg reports saving refreshed bookmark sizes.
+type bookmarkSizesSavedMsg struct {
+	err error
+}
+
+// bookmarkList is the bookmark list overlay.
+type bookmarkList struct {
+	cursor int
+	err    error // saving refreshed sizes failed
+}
+
+// updateBookmarkList handles keys while the bookmark list is open: ↑↓
+// move, the open keys or the bookmark's key jump, delete/backspace removes
+// the selected bookmark, escape, quit or the bookmarks key close. A
+// bookmark's key wins over the others, so every bookmark can be jumped to.
+func (m Model) updateBookmarkList(msg tea.KeyMsg) (Model, tea.Cmd) {
+	l := m.bookmarkList
+	keys := bookmarkKeys(m.bookmarks)
+	k := msg.String()
+	if b, ok := m.bookmarks[k]; ok {
+		m.bookmarkList = nil
+		return m.navigateTo(b.Path)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Bookmarks, m.keys.Escape, m.keys.Quit):
+		m.bookmarkList = nil
+	case key.Matches(msg, m.keys.Up):
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if l.cursor < len(keys)-1 {
+			l.cursor++
+		}
+	case key.Matches(msg, m.keys.Right):
+		m.bookmarkList = nil
+		if len(keys) > 0 {
+			return m.navigateTo(m.bookmarks[keys[l.cursor]].Path)
+		}
+	case k == "delete" || k == "backspace":
+		if len(keys) == 0 {
+			return m, nil
+		}
+		bookmarks := make(map[string]bookmark, len(m.bookmarks))
+		for name, b := range m.bookmarks {
+			if name != keys[l.cursor] {
+				bookmarks[name] = b
+			}
+		}
+		if err := saveBookmarks(m.bookmarksPath, bookmarks); err != nil {
+			m.err = fmt.Errorf("deleting bookmark: %w", err)
+			return m, nil
+		}
+		m.bookmarks = bookmarks
+		l.cursor = max(min(l.cursor, len(keys)-2), 0)
+	}
+	return m, nil
+}
+
+// renderBookmarkList renders the bookmark list overlay.
+func renderBookmarkList(m Model) string {
+	l := m.bookmarkList
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(helpTitleStyle.Render("  Bookmarks"))
+	b.WriteString("\n\n")
+
+	w := minInt(80, m.width-4)
+	keys := bookmarkKeys(m.bookmarks)
+	if len(keys) == 0 {
+		b.WriteString(rowDimStyle.Render("  none yet — press m and a letter to bookmark a directory"))
+		b.WriteString("\n")
+	}
+	var largest int64
+	for _, k := range keys {
+		largest = max(largest, m.bookmarks[k].Size)
+	}
+	nameW := maxInt(8, w-30)
+	for i, k := range keys {
+		bm := m.bookmarks[k]
+		pointer := "  "
+		if i == l.cursor {
+			pointer = "▶ "
+		}
+		size, bar := padLeft("—", 9), strings.Repeat(" ", 10)
+		if bm.Size > 0 {
+			pct := float64(bm.Size) / float64(max(largest, 1)) * 100
+			size = padLeft(formatSize(bm.Size), 9)
+			bar = m.bar(pct, 10)
+		}
+		nameSt := rowNameStyle
+		if bm.Path == m.path {
+			nameSt = rowNameSelStyle
+		}
+		name := truncateStrVisual(shortenPath(bm.Path), nameW)
+		b.WriteString(rowPointerActiveStyle.Render(pointer))
+		b.WriteString(footerKeyStyle.Render(k + " "))
+		b.WriteString(nameSt.Render(padRightVisual(name, nameW)))
+		b.WriteString(rowDimStyle.Render(size) + " " + bar)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if l.err != nil {
+		b.WriteString(errorStyle.Render(truncateStrVisual("  saving sizes: "+l.err.Error(), w)) + "\n")
+	}
+	b.WriteString(footerStyle.Render("  ⏎ or key go  del remove  " + m.keys.closeLabel(m.keys.Bookmarks) + " close"))
+	b.WriteString("\n")
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorCyan).
+		Padding(0, 2).
+		Width(w)
+
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(b.String()))
+}
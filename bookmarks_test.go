@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBookmarks(t *testing.T) {
+	root := t.TempDir()
+	docker := filepath.Join(root, "docker")
+	os.Mkdir(docker, 0o755)
+	file := filepath.Join(t.TempDir(), "bookmarks.toml")
+
+	m := NewModel(docker)
+	m.width, m.height = 80, 24
+	m.bookmarksPath = file
+	step := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			next, _ := m.Update(msg)
+			m = next.(Model)
+		}
+	}
+	next, _ := m.Update(scanResultMsg{path: docker, totalSize: 4096})
+	m = next.(Model)
+
+	step("m", "d")
+	saved, err := loadBookmarks(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := saved["d"]; b.Path != docker || b.Size != 4096 {
+		t.Fatalf("saved %+v", saved)
+	}
+
+	m, _ = m.navigateTo(root)
+	step("'", "d")
+	if m.path != docker {
+		t.Errorf("jump went to %s", m.path)
+	}
+	step("'", "x")
+	if m.err == nil || m.path != docker {
+		t.Errorf("unknown bookmark: at %s, err %v", m.path, m.err)
+	}
+	step("m", "?")
+	if m.err == nil || len(m.bookmarks) != 1 {
+		t.Errorf("bad key: err %v, %v", m.err, m.bookmarks)
+	}
+
+	// The list jumps with enter and deletes with backspace
+	m, _ = m.navigateTo(root)
+	step("B", "enter")
+	if m.bookmarkList != nil || m.path != docker {
+		t.Errorf("list enter: at %s", m.path)
+	}
+	step("B")
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = next.(Model)
+	if saved, _ := loadBookmarks(file); len(m.bookmarks) != 0 || len(saved) != 0 {
+		t.Errorf("after delete: %v, file %v", m.bookmarks, saved)
+	}
+	// Bookmarks named like the list's close keys still jump from it
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	m, _ = m.navigateTo(root)
+	next, _ = m.Update(scanResultMsg{path: root})
+	m = next.(Model)
+	step("m", "q")
+	m, _ = m.navigateTo(docker)
+	step("m", "B")
+	step("B", "q")
+	if m.bookmarkList != nil || m.path != root {
+		t.Errorf("list q: at %s", m.path)
+	}
+	step("B", "B")
+	if m.bookmarkList != nil || m.path != docker {
+		t.Errorf("list B: at %s", m.path)
+	}
+}
+
+func TestLoadBookmarksMissing(t *testing.T) {
+	b, err := loadBookmarks(filepath.Join(t.TempDir(), "none.toml"))
+	if err != nil || b == nil || len(b) != 0 {
+		t.Errorf("got %v, %v", b, err)
+	}
+}
+
+func TestBookmarkSizesSavedInBackground(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(t.TempDir(), "bookmarks.toml")
+	saveBookmarks(file, map[string]bookmark{"r": {Path: root}})
+
+	m := NewModel(root)
+	m.width, m.height = 80, 24
+	m.bookmarksPath = file
+	m.bookmarks, _ = loadBookmarks(file)
+	next, _ := m.Update(scanResultMsg{path: root, totalSize: 2048})
+	m = next.(Model)
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	m = next.(Model)
+	if cmd == nil || m.bookmarks["r"].Size != 2048 {
+		t.Fatalf("sizes not refreshed: %v", m.bookmarks)
+	}
+	// A bookmark set before the save lands is kept
+	saveBookmarks(file, map[string]bookmark{"r": {Path: root}, "s": {Path: "/srv"}})
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	saved, _ := loadBookmarks(file)
+	if saved["r"].Size != 2048 || saved["s"].Path != "/srv" || m.bookmarkList.err != nil {
+		t.Errorf("saved %v, err %v", saved, m.bookmarkList.err)
+	}
+
+	// A failed save shows in the list
+	next, _ = m.Update(bookmarkSizesSavedMsg{err: errors.New("disk full")})
+	m = next.(Model)
+	if !strings.Contains(renderBookmarkList(m), "saving sizes: disk full") {
+		t.Error("save error not shown in the list")
+	}
+}
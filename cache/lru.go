@@ -0,0 +1,93 @@
+// Package cache provides a bounded, concurrency-safe LRU cache keyed by path.
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU is a bounded in-memory LRU cache. Create one with New.
+type LRU[V any] struct {
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	mu         sync.Mutex
+}
+
+type item[V any] struct {
+	key   string
+	value V
+}
+
+// New returns an LRU holding at most maxEntries values.
+func New[V any](maxEntries int) *LRU[V] {
+	return &LRU[V]{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get retrieves a cached value. Returns (value, true) on hit.
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*item[V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Peek retrieves a cached value without marking it recently used.
+func (c *LRU[V]) Peek(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		return el.Value.(*item[V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put stores a value, evicting the LRU entry if over capacity.
+func (c *LRU[V]) Put(key string, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*item[V]).value = val
+		return
+	}
+	el := c.ll.PushFront(&item[V]{key: key, value: val})
+	c.items[key] = el
+	if c.ll.Len() > c.maxEntries {
+		c.evictOldest()
+	}
+}
+
+// Delete removes a cached entry.
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU[V]) evictOldest() {
+	el := c.ll.Back()
+	if el != nil {
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*item[V]).key)
+	}
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"testing"
+)
+
+func TestLRUPutGet(t *testing.T) {
+	c := New[int](3)
+	c.Put("/a", 1)
+	c.Put("/b", 2)
+	c.Put("/c", 3)
+
+	if c.Len() != 3 {
+		t.Fatalf("expected 3, got %d", c.Len())
+	}
+
+	v, ok := c.Get("/b")
+	if !ok || v != 2 {
+		t.Errorf("expected /b with value 2, got ok=%v value=%d", ok, v)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c := New[int](2)
+	c.Put("/a", 0)
+	c.Put("/b", 0)
+	c.Put("/c", 0) // should evict /a
+
+	if _, ok := c.Get("/a"); ok {
+		t.Error("/a should have been evicted")
+	}
+	if _, ok := c.Get("/b"); !ok {
+		t.Error("/b should still be present")
+	}
+	if _, ok := c.Get("/c"); !ok {
+		t.Error("/c should still be present")
+	}
+}
+
+func TestLRULRUOrder(t *testing.T) {
+	c := New[int](2)
+	c.Put("/a", 0)
+	c.Put("/b", 0)
+
+	// Access /a to make it most recently used
+	c.Get("/a")
+
+	// Adding /c should evict /b (least recently used)
+	c.Put("/c", 0)
+
+	if _, ok := c.Get("/b"); ok {
+		t.Error("/b should have been evicted")
+	}
+	if _, ok := c.Get("/a"); !ok {
+		t.Error("/a should still be present")
+	}
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := New[int](5)
+	c.Put("/a", 0)
+	c.Delete("/a")
+
+	if _, ok := c.Get("/a"); ok {
+		t.Error("/a should have been deleted")
+	}
+	if c.Len() != 0 {
+		t.Errorf("expected length 0, got %d", c.Len())
+	}
+}
+
+func TestLRUUpdate(t *testing.T) {
+	c := New[int](5)
+	c.Put("/a", 1)
+	c.Put("/a", 99)
+
+	v, _ := c.Get("/a")
+	if v != 99 {
+		t.Errorf("expected updated value 99, got %d", v)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected length 1, got %d", c.Len())
+	}
+}
+
+func TestLRUPeek(t *testing.T) {
+	c := New[int](2)
+	c.Put("/a", 1)
+	c.Put("/b", 2)
+	if v, ok := c.Peek("/a"); !ok || v != 1 {
+		t.Fatalf("Peek(/a) = %d, %v", v, ok)
+	}
+	// Peek does not promote /a, so it is still evicted first
+	c.Put("/c", 3)
+	if _, ok := c.Get("/a"); ok {
+		t.Error("/a should have been evicted")
+	}
+}
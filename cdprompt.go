@@ -0,0 +1,225 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cdHistoryMax is how many paths the cd prompt remembers.
+const cdHistoryMax = 100
+
+// cdPrompt is the state of the cd prompt (c) beyond its text input: the
+// paths entered before, recalled with ↑↓, and the directory names Tab
+// cycles through.
+type cdPrompt struct {
+	history []string // entered paths, oldest first
+	histPos int      // index of the recalled path; len(history) for the draft
+	draft   string   // text typed before recalling history
+
+	matches []string // completions of the last path element; nil when not cycling
+	match   int      // index of the completion shown
+	base    string   // input up to the element being completed
+}
+
+// openGoto shows the cd prompt.
+func (m Model) openGoto() (Model, tea.Cmd) {
+	m.gotoMode = true
+	m.gotoInput.SetValue("")
+	m.cd.histPos = len(m.cd.history)
+	m.cd.matches = nil
+	return m, m.gotoInput.Focus()
+}
+
+// updateGoto handles keys while the cd prompt is open: enter goes, tab and
+// shift+tab complete directory names, ↑↓ recall earlier paths.
+func (m Model) updateGoto(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Escape):
+		m.gotoMode = false
+		m.gotoInput.SetValue("")
+		m.gotoInput.Blur()
+		m.cd.matches = nil
+		return m, nil
+	case msg.Type == tea.KeyEnter:
+		m.gotoMode = false
+		m.gotoInput.Blur()
+		target := m.gotoInput.Value()
+		m.gotoInput.SetValue("")
+		m.cd.matches = nil
+		if target == "" {
+			return m, nil
+		}
+		m.cd.remember(target)
+		return m.navigateTo(expandHome(expandVars(target)))
+	case msg.Type == tea.KeyTab:
+		return m.completeGoto(1), nil
+	case msg.Type == tea.KeyShiftTab:
+		return m.completeGoto(-1), nil
+	case msg.Type == tea.KeyUp:
+		return m.recallGoto(-1), nil
+	case msg.Type == tea.KeyDown:
+		return m.recallGoto(1), nil
+	default:
+		m.cd.matches = nil
+		var cmd tea.Cmd
+		m.gotoInput, cmd = m.gotoInput.Update(msg)
+		return m, cmd
+	}
+}
+
+// remember adds path to the end of the history, dropping an earlier copy.
+func (c *cdPrompt) remember(path string) {
+	h := make([]string, 0, len(c.history)+1)
+	for _, p := range c.history {
+		if p != path {
+			h = append(h, p)
+		}
+	}
+	h = append(h, path)
+	if over := len(h) - cdHistoryMax; over > 0 {
+		h = h[over:]
+	}
+	c.history = h
+	c.histPos = len(h)
+}
+
+// recallGoto shows the path delta steps back (negative) or forward in the
+// history; stepping past the newest restores what was typed.
+func (m Model) recallGoto(delta int) Model {
+	pos := m.cd.histPos + delta
+	if pos < 0 || pos > len(m.cd.history) {
+		return m
+	}
+	if m.cd.histPos == len(m.cd.history) {
+		m.cd.draft = m.gotoInput.Value()
+	}
+	m.cd.histPos = pos
+	m.cd.matches = nil
+	if pos == len(m.cd.history) {
+		m.gotoInput.SetValue(m.cd.draft)
+	} else {
+		m.gotoInput.SetValue(m.cd.history[pos])
+	}
+	m.gotoInput.CursorEnd()
+	return m
+}
+
+// completeGoto completes the last element of the typed path to a directory
+// name. A single match is filled in; with several, the first Tab extends to
+// their common prefix and later ones cycle through them (delta -1 backwards).
+func (m Model) completeGoto(delta int) Model {
+	c := &m.cd
+	if len(c.matches) > 1 {
+		c.match = (c.match + delta + len(c.matches)) % len(c.matches)
+		m.gotoInput.SetValue(c.base + c.matches[c.match] + string(filepath.Separator))
+		m.gotoInput.CursorEnd()
+		return m
+	}
+
+	value := m.gotoInput.Value()
+	base, word := "", value
+	if i := strings.LastIndexAny(value, `/`+string(filepath.Separator)); i >= 0 {
+		base, word = value[:i+1], value[i+1:]
+	}
+	dir := expandHome(expandVars(base))
+	if dir == "" {
+		dir = m.path
+	} else if !filepath.IsAbs(dir) {
+		dir = filepath.Join(m.path, dir)
+	}
+	matches := m.dirCompletions(dir, word)
+	switch {
+	case len(matches) == 0:
+		return m
+	case len(matches) == 1:
+		m.gotoInput.SetValue(base + matches[0] + string(filepath.Separator))
+	default:
+		if p := commonPrefix(matches); len(p) > len(word) {
+			m.gotoInput.SetValue(base + p)
+			break
+		}
+		c.matches, c.base, c.match = matches, base, 0
+		if delta < 0 {
+			c.match = len(matches) - 1
+		}
+		m.gotoInput.SetValue(base + matches[c.match] + string(filepath.Separator))
+	}
+	m.gotoInput.CursorEnd()
+	return m
+}
+
+// dirCompletions returns the names of the subdirectories of dir starting
+// with prefix, sorted. The match is case-insensitive if nothing matches
+// exactly, and hidden directories are only offered for a prefix with a dot.
+func (m Model) dirCompletions(dir, prefix string) []string {
+	var entries []fs.DirEntry
+	var err error
+	if m.vfs != nil && m.vfs.contains(filepath.Clean(dir)) {
+		entries, err = m.vfs.fsys.ReadDir(m.vfs.name(filepath.Clean(dir)))
+	} else {
+		entries, err = os.ReadDir(dir)
+	}
+	if err != nil {
+		return nil
+	}
+	var exact, folded []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if !e.IsDir() {
+			// Follow symlinks to directories
+			if e.Type()&fs.ModeSymlink == 0 || m.vfs != nil {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(dir, name)); err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		switch {
+		case strings.HasPrefix(name, prefix):
+			exact = append(exact, name)
+		case len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix):
+			folded = append(folded, name)
+		}
+	}
+	if len(exact) == 0 {
+		exact = folded
+	}
+	sort.Slice(exact, func(i, j int) bool { return exact[i] < exact[j] })
+	return exact
+}
+
+// commonPrefix returns the longest prefix shared by all of names.
+func commonPrefix(names []string) string {
+	p := names[0]
+	for _, n := range names[1:] {
+		for !strings.HasPrefix(n, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	// Names sharing the first bytes of a rune must not leave half of it
+	for !utf8.ValidString(p) {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// renderGotoFooter renders the cd prompt, with the position among the
+// completions while cycling through them.
+func renderGotoFooter(m Model) string {
+	s := searchPromptStyle.Render(" cd ") + m.gotoInput.View()
+	if len(m.cd.matches) > 1 {
+		s += footerDescStyle.Render("  " + strconv.Itoa(m.cd.match+1) + "/" + strconv.Itoa(len(m.cd.matches)) + " tab next")
+	}
+	return s
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGotoCompletion(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{"Downloads", "Documents", "Docker", ".dotfiles", "music"} {
+		os.Mkdir(filepath.Join(root, d), 0o755)
+	}
+	os.WriteFile(filepath.Join(root, "Dockerfile"), nil, 0o644)
+
+	m := NewModel(root)
+	m, _ = m.openGoto()
+	typeText := func(s string) {
+		m.gotoInput.SetValue(s)
+		m.gotoInput.CursorEnd()
+		m.cd.matches = nil
+	}
+	tab := func() {
+		m, _ = m.updateGoto(tea.KeyMsg{Type: tea.KeyTab})
+	}
+
+	typeText("mu")
+	tab()
+	if got := m.gotoInput.Value(); got != "music/" {
+		t.Errorf("single match: %q", got)
+	}
+
+	// The first tab extends to the common prefix, then tabs cycle
+	typeText("D")
+	tab()
+	if got := m.gotoInput.Value(); got != "Do" {
+		t.Fatalf("common prefix: %q", got)
+	}
+	tab()
+	tab()
+	if got := m.gotoInput.Value(); got != "Documents/" || len(m.cd.matches) != 3 {
+		t.Errorf("second cycle: %q of %v", got, m.cd.matches)
+	}
+	m, _ = m.updateGoto(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if got := m.gotoInput.Value(); got != "Docker/" {
+		t.Errorf("shift+tab: %q", got)
+	}
+
+	// Case-insensitive when nothing matches exactly; hidden only with a dot
+	typeText("MUS")
+	tab()
+	if got := m.gotoInput.Value(); got != "music/" {
+		t.Errorf("folded: %q", got)
+	}
+	typeText(".")
+	tab()
+	if got := m.gotoInput.Value(); got != ".dotfiles/" {
+		t.Errorf("hidden: %q", got)
+	}
+
+	// $VAR in the directory part
+	t.Setenv("DIRGO_TEST_ROOT", root)
+	typeText("$DIRGO_TEST_ROOT/mu")
+	tab()
+	if got := m.gotoInput.Value(); got != "$DIRGO_TEST_ROOT/music/" {
+		t.Errorf("var: %q", got)
+	}
+}
+
+func TestGotoExpandsOnlyInThePrompt(t *testing.T) {
+	root := t.TempDir()
+	literal := filepath.Join(root, "$DIRGO_TEST_DIR")
+	os.Mkdir(literal, 0o755)
+	os.Mkdir(filepath.Join(root, "music"), 0o755)
+	t.Setenv("DIRGO_TEST_DIR", "music")
+
+	// Paths from history, bookmarks and the finder are used as written
+	m := NewModel(root)
+	m, _ = m.navigateTo(literal)
+	if m.path != literal || m.err != nil {
+		t.Errorf("literal path: at %s, err %v", m.path, m.err)
+	}
+
+	// The cd prompt expands $VAR before going there
+	m = NewModel(root)
+	m, _ = m.openGoto()
+	m.gotoInput.SetValue("$DIRGO_TEST_DIR")
+	m, _ = m.updateGoto(tea.KeyMsg{Type: tea.KeyEnter})
+	if want := filepath.Join(root, "music"); m.path != want || m.err != nil {
+		t.Errorf("cd $DIRGO_TEST_DIR: at %s, err %v", m.path, m.err)
+	}
+}
+
+func TestGotoHistory(t *testing.T) {
+	m := NewModel(t.TempDir())
+	m.cd.remember("/a")
+	m.cd.remember("/b")
+	m.cd.remember("/a")
+	m, _ = m.openGoto()
+	m.gotoInput.SetValue("dra")
+
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for _, step := range []struct {
+		key  tea.KeyMsg
+		want string
+	}{{up, "/a"}, {up, "/b"}, {up, "/b"}, {down, "/a"}, {down, "dra"}, {down, "dra"}} {
+		m, _ = m.updateGoto(step.key)
+		if got := m.gotoInput.Value(); got != step.want {
+			t.Fatalf("got %q, want %q (history %v)", got, step.want, m.cd.history)
+		}
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+	if got := commonPrefix([]string{"née", "néon"}); got != "né" {
+		t.Errorf("got %q", got)
+	}
+	if got := commonPrefix([]string{"é", "è"}); got != "" {
+		t.Errorf("split rune: %q", got)
+	}
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+// columnID identifies an optional column shown to the right of the name.
+type columnID int
+
+const (
+	colSize     columnID = iota // apparent size
+	colDisk                     // allocated size on disk
+	colLines                    // line count (compressed size + ratio inside archives)
+	colMTime                    // relative modification time
+	colMTimeAbs                 // absolute modification time
+	colOwner                    // owning user
+	colGroup                    // owning group
+	colMode                     // permission bits
+	colFiles                    // recursive child file count
+	colDirs                     // recursive child dir count
+	colInodes                   // inodes accounted for (entry + descendants)
+
+	numColumns
+)
+
+// column describes how one optional column is named, sized and rendered.
+type column struct {
+	key   string // name used in --columns and the config file
+	title string // label in the column chooser
+	width int
+	// priority decides which columns survive on narrow terminals:
+	// the lowest-priority column is dropped first.
+	priority int
+	render   func(m Model, e entry.FileEntry) string
+}
+
+var columns = [numColumns]column{
+	colSize: {"size", "Size (apparent)", 9, 100, func(_ Model, e entry.FileEntry) string {
+		return formatSize(e.Size)
+	}},
+	colDisk: {"disk", "Size on disk", 9, 50, func(_ Model, e entry.FileEntry) string {
+		if e.DiskSize <= 0 {
+			return ""
+		}
+		return formatSize(e.DiskSize)
+	}},
+	colLines: {"lines", "Line count", 6, 60, func(m Model, e entry.FileEntry) string {
+		if m.vfs != nil && m.vfs.archive {
+			if e.DiskSize <= 0 {
+				return ""
+			}
+			return formatSize(e.DiskSize) + " " + padLeft(formatRatio(e.DiskSize, e.Size), 5)
+		}
+		if e.IsDir || e.LineCount <= 0 {
+			return ""
+		}
+		return formatCount(e.LineCount) + " l"
+	}},
+	colMTime: {"mtime", "Modified (relative)", 8, 40, func(_ Model, e entry.FileEntry) string {
+		return formatAge(e.ModTime, time.Now())
+	}},
+	colMTimeAbs: {"mtime-abs", "Modified (date)", 16, 30, func(_ Model, e entry.FileEntry) string {
+		if e.ModTime.IsZero() {
+			return ""
+		}
+		return e.ModTime.Format("2006-01-02 15:04")
+	}},
+	colOwner: {"owner", "Owner", 8, 15, func(_ Model, e entry.FileEntry) string {
+		return lookupOwner(e.UID)
+	}},
+	colGroup: {"group", "Group", 8, 10, func(_ Model, e entry.FileEntry) string {
+		return lookupGroup(e.GID)
+	}},
+	colMode: {"mode", "Permissions", 10, 20, func(_ Model, e entry.FileEntry) string {
+		if e.Mode == 0 && !e.IsDir {
+			return ""
+		}
+		return e.Mode.String()
+	}},
+	colFiles: {"files", "Child files", 6, 35, func(_ Model, e entry.FileEntry) string {
+		if !e.IsDir {
+			return ""
+		}
+		return formatCount(e.ChildFiles) + " f"
+	}},
+	colDirs: {"dirs", "Child dirs", 6, 25, func(_ Model, e entry.FileEntry) string {
+		if !e.IsDir {
+			return ""
+		}
+		return formatCount(e.ChildDirs) + " d"
+	}},
+	colInodes: {"inodes", "Inode count", 6, 22, func(_ Model, e entry.FileEntry) string {
+		return formatCount(e.Items()) + " i"
+	}},
+}
+
+// defaultColumns is the classic layout: size followed by the line count.
+var defaultColumns = []columnID{colSize, colLines}
+
+// widthIn returns the column's width in the current view. The line column
+// widens to hold "size ratio" inside archives.
+func (id columnID) widthIn(m Model) int {
+	if id == colLines && m.vfs != nil && m.vfs.archive {
+		return 15
+	}
+	return columns[id].width
+}
+
+// parseColumns parses a comma-separated column list such as "size,mtime,owner".
+func parseColumns(s string) ([]columnID, error) {
+	var out []columnID
+	seen := make(map[columnID]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		id, ok := columnByKey(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, columnKeys())
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+func columnByKey(key string) (columnID, bool) {
+	for id := range columns {
+		if columns[id].key == key {
+			return columnID(id), true
+		}
+	}
+	return 0, false
+}
+
+// columnKeys lists every column name for error messages and help text.
+func columnKeys() string {
+	keys := make([]string, numColumns)
+	for id := range columns {
+		keys[id] = columns[id].key
+	}
+	return strings.Join(keys, ", ")
+}
+
+// rowFixedWidth is the width of everything in a row except the bar, the
+// name and the optional columns:
+// pointer(2) + num(4) + sp(1) + sp(1) + pct(6) + sp(1) + sep(1) + sp(1) + icon(2).
+const rowFixedWidth = 19
+
+// minNameWidth is kept for the name before optional columns start dropping.
+const minNameWidth = 16
+
+// fitColumns returns the columns of m that fit in width w, in display order,
+// dropping the lowest-priority ones first, plus their total width (each
+// column counts one separating space). dst is reused to avoid allocation.
+func fitColumns(dst []columnID, m Model, w int) ([]columnID, int) {
+	dst = append(dst[:0], m.columns...)
+	total := 0
+	for _, id := range dst {
+		total += id.widthIn(m) + 1
+	}
+	for len(dst) > 0 && rowFixedWidth+total+6+minNameWidth > w {
+		drop := 0
+		for i, id := range dst {
+			if columns[id].priority < columns[dst[drop]].priority {
+				drop = i
+			}
+		}
+		total -= dst[drop].widthIn(m) + 1
+		dst = append(dst[:drop], dst[drop+1:]...)
+	}
+	return dst, total
+}
+
+// formatAge renders the time since t compactly: "now", "5m ago", "3h ago",
+// "12d ago", "4mo ago", "2y ago".
+func formatAge(t, now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return strconv.Itoa(int(d/time.Minute)) + "m ago"
+	case d < 24*time.Hour:
+		return strconv.Itoa(int(d/time.Hour)) + "h ago"
+	case d < 30*24*time.Hour:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d ago"
+	case d < 365*24*time.Hour:
+		return strconv.Itoa(int(d/(30*24*time.Hour))) + "mo ago"
+	default:
+		return strconv.Itoa(int(d/(365*24*time.Hour))) + "y ago"
+	}
+}
+
+// Owner and group names are looked up once per id; render runs on every frame.
+var (
+	idNamesMu  sync.Mutex
+	userNames  = make(map[int]string)
+	groupNames = make(map[int]string)
+)
+
+func lookupOwner(uid int) string {
+	return lookupID(uid, userNames, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	})
+}
+
+func lookupGroup(gid int) string {
+	return lookupID(gid, groupNames, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+		return g.Name, nil
+	})
+}
+
+// lookupID resolves id through lookup, caching the result (or the numeric id
+// when it has no name). Unknown ids (-1) render as "".
+func lookupID(id int, names map[int]string, lookup func(string) (string, error)) string {
+	if id < 0 {
+		return ""
+	}
+	idNamesMu.Lock()
+	defer idNamesMu.Unlock()
+	if name, ok := names[id]; ok {
+		return name
+	}
+	s := strconv.Itoa(id)
+	name, err := lookup(s)
+	if err != nil || name == "" {
+		name = s
+	}
+	names[id] = name
+	return name
+}
+
+// columnChooser is the in-app overlay for showing, hiding and reordering
+// columns. Its items are the enabled columns in display order followed by
+// the disabled ones.
+type columnChooser struct {
+	cursor int
+}
+
+// chooserItems returns every column: enabled ones first, in display order.
+func chooserItems(enabled []columnID) []columnID {
+	items := append([]columnID(nil), enabled...)
+	for id := columnID(0); id < numColumns; id++ {
+		if columnIndex(enabled, id) < 0 {
+			items = append(items, id)
+		}
+	}
+	return items
+}
+
+func columnIndex(cols []columnID, id columnID) int {
+	for i, c := range cols {
+		if c == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateColumnChooser handles keys while the column chooser is open:
+// ↑↓ move, space toggles, K/J move the column up/down, enter, escape, quit
+// or the columns key close.
+func (m Model) updateColumnChooser(msg tea.KeyMsg) (Model, tea.Cmd) {
+	c := m.colChooser
+	items := chooserItems(m.columns)
+	switch k := msg.String(); {
+	case key.Matches(msg, m.keys.Columns, m.keys.Escape, m.keys.Quit) || k == "enter":
+		m.colChooser = nil
+	case key.Matches(msg, m.keys.Up):
+		if c.cursor > 0 {
+			c.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if c.cursor < len(items)-1 {
+			c.cursor++
+		}
+	case k == " " || k == "x":
+		id := items[c.cursor]
+		if i := columnIndex(m.columns, id); i >= 0 {
+			m.columns = append(m.columns[:i:i], m.columns[i+1:]...)
+			// Keep the cursor on the same column, now first among the disabled
+			c.cursor = columnIndex(chooserItems(m.columns), id)
+		} else {
+			m.columns = append(m.columns[:len(m.columns):len(m.columns)], id)
+			c.cursor = len(m.columns) - 1
+		}
+	case k == "K" || k == "shift+up":
+		if c.cursor > 0 && c.cursor < len(m.columns) {
+			m.columns = append([]columnID(nil), m.columns...)
+			m.columns[c.cursor-1], m.columns[c.cursor] = m.columns[c.cursor], m.columns[c.cursor-1]
+			c.cursor--
+		}
+	case k == "J" || k == "shift+down":
+		if c.cursor < len(m.columns)-1 {
+			m.columns = append([]columnID(nil), m.columns...)
+			m.columns[c.cursor+1], m.columns[c.cursor] = m.columns[c.cursor], m.columns[c.cursor+1]
+			c.cursor++
+		}
+	}
+	return m, nil
+}
+
+// renderColumnChooser renders the column chooser overlay.
+func renderColumnChooser(m Model) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(helpTitleStyle.Render("  Columns"))
+	b.WriteString("\n\n")
+
+	for i, id := range chooserItems(m.columns) {
+		check := "[ ] "
+		if columnIndex(m.columns, id) >= 0 {
+			check = "[x] "
+		}
+		pointer := "  "
+		if i == m.colChooser.cursor {
+			pointer = "▶ "
+		}
+		b.WriteString(rowPointerActiveStyle.Render(pointer))
+		b.WriteString(helpKeyStyle.Render(check + columns[id].key))
+		b.WriteString(helpDescStyle.Render(columns[id].title))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(footerStyle.Render("  ␣ toggle  K/J move  " + m.keys.closeLabel(m.keys.Columns) + " close"))
+	b.WriteString("\n")
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorCyan).
+		Padding(0, 2).
+		Width(minInt(50, m.width-4))
+
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(b.String()))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestParseColumns(t *testing.T) {
+	cols, err := parseColumns("size, MTime,owner,size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 3 || cols[0] != colSize || cols[1] != colMTime || cols[2] != colOwner {
+		t.Errorf("unexpected columns: %v", cols)
+	}
+	if _, err := parseColumns("size,bogus"); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("expected unknown column error, got %v", err)
+	}
+}
+
+func TestFitColumnsDropsLowPriority(t *testing.T) {
+	m := makeTestModel(5)
+	m.columns = []columnID{colOwner, colSize, colMTime, colLines}
+
+	cols, _ := fitColumns(nil, m, 200)
+	if len(cols) != 4 {
+		t.Fatalf("expected all columns on a wide terminal, got %v", cols)
+	}
+	cols, width := fitColumns(nil, m, 60)
+	// owner (15) and mtime (40) go first; order of the rest is preserved
+	if len(cols) != 2 || cols[0] != colSize || cols[1] != colLines {
+		t.Errorf("unexpected columns at width 60: %v", cols)
+	}
+	if width != 9+1+6+1 {
+		t.Errorf("unexpected columns width %d", width)
+	}
+}
+
+func TestRenderRowFitsWidth(t *testing.T) {
+	m := makeTestModel(5)
+	m.columns = []columnID{colSize, colDisk, colLines, colMTime, colMTimeAbs, colOwner, colGroup, colMode, colFiles, colDirs, colInodes}
+	e := entry.New("a-rather-long-file-name.txt")
+	e.Size, e.ModTime = 1234, time.Now()
+	for _, w := range []int{40, 60, 80, 120, 200} {
+		m.width = w
+		if got := lipgloss.Width(renderRow(m, 0, e, true)); got != w {
+			t.Errorf("row at width %d rendered %d cells", w, got)
+		}
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{10 * time.Second, "now"},
+		{5 * time.Minute, "5m ago"},
+		{3 * time.Hour, "3h ago"},
+		{12 * 24 * time.Hour, "12d ago"},
+		{100 * 24 * time.Hour, "3mo ago"},
+		{800 * 24 * time.Hour, "2y ago"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("formatAge(-%v) = %q, want %q", tt.ago, got, tt.want)
+		}
+	}
+	if formatAge(time.Time{}, now) != "" {
+		t.Error("zero time should render empty")
+	}
+}
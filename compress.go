@@ -0,0 +1,425 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// archiveFormat selects the container/compression used by the compress action.
+type archiveFormat int
+
+const (
+	formatTarGz  archiveFormat = iota // tar + gzip
+	formatTarZst                      // tar + zstandard
+)
+
+// ext returns the file extension for the format, including the leading dot.
+func (f archiveFormat) ext() string {
+	if f == formatTarZst {
+		return ".tar.zst"
+	}
+	return ".tar.gz"
+}
+
+// CompressProgress holds live counters updated by the compress goroutine.
+// Total is fixed before the goroutine starts; Done is read via atomic loads.
+type CompressProgress struct {
+	Done  atomic.Int64
+	Total int64
+}
+
+// compressResultMsg is sent when a compress (and optional verify) completes.
+type compressResultMsg struct {
+	err         error
+	dir         string // directory containing the source and archive
+	name        string // source entry name
+	archive     string // archive file name (relative to dir)
+	srcSize     int64
+	archiveSize int64
+	verified    bool
+	isDir       bool
+}
+
+// ratio returns the archive size as a percentage of the source size.
+func (r compressResultMsg) ratio() float64 {
+	if r.srcSize <= 0 {
+		return 0
+	}
+	return float64(r.archiveSize) / float64(r.srcSize) * 100
+}
+
+// compressStage tracks where the compress action is in its lifecycle.
+type compressStage int
+
+const (
+	compressChoose  compressStage = iota // picking format / verify
+	compressRunning                      // archive being written
+	compressConfirm                      // done, asking whether to trash the original
+)
+
+// compressState is the UI state of an in-flight compress action.
+type compressState struct {
+	stage  compressStage
+	entry  entry.FileEntry
+	format archiveFormat
+	verify bool
+	prog   *CompressProgress
+	cancel context.CancelFunc
+	result compressResultMsg
+}
+
+// uniqueArchiveName returns name+ext, or name-N+ext if that already exists in dir.
+func uniqueArchiveName(dir, name string, format archiveFormat) string {
+	candidate := name + format.ext()
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = name + "-" + strconv.Itoa(i) + format.ext()
+		if i > 1000 {
+			return candidate
+		}
+	}
+}
+
+// compressCmd packs dir/name into an archive next to it, then optionally
+// re-reads the archive to verify it. The archive is written to a temporary
+// file and only renamed into place on success.
+func compressCmd(ctx context.Context, dir string, e entry.FileEntry, format archiveFormat, verify bool, prog *CompressProgress) tea.Cmd {
+	return func() tea.Msg {
+		res := compressResultMsg{dir: dir, name: e.Name, srcSize: e.Size, isDir: e.IsDir}
+		res.archive = uniqueArchiveName(dir, e.Name, format)
+		dst := filepath.Join(dir, res.archive)
+		tmp := dst + ".partial"
+
+		members, bytes, err := writeArchive(ctx, filepath.Join(dir, e.Name), tmp, format, prog)
+		if err != nil {
+			os.Remove(tmp)
+			res.err = fmt.Errorf("compress failed: %w", err)
+			return res
+		}
+		if verify {
+			if err := verifyArchive(ctx, tmp, format, members, bytes); err != nil {
+				os.Remove(tmp)
+				res.err = fmt.Errorf("verify failed: %w", err)
+				return res
+			}
+			res.verified = true
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			os.Remove(tmp)
+			res.err = fmt.Errorf("compress failed: %w", err)
+			return res
+		}
+		if info, err := os.Stat(dst); err == nil {
+			res.archiveSize = info.Size()
+		}
+		return res
+	}
+}
+
+// writeArchive writes src (file or directory tree) as a tar stream compressed
+// with format into dst. Returns the number of tar members and regular-file
+// bytes written so the archive can be verified afterwards.
+func writeArchive(ctx context.Context, src, dst string, format archiveFormat, prog *CompressProgress) (members int, total int64, err error) {
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	var zw io.WriteCloser
+	switch format {
+	case formatTarZst:
+		zw, err = zstd.NewWriter(out)
+		if err != nil {
+			return 0, 0, err
+		}
+	default:
+		zw = gzip.NewWriter(out)
+	}
+	tw := tar.NewWriter(zw)
+
+	base := filepath.Dir(src)
+	walkErr := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		members++
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(tw, &progressReader{ctx: ctx, r: f, prog: prog})
+		f.Close()
+		total += n
+		return err
+	})
+	if walkErr != nil {
+		return 0, 0, walkErr
+	}
+	if err := tw.Close(); err != nil {
+		return 0, 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, 0, err
+	}
+	return members, total, nil
+}
+
+// verifyArchive decompresses the archive at path end to end and checks that
+// it contains the expected number of members and file bytes.
+func verifyArchive(ctx context.Context, path string, format archiveFormat, wantMembers int, wantBytes int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var zr io.Reader
+	switch format {
+	case formatTarZst:
+		d, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer d.Close()
+		zr = d
+	default:
+		g, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+		zr = g
+	}
+
+	tr := tar.NewReader(zr)
+	members := 0
+	var total int64
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		members++
+		if hdr.Typeflag == tar.TypeReg {
+			n, err := io.Copy(io.Discard, tr)
+			if err != nil {
+				return err
+			}
+			total += n
+		}
+	}
+	if members != wantMembers || total != wantBytes {
+		return fmt.Errorf("archive has %d members / %s, expected %d / %s",
+			members, formatSize(total), wantMembers, formatSize(wantBytes))
+	}
+	return nil
+}
+
+// progressReader counts bytes read into prog and aborts when ctx is cancelled.
+type progressReader struct {
+	ctx  context.Context
+	r    io.Reader
+	prog *CompressProgress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	if p.prog != nil && n > 0 {
+		p.prog.Done.Add(int64(n))
+	}
+	return n, err
+}
+
+// --- Model integration ---
+
+// startCompress opens the compress prompt for the selected entry.
+func (m Model) startCompress() (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	m.compress = &compressState{
+		stage:  compressChoose,
+		entry:  m.filtered[m.cursor],
+		format: formatTarZst,
+		verify: true,
+	}
+	return m, nil
+}
+
+// updateCompress handles keys while the compress action is active.
+func (m Model) updateCompress(msg tea.KeyMsg) (Model, tea.Cmd) {
+	c := m.compress
+	switch c.stage {
+	case compressChoose:
+		switch msg.String() {
+		case "g":
+			c.format = formatTarGz
+		case "z":
+			c.format = formatTarZst
+		case "v":
+			c.verify = !c.verify
+		case "enter":
+			ctx, cancel := context.WithCancel(context.Background())
+			c.stage = compressRunning
+			c.cancel = cancel
+			c.prog = &CompressProgress{Total: c.entry.Size}
+			return m, tea.Batch(compressCmd(ctx, m.path, c.entry, c.format, c.verify, c.prog), m.spinner.Tick)
+		case "esc":
+			m.compress = nil
+		}
+	case compressRunning:
+		if key := msg.String(); key == "esc" || key == "ctrl+c" {
+			c.cancel()
+		}
+	case compressConfirm:
+		switch msg.String() {
+		case "y":
+			r := c.result
+			m.compress = nil
+			return m, trashCmd(filepath.Join(r.dir, r.name), r.name, r.srcSize, r.isDir)
+		case "n", "esc":
+			m.compress = nil
+		}
+	}
+	return m, nil
+}
+
+// applyCompressResult records a finished compress and adds the new archive
+// to the listing without a full rescan.
+func (m Model) applyCompressResult(msg compressResultMsg) Model {
+	if m.compress != nil && m.compress.cancel != nil {
+		m.compress.cancel()
+	}
+	if msg.err != nil {
+		m.compress = nil
+		if !errors.Is(msg.err, context.Canceled) {
+			m.err = msg.err
+		}
+		return m
+	}
+	if msg.dir != m.path {
+		m.compress = nil
+		m.cache.Delete(msg.dir)
+		return m
+	}
+
+	e, err := scan.File(scan.DirFS(msg.dir), msg.archive)
+	if err != nil {
+		e = entry.New(msg.archive)
+		e.Size = msg.archiveSize
+		e.IsBinary = true
+	}
+	m.addEntry(e)
+	if m.compress != nil {
+		m.compress.stage = compressConfirm
+		m.compress.result = msg
+	}
+	return m
+}
+
+// renderCompressFooter renders the footer line while the compress action is active.
+func renderCompressFooter(m Model) string {
+	c := m.compress
+	name := truncateStrVisual(c.entry.Name, maxInt(8, m.width/4))
+	switch c.stage {
+	case compressChoose:
+		gz, zst := "gzip", "zstd"
+		if c.format == formatTarGz {
+			gz = "▸" + gz
+		} else {
+			zst = "▸" + zst
+		}
+		verify := "off"
+		if c.verify {
+			verify = "on"
+		}
+		return searchPromptStyle.Render(" compress ") + headerPathStyle.Render(name) +
+			footerDescStyle.Render(" → ") +
+			footerKeyStyle.Render("g") + footerDescStyle.Render(" "+gz+"  ") +
+			footerKeyStyle.Render("z") + footerDescStyle.Render(" "+zst+"  ") +
+			footerKeyStyle.Render("v") + footerDescStyle.Render(" verify: "+verify+"  ⏎ start  esc cancel")
+	case compressRunning:
+		done := c.prog.Done.Load()
+		pct := 0.0
+		if c.prog.Total > 0 {
+			pct = float64(done) / float64(c.prog.Total) * 100
+			if pct > 100 {
+				pct = 100
+			}
+		}
+		return searchPromptStyle.Render(" "+m.spinner.View()+"compressing ") + headerPathStyle.Render(name) + " " +
+			m.bar(pct, 20) + " " +
+			footerDescStyle.Render(fmt.Sprintf("%.0f%%  %s / %s  esc cancel", pct, formatSize(done), formatSize(c.prog.Total)))
+	default:
+		r := c.result
+		status := fmt.Sprintf(" %s (%.1f%% of %s", formatSize(r.archiveSize), r.ratio(), formatSize(r.srcSize))
+		if r.verified {
+			status += ", verified"
+		}
+		status += ")"
+		return searchPromptStyle.Render(" ✓ ") + headerPathStyle.Render(truncateStrVisual(r.archive, maxInt(8, m.width/4))) +
+			footerDescStyle.Render(status+"  trash original? ") +
+			footerKeyStyle.Render("y") + footerDescStyle.Render("/") + footerKeyStyle.Render("n")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestWriteAndVerifyArchive(t *testing.T) {
+	for _, format := range []archiveFormat{formatTarGz, formatTarZst} {
+		t.Run(format.ext(), func(t *testing.T) {
+			dir := makeTempDir(t, 3, 2, 4)
+			dst := filepath.Join(t.TempDir(), "out"+format.ext())
+			prog := &CompressProgress{}
+
+			members, total, err := writeArchive(context.Background(), dir, dst, format, prog)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// root + 3 files + 2 subdirs + 8 subfiles
+			if members != 14 {
+				t.Errorf("expected 14 members, got %d", members)
+			}
+			if prog.Done.Load() != total {
+				t.Errorf("progress %d != bytes written %d", prog.Done.Load(), total)
+			}
+			if err := verifyArchive(context.Background(), dst, format, members, total); err != nil {
+				t.Errorf("verify failed: %v", err)
+			}
+			if err := verifyArchive(context.Background(), dst, format, members+1, total); err == nil {
+				t.Error("expected verify to fail on member count mismatch")
+			}
+		})
+	}
+}
+
+func TestWriteArchiveCancelled(t *testing.T) {
+	dir := makeTempDir(t, 3, 0, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := writeArchive(ctx, dir, filepath.Join(t.TempDir(), "x.tar.gz"), formatTarGz, nil); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestUniqueArchiveName(t *testing.T) {
+	dir := t.TempDir()
+	if got := uniqueArchiveName(dir, "data", formatTarGz); got != "data.tar.gz" {
+		t.Errorf("got %q", got)
+	}
+	os.WriteFile(filepath.Join(dir, "data.tar.gz"), nil, 0o644)
+	if got := uniqueArchiveName(dir, "data", formatTarGz); got != "data-1.tar.gz" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCompressCmd(t *testing.T) {
+	dir := makeTempDir(t, 0, 1, 5)
+	e := entry.FileEntry{Name: "subdir_0000", IsDir: true, Size: 40}
+	msg := compressCmd(context.Background(), dir, e, formatTarZst, true, &CompressProgress{Total: 40})()
+	res, ok := msg.(compressResultMsg)
+	if !ok {
+		t.Fatalf("expected compressResultMsg, got %T", msg)
+	}
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if !res.verified || res.archive != "subdir_0000.tar.zst" || res.archiveSize <= 0 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(dir, res.archive+".partial")); !os.IsNotExist(err) {
+		t.Error("temporary archive should have been renamed")
+	}
+}
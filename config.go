@@ -0,0 +1,446 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mohsinkaleem/dirgo/cache"
+)
+
+// config is the user's settings file. Settings left out keep their
+// defaults, and a missing file is the default config.
+//
+//	show_hidden = false
+//	columns = ["size", "mtime"]
+//
+//	[[dir]]
+//	path = "~/src"
+//	top_n = 25
+//
+//	[[action]]
+//	key = "X"
+//	label = "docker prune"
+//	command = "docker system prune -f"
+//	on = "dir"
+//	refresh = true
+//
+//	[filters]
+//	junk = "ext:log,tmp,bak age>30d"
+type config struct {
+	settings
+	Keys    map[string]keyList `toml:"keys"`              // binding name to keys, see KeyMap.named
+	Filters map[string]string  `toml:"filters,omitempty"` // saved search queries, used as @name
+	Dirs    []dirOverride      `toml:"dir"`
+	Actions []actionConfig     `toml:"action"`
+
+	path string // file the config was read from, "" for the defaults
+}
+
+// settings are the tunables a config file can change.
+type settings struct {
+	ShowHidden    bool      `toml:"show_hidden"`
+	TopN          int       `toml:"top_n"` // entries in the top view (t)
+	Columns       []string  `toml:"columns"`
+	CacheSize     int       `toml:"cache_size"`     // directory listings kept for instant navigation
+	HistorySize   int       `toml:"history_size"`   // directories in the back/forward history, and whose cursor and sort are remembered
+	MaxOpenSize   byteSize  `toml:"max_open_size"`  // larger binaries are not handed to the OS
+	LineCountMax  byteSize  `toml:"line_count_max"` // larger files are not line-counted
+	BarThresholds []float64 `toml:"bar_thresholds"` // percentages at which bars turn red, orange, yellow, green
+	Theme         string    `toml:"theme"`          // built-in theme, theme file, or "auto"
+	RankSearch    bool      `toml:"rank_search"`    // order search results by match quality rather than the sort mode
+}
+
+// defaultSettings are the built-in settings.
+func defaultSettings() settings {
+	return settings{
+		ShowHidden:    true,
+		TopN:          10,
+		Columns:       []string{"size", "lines"},
+		CacheSize:     100,
+		HistorySize:   500,
+		MaxOpenSize:   100 << 20,
+		LineCountMax:  10 << 20,
+		BarThresholds: []float64{40, 20, 10, 2},
+		Theme:         "auto",
+	}
+}
+
+// dirOverride changes settings below one directory. Unset fields keep the
+// global value.
+type dirOverride struct {
+	Path       string   `toml:"path"`
+	ShowHidden *bool    `toml:"show_hidden"`
+	TopN       *int     `toml:"top_n"`
+	Columns    []string `toml:"columns,omitempty"`
+}
+
+// keyList is one key or a list of keys in the [keys] table.
+type keyList []string
+
+func (l *keyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*l = keyList{v}
+		return nil
+	case []any:
+		*l = make(keyList, len(v))
+		for i, k := range v {
+			s, ok := k.(string)
+			if !ok {
+				return fmt.Errorf("key %v must be a string", k)
+			}
+			(*l)[i] = s
+		}
+		return nil
+	}
+	return fmt.Errorf("keys must be a string or a list of strings, not %v", v)
+}
+
+// byteSize is a size setting, written as a number of bytes or with a unit
+// such as "100M".
+type byteSize int64
+
+func (b *byteSize) UnmarshalText(text []byte) error {
+	n, err := parseSize(string(text))
+	*b = byteSize(n)
+	return err
+}
+
+func (b byteSize) MarshalText() ([]byte, error) {
+	return []byte(formatSizeExact(int64(b))), nil
+}
+
+// actionConfig is a custom command bound to a key; see actions.go.
+type actionConfig struct {
+	Key     string `toml:"key"`
+	Label   string `toml:"label"`
+	Command string `toml:"command"` // shell command with {path} {name} {dir} {marked}
+	Mode    string `toml:"mode"`    // "background" (default) or "foreground"
+	On      string `toml:"on"`      // "any" (default), "file" or "dir"
+	Refresh bool   `toml:"refresh"` // rescan the directory when the command exits
+}
+
+const (
+	actionBackground = "background"
+	actionForeground = "foreground"
+)
+
+// configDir is $XDG_CONFIG_HOME/dirgo, falling back to ~/.config/dirgo
+// (the platform config directory on Windows).
+func configDir() string {
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return filepath.Join(d, "dirgo")
+	}
+	if runtime.GOOS == "windows" {
+		if d, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(d, "dirgo")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "dirgo")
+}
+
+// stateDir is $XDG_STATE_HOME/dirgo, falling back to ~/.local/state/dirgo
+// (the local app data directory on Windows). It holds what dirgo remembers
+// between sessions, such as the navigation history.
+func stateDir() string {
+	if d := os.Getenv("XDG_STATE_HOME"); d != "" {
+		return filepath.Join(d, "dirgo")
+	}
+	if runtime.GOOS == "windows" {
+		if d, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(d, "dirgo")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "dirgo")
+}
+
+// defaultConfigPath is the settings file read when --config is not given:
+// config.toml in configDir, or a file named just config.
+func defaultConfigPath() string {
+	d := configDir()
+	if d == "" {
+		return ""
+	}
+	path := filepath.Join(d, "config.toml")
+	if _, err := os.Stat(path); err != nil {
+		if _, err := os.Stat(filepath.Join(d, "config")); err == nil {
+			return filepath.Join(d, "config")
+		}
+	}
+	return path
+}
+
+// loadConfig reads and validates the TOML settings file at path. Unknown
+// settings are errors so that typos are not silently ignored.
+func loadConfig(path string) (config, error) {
+	c := config{settings: defaultSettings()}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	md, err := toml.Decode(string(data), &c)
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+		return c, fmt.Errorf("%s: unknown setting %s", path, strings.Join(keys, ", "))
+	}
+	c.path = path
+	c.setDefaults()
+	if err := c.validate(); err != nil {
+		return c, fmt.Errorf("%s:\n  %s", path, strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return c, nil
+}
+
+// setDefaults fills in optional fields left empty and expands ~ in paths.
+func (c *config) setDefaults() {
+	for i := range c.Dirs {
+		c.Dirs[i].Path = expandHome(c.Dirs[i].Path)
+	}
+	for i := range c.Actions {
+		a := &c.Actions[i]
+		if ks, err := parseKeySpec(a.Key); err == nil {
+			a.Key = ks
+		}
+		if a.Mode == "" {
+			a.Mode = actionBackground
+		}
+		if a.On == "" {
+			a.On = "any"
+		}
+		if a.Label == "" {
+			a.Label = a.Command
+		}
+	}
+}
+
+// keyMap returns the default bindings with the [keys] table applied, and
+// the problems found in it.
+func (c config) keyMap() (KeyMap, []error) {
+	km := DefaultKeyMap()
+	names := make([]string, 0, len(c.Keys))
+	for name := range c.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		if err := km.remap(name, c.Keys[name]); err != nil {
+			errs = append(errs, fmt.Errorf("keys.%s: %w", name, err))
+		}
+	}
+	return km, errs
+}
+
+// validate reports every problem in c at once, one per line.
+func (c config) validate() error {
+	km, errs := c.keyMap()
+	bad := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	s := c.settings
+	if s.TopN < 1 {
+		bad("top_n: %d must be at least 1", s.TopN)
+	}
+	if _, err := parseColumns(strings.Join(s.Columns, ",")); err != nil {
+		bad("columns: %v", err)
+	}
+	if s.CacheSize < 1 {
+		bad("cache_size: %d must be at least 1", s.CacheSize)
+	}
+	if s.HistorySize < 1 {
+		bad("history_size: %d must be at least 1", s.HistorySize)
+	}
+	if s.MaxOpenSize <= 0 {
+		bad("max_open_size: must be positive")
+	}
+	if s.LineCountMax <= 0 {
+		bad("line_count_max: must be positive")
+	}
+	if len(s.BarThresholds) != 4 {
+		bad("bar_thresholds: want 4 percentages (red, orange, yellow, green), got %d", len(s.BarThresholds))
+	} else {
+		for i, t := range s.BarThresholds {
+			if t < 0 || t > 100 || i > 0 && t >= s.BarThresholds[i-1] {
+				bad("bar_thresholds: %v must be decreasing percentages", s.BarThresholds)
+				break
+			}
+		}
+	}
+	if s.Theme != "auto" {
+		if _, err := loadTheme(s.Theme); err != nil {
+			bad("theme: %v", err)
+		}
+	}
+
+	for _, name := range filterNames(c.Filters) {
+		switch {
+		case name == "" || strings.ContainsAny(name, " \t\"@!"):
+			bad("filters: %q is not a usable name", name)
+		default:
+			if _, err := parseQuery(c.Filters[name], c.Filters, time.Now()); err != nil {
+				bad("filters.%s: %v", name, err)
+			}
+		}
+	}
+
+	dirs := make(map[string]int)
+	for i, d := range c.Dirs {
+		switch {
+		case d.Path == "":
+			bad("dir %d: path is required", i+1)
+		case !filepath.IsAbs(d.Path):
+			bad("dir %d (%s): path must be absolute or start with ~", i+1, d.Path)
+		case dirs[d.Path] > 0:
+			bad("dir %d (%s): also configured by dir %d", i+1, d.Path, dirs[d.Path])
+		default:
+			dirs[d.Path] = i + 1
+		}
+		if d.TopN != nil && *d.TopN < 1 {
+			bad("dir %d (%s): top_n %d must be at least 1", i+1, d.Path, *d.TopN)
+		}
+		if _, err := parseColumns(strings.Join(d.Columns, ",")); err != nil {
+			bad("dir %d (%s): columns: %v", i+1, d.Path, err)
+		}
+	}
+
+	var actionKeys []namedBinding
+	for i, a := range c.Actions {
+		name := fmt.Sprintf("action %d (%s)", i+1, a.Label)
+		prefix := name + ": "
+		if a.Key == "" {
+			bad("%skey is required", prefix)
+		} else if ks, err := parseKeySpec(a.Key); err != nil {
+			bad("%s%v", prefix, err)
+		} else {
+			b := bind(a.Label, ks)
+			actionKeys = append(actionKeys, namedBinding{name: name, b: &b})
+		}
+		if strings.TrimSpace(a.Command) == "" {
+			bad("%scommand is required", prefix)
+		}
+		if a.Mode != actionBackground && a.Mode != actionForeground {
+			bad("%smode %q must be background or foreground", prefix, a.Mode)
+		}
+		if a.On != "any" && a.On != "file" && a.On != "dir" {
+			bad("%son %q must be any, file or dir", prefix, a.On)
+		}
+	}
+	errs = append(errs, km.conflicts(actionKeys)...)
+	return errors.Join(errs...)
+}
+
+// forDir returns the settings in effect in dir and the index of the [[dir]]
+// override that applies, the deepest one containing dir, or -1.
+func (c config) forDir(dir string) (settings, int) {
+	match := -1
+	for i, d := range c.Dirs {
+		if d.Path != dir && !strings.HasPrefix(dir, strings.TrimSuffix(d.Path, string(filepath.Separator))+string(filepath.Separator)) {
+			continue
+		}
+		if match < 0 || len(d.Path) > len(c.Dirs[match].Path) {
+			match = i
+		}
+	}
+	s := c.settings
+	if match < 0 {
+		return s, match
+	}
+	d := c.Dirs[match]
+	if d.ShowHidden != nil {
+		s.ShowHidden = *d.ShowHidden
+	}
+	if d.TopN != nil {
+		s.TopN = *d.TopN
+	}
+	if d.Columns != nil {
+		s.Columns = d.Columns
+	}
+	return s, match
+}
+
+// dump writes the effective config as TOML.
+func (c config) dump(w io.Writer) error {
+	if c.path == "" {
+		fmt.Fprintf(w, "# dirgo defaults (no config file at %s)\n\n", defaultConfigPath())
+	} else {
+		fmt.Fprintf(w, "# dirgo settings from %s\n\n", c.path)
+	}
+	// Show every binding, not just the remapped ones
+	km, _ := c.keyMap()
+	c.Keys = make(map[string]keyList)
+	for _, nb := range km.named() {
+		keys := make(keyList, len(nb.b.Keys()))
+		for i, ks := range nb.b.Keys() {
+			if ks == " " {
+				ks = "space"
+			}
+			keys[i] = ks
+		}
+		c.Keys[nb.name] = keys
+	}
+	return toml.NewEncoder(w).Encode(c)
+}
+
+// withConfig applies c to a new model.
+func (m Model) withConfig(c config) Model {
+	m.config = c
+	m.settings = c.settings
+	m.keys, _ = c.keyMap()
+	m.actions = c.Actions
+	m.cache = cache.New[scanResultMsg](c.CacheSize)
+	m.showHidden = c.ShowHidden
+	m.rankSearch = c.RankSearch
+	m.columns, _ = parseColumns(strings.Join(c.Columns, ","))
+	m.dirOverride, m.settingsPath = -1, ""
+	return m.applyDirSettings()
+}
+
+// applyDirSettings switches to the settings of the [[dir]] override covering
+// m.path when browsing crosses into or out of one. Toggles made while
+// browsing, such as h, last until then.
+func (m Model) applyDirSettings() Model {
+	if len(m.config.Dirs) == 0 || m.path == m.settingsPath {
+		return m
+	}
+	m.settingsPath = m.path
+	s, match := m.config.forDir(m.path)
+	if match == m.dirOverride {
+		return m
+	}
+	m.dirOverride = match
+	m.settings = s
+	m.showHidden = s.ShowHidden
+	m.columns, _ = parseColumns(strings.Join(s.Columns, ","))
+	m.applyFilter()
+	return m
+}
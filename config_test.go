@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+[[action]]
+key = "X"
+label = "prune"
+command = "docker system prune -f"
+on = "dir"
+refresh = true
+
+[[action]]
+key = "ctrl+f"
+command = "ffprobe {path}"
+mode = "foreground"
+`), 0o644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Actions) != 2 {
+		t.Fatalf("got %d actions", len(c.Actions))
+	}
+	if a := c.Actions[0]; a.Mode != actionBackground || a.On != "dir" || !a.Refresh {
+		t.Errorf("first action: %+v", a)
+	}
+	if a := c.Actions[1]; a.Mode != actionForeground || a.On != "any" || a.Label != a.Command {
+		t.Errorf("defaults not applied: %+v", a)
+	}
+
+	// A missing file is an empty config
+	if c, err := loadConfig(filepath.Join(t.TempDir(), "none.toml")); err != nil || len(c.Actions) != 0 {
+		t.Errorf("missing file: %+v %v", c, err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"syntax", "[[action]\n", "config.toml"},
+		{"unknown", "[[action]]\nkey = \"X\"\ncommand = \"ls\"\nrefesh = true\n", "unknown setting action.refesh"},
+		{"builtin key", "[[action]]\nkey = \"d\"\ncommand = \"ls\"\n", `key "d" is bound to both delete and action 1 (ls)`},
+		{"duplicate", "[[action]]\nkey = \"X\"\ncommand = \"ls\"\n[[action]]\nkey = \"X\"\ncommand = \"pwd\"\n", `key "X" is bound to both action 1 (ls) and action 2 (pwd)`},
+		{"no command", "[[action]]\nkey = \"X\"\n", "command is required"},
+		{"mode", "[[action]]\nkey = \"X\"\ncommand = \"ls\"\nmode = \"later\"\n", "must be background or foreground"},
+		{"top_n", "top_n = 0\n", "top_n: 0 must be at least 1"},
+		{"size", "max_open_size = \"lots\"\n", `invalid size "lots"`},
+		{"thresholds", "bar_thresholds = [10, 20, 30, 40]\n", "must be decreasing"},
+		{"columns", "columns = [\"size\", \"colour\"]\n", `unknown column "colour"`},
+		{"dir path", "[[dir]]\npath = \"src\"\n", "path must be absolute"},
+		{"theme", "theme = \"solarized\"\n", `theme: unknown theme "solarized"`},
+		{"filter", "[filters]\nbig = \"size>lots\"\n", `filters.big: size: invalid size "lots"`},
+		{"filter loop", "[filters]\na = \"@b\"\nb = \"@a\"\n", "in a loop"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		os.WriteFile(path, []byte(tt.content), 0o644)
+		_, err := loadConfig(path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Every problem is reported, not just the first
+	c := config{settings: defaultSettings(), Actions: []actionConfig{{Mode: "x", On: "y"}}}
+	err := c.validate()
+	if err == nil || strings.Count(err.Error(), "\n") != 3 {
+		t.Errorf("got %v, want four errors", err)
+	}
+}
+
+func TestConfigDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if got := defaultConfigPath(); got != filepath.Join("/tmp/xdg", "dirgo", "config.toml") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestConfigSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+show_hidden = false
+columns = ["size", "mtime"]
+max_open_size = "1.5G"
+line_count_max = 4096
+bar_thresholds = [50, 25, 5, 1]
+
+[[dir]]
+path = "/srv"
+show_hidden = true
+
+[[dir]]
+path = "/srv/logs"
+top_n = 3
+columns = ["lines"]
+`), 0o644)
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ShowHidden || c.TopN != 10 || c.CacheSize != 100 || c.MaxOpenSize != 3<<29 || c.LineCountMax != 4096 {
+		t.Errorf("settings: %+v", c.settings)
+	}
+
+	// The deepest override applies; unset fields come from the top level
+	s, i := c.forDir("/srv/logs/app")
+	if i != 1 || s.ShowHidden || s.TopN != 3 || strings.Join(s.Columns, ",") != "lines" {
+		t.Errorf("/srv/logs/app: override %d, %+v", i, s)
+	}
+	if s, i := c.forDir("/srv"); i != 0 || !s.ShowHidden || s.TopN != 10 {
+		t.Errorf("/srv: override %d, %+v", i, s)
+	}
+	if _, i := c.forDir("/srvx"); i != -1 {
+		t.Errorf("/srvx matched override %d", i)
+	}
+
+	// The dump reads back as the same config
+	var b strings.Builder
+	if err := c.dump(&b); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, []byte(b.String()), 0o644)
+	again, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, b.String())
+	}
+	if fmt.Sprint(again.settings, again.Dirs[1].Path) != fmt.Sprint(c.settings, c.Dirs[1].Path) {
+		t.Errorf("dump round trip:\n%s", b.String())
+	}
+}
+
+func TestDirSettingsFollowPath(t *testing.T) {
+	hidden := false
+	top := 2
+	c := config{settings: defaultSettings(), Dirs: []dirOverride{{Path: "/data/media", ShowHidden: &hidden, TopN: &top}}}
+	m := NewModel("/data").withConfig(c)
+	if !m.showHidden || m.settings.TopN != 10 {
+		t.Fatalf("outside the override: hidden=%v top=%d", m.showHidden, m.settings.TopN)
+	}
+
+	m.path = "/data/media/films"
+	m = m.applyDirSettings()
+	if m.showHidden || m.settings.TopN != 2 {
+		t.Fatalf("inside the override: hidden=%v top=%d", m.showHidden, m.settings.TopN)
+	}
+
+	// A toggle lasts while browsing inside the same override
+	m.showHidden = true
+	m.path = "/data/media"
+	if m = m.applyDirSettings(); !m.showHidden {
+		t.Error("toggle reset within the override")
+	}
+	m.path = "/data"
+	if m = m.applyDirSettings(); !m.showHidden || m.settings.TopN != 10 {
+		t.Errorf("leaving the override: hidden=%v top=%d", m.showHidden, m.settings.TopN)
+	}
+}
+
+func TestBarThresholdsPerModel(t *testing.T) {
+	c := config{settings: defaultSettings()}
+	c.BarThresholds = []float64{90, 80, 70, 60}
+	custom := NewModel("/").withConfig(c)
+	plain := NewModel("/")
+
+	// Configuring one model leaves the others' colors alone
+	if got := barLevelOf(50, plain.settings.BarThresholds); got != barRed {
+		t.Errorf("default thresholds: 50%% is level %d, want red", got)
+	}
+	if got := barLevelOf(50, custom.settings.BarThresholds); got != barDim {
+		t.Errorf("custom thresholds: 50%% is level %d, want dim", got)
+	}
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/dupes"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// errNoReflink is returned by cloneFile where reflinks are not supported.
+var errNoReflink = errors.New("the filesystem does not support reflinks")
+
+// dupeAction is what to do with the copies in a group other than the one
+// kept.
+type dupeAction int
+
+const (
+	dupeNone    dupeAction = iota
+	dupeTrash              // move them to the trash
+	dupeLink               // replace them with hard links to the kept file
+	dupeReflink            // replace them with copy-on-write clones of the kept file
+)
+
+// verb describes a in the past tense for status messages.
+func (a dupeAction) verb() string {
+	switch a {
+	case dupeTrash:
+		return "trashed"
+	case dupeLink:
+		return "hard-linked"
+	default:
+		return "reflinked"
+	}
+}
+
+// dupeView is the duplicate finder overlay (D). It opens at once, fills in
+// when the search finishes, and lists groups of identical files by wasted
+// bytes. The file under the cursor is the copy actions keep.
+type dupeView struct {
+	root   string // OS directory searched
+	groups []dupes.Group
+	group  int // cursor: group and file within it
+	file   int
+	offset int // first row shown
+
+	prog   *dupes.Progress
+	cancel context.CancelFunc // stops the search; nil once it is done
+
+	confirm dupeAction // action waiting for y
+	busy    bool       // an action is running
+	status  string     // outcome of the last action
+	err     error      // why the last action stopped
+	changed bool       // files were removed or replaced; rescan on close
+}
+
+// dupesMsg is sent when a duplicate search finishes.
+type dupesMsg struct {
+	root   string
+	groups []dupes.Group
+	err    error
+}
+
+// dupeActionMsg is sent when an action on a group finishes.
+type dupeActionMsg struct {
+	root   string
+	group  int
+	action dupeAction
+	done   []string // names of the copies acted on
+	bytes  int64    // their total size
+	err    error
+}
+
+// openDupes searches the tree under the current directory for duplicates.
+func (m Model) openDupes() (Model, tea.Cmd) {
+	if m.loading {
+		return m, nil
+	}
+	if m.vfs != nil {
+		m.err = fmt.Errorf("duplicates are not searched inside %s", filepath.Base(m.vfs.path))
+		return m, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.dupes = &dupeView{root: m.path, prog: &dupes.Progress{}, cancel: cancel}
+	return m, tea.Batch(dupesCmd(ctx, m.path, m.dupes.prog), m.spinner.Tick)
+}
+
+// dupesCmd searches the OS directory root for duplicates.
+func dupesCmd(ctx context.Context, root string, prog *dupes.Progress) tea.Cmd {
+	return func() tea.Msg {
+		groups, err := dupes.Find(ctx, scan.DirFS(root), ".", dupes.Options{Progress: prog})
+		return dupesMsg{root: root, groups: groups, err: err}
+	}
+}
+
+// closeDupes closes the overlay, stopping a search still running, and
+// rescans if files were changed.
+func (m Model) closeDupes() (Model, tea.Cmd) {
+	d := m.dupes
+	if d.cancel != nil {
+		d.cancel()
+	}
+	m.dupes = nil
+	if !d.changed {
+		return m, nil
+	}
+	m.index = nil
+	return m.refreshAfterAction(d.root)
+}
+
+// applyDupes shows the result of a finished search.
+func (m Model) applyDupes(msg dupesMsg) Model {
+	d := m.dupes
+	if d == nil || msg.root != d.root {
+		return m
+	}
+	d.cancel = nil
+	if msg.err != nil {
+		if !errors.Is(msg.err, context.Canceled) {
+			m.err = msg.err
+		}
+		m.dupes = nil
+		return m
+	}
+	d.groups = msg.groups
+	return m
+}
+
+// updateDupes handles keys while the duplicate finder is open: ↑↓ move, d
+// trashes every copy but the selected one, h and r replace them with hard
+// links or reflinks (each after y to confirm), enter shows the file in its
+// directory, esc/q or the duplicates key close.
+func (m Model) updateDupes(msg tea.KeyMsg) (Model, tea.Cmd) {
+	d := m.dupes
+	if d.busy {
+		// The cursor picks the copy being kept; wait for the action
+		return m, nil
+	}
+	k := msg.String()
+	if d.confirm != dupeNone {
+		action := d.confirm
+		d.confirm = dupeNone
+		if k != "y" {
+			return m, nil
+		}
+		g := d.groups[d.group]
+		d.busy, d.status, d.err = true, "", nil
+		return m, tea.Batch(dupeActionCmd(d.root, d.group, g, d.file, action), m.spinner.Tick)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Dupes, m.keys.Escape, m.keys.Quit):
+		return m.closeDupes()
+	case key.Matches(msg, m.keys.Up):
+		d.move(-1, m.dupeRows())
+	case key.Matches(msg, m.keys.Down):
+		d.move(1, m.dupeRows())
+	case key.Matches(msg, m.keys.PageUp):
+		d.move(-m.dupeRows(), m.dupeRows())
+	case key.Matches(msg, m.keys.PageDown):
+		d.move(m.dupeRows(), m.dupeRows())
+	case k == "enter":
+		if d.cancel != nil || len(d.groups) == 0 {
+			return m, nil
+		}
+		target := filepath.Join(d.root, filepath.FromSlash(d.groups[d.group].Files[d.file].Name))
+		m, cmd := m.closeDupes()
+		if cmd == nil || filepath.Dir(target) != m.path {
+			return m.reveal(target)
+		}
+		// The listing is being rescanned: select the file when it is in
+		m.searchInput.SetValue("")
+		m.viewFilter = entry.FilterAll
+		m.topMode = false
+		m.pendingCursorEntry = filepath.Base(target)
+		return m, cmd
+	case k == "d" || k == "h" || k == "r":
+		if d.cancel != nil || d.busy || len(d.groups) == 0 {
+			return m, nil
+		}
+		d.confirm = map[string]dupeAction{"d": dupeTrash, "h": dupeLink, "r": dupeReflink}[k]
+	}
+	return m, nil
+}
+
+// dupeActionCmd acts on every file of g but keep. It stops at the first
+// failure, and refuses to touch files changed since the search.
+func dupeActionCmd(root string, gi int, g dupes.Group, keep int, action dupeAction) tea.Cmd {
+	return func() tea.Msg {
+		msg := dupeActionMsg{root: root, group: gi, action: action}
+		kept := filepath.Join(root, filepath.FromSlash(g.Files[keep].Name))
+		if err := unchanged(kept, g.Size, g.Files[keep].ModTime); err != nil {
+			msg.err = fmt.Errorf("%s: %w", g.Files[keep].Name, err)
+			return msg
+		}
+		for i, f := range g.Files {
+			if i == keep {
+				continue
+			}
+			path := filepath.Join(root, filepath.FromSlash(f.Name))
+			err := unchanged(path, g.Size, f.ModTime)
+			if err == nil {
+				switch action {
+				case dupeTrash:
+					err = moveToTrash(path)
+				case dupeLink:
+					err = replaceWithLink(kept, path)
+				case dupeReflink:
+					err = replaceWithClone(kept, path)
+				}
+			}
+			if err != nil {
+				msg.err = fmt.Errorf("%s: %w", f.Name, err)
+				break
+			}
+			msg.done = append(msg.done, f.Name)
+			msg.bytes += g.Size
+		}
+		return msg
+	}
+}
+
+// unchanged reports an error unless path is still a regular file of size
+// last modified at mod.
+func unchanged(path string, size int64, mod time.Time) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() != size || !info.ModTime().Equal(mod) {
+		return errors.New("changed since the search, search again")
+	}
+	return nil
+}
+
+// replaceWithLink replaces path with a hard link to target. The link is
+// made beside path first and renamed over it, so path is never missing.
+func replaceWithLink(target, path string) error {
+	tmp := tempSibling(path)
+	if err := os.Link(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// replaceWithClone replaces path with a reflink of target, keeping path's
+// permissions and modification time.
+func replaceWithClone(target, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	tmp := tempSibling(path)
+	if err := cloneFile(target, tmp); err != nil {
+		return err
+	}
+	err = os.Chmod(tmp, info.Mode().Perm())
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// tempSibling returns a hidden name beside path for a file about to
+// replace it.
+func tempSibling(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".dirgo-tmp")
+}
+
+// applyDupeAction records a finished action: the copies acted on leave
+// their group, and groups left with one file leave the list.
+func (m Model) applyDupeAction(msg dupeActionMsg) Model {
+	d := m.dupes
+	for _, name := range msg.done {
+		// Listings of the directories above the copies are stale
+		for dir := filepath.Dir(filepath.Join(msg.root, filepath.FromSlash(name))); strings.HasPrefix(dir, msg.root); dir = filepath.Dir(dir) {
+			m.cache.Delete(dir)
+			if dir == msg.root {
+				break
+			}
+		}
+	}
+	if d == nil || msg.root != d.root {
+		return m
+	}
+	d.busy = false
+	d.err = msg.err
+	if len(msg.done) == 0 {
+		return m
+	}
+	d.changed = true
+	d.status = fmt.Sprintf("%s %s, %s", msg.action.verb(), countNoun(len(msg.done), "copy", "copies"), formatSize(msg.bytes))
+	if msg.action == dupeTrash {
+		d.status += " moved to the trash"
+	} else {
+		d.status += " freed"
+	}
+
+	g := &d.groups[msg.group]
+	done := make(map[string]bool, len(msg.done))
+	for _, name := range msg.done {
+		done[name] = true
+	}
+	kept := g.Files[d.file].Name
+	files := g.Files[:0]
+	for _, f := range g.Files {
+		if !done[f.Name] {
+			files = append(files, f)
+		}
+	}
+	g.Files = files
+	if len(files) < 2 {
+		d.groups = append(d.groups[:msg.group], d.groups[msg.group+1:]...)
+		d.group = min(msg.group, max(len(d.groups)-1, 0))
+		d.file = 0
+	} else {
+		for i, f := range files {
+			if f.Name == kept {
+				d.file = i
+			}
+		}
+	}
+	d.scroll(m.dupeRows())
+	return m
+}
+
+// move moves the cursor by delta files, across groups, keeping it within
+// rows of the offset.
+func (d *dupeView) move(delta, rows int) {
+	defer d.scroll(rows)
+	if len(d.groups) == 0 {
+		return
+	}
+	for ; delta > 0; delta-- {
+		switch {
+		case d.file < len(d.groups[d.group].Files)-1:
+			d.file++
+		case d.group < len(d.groups)-1:
+			d.group, d.file = d.group+1, 0
+		}
+	}
+	for ; delta < 0; delta++ {
+		switch {
+		case d.file > 0:
+			d.file--
+		case d.group > 0:
+			d.group--
+			d.file = len(d.groups[d.group].Files) - 1
+		}
+	}
+}
+
+// scroll moves the offset so the cursor's row, and if possible its group's
+// header, are within rows of it.
+func (d *dupeView) scroll(rows int) {
+	if len(d.groups) == 0 {
+		d.offset = 0
+		return
+	}
+	cur := d.cursorRow()
+	if d.file == 0 && cur-1 < d.offset {
+		d.offset = cur - 1
+	}
+	if cur < d.offset {
+		d.offset = cur
+	}
+	if cur >= d.offset+rows {
+		d.offset = cur - rows + 1
+	}
+}
+
+// cursorRow returns the row of the cursor, counting a header row per group.
+func (d *dupeView) cursorRow() int {
+	row := 0
+	for i := 0; i < d.group; i++ {
+		row += 1 + len(d.groups[i].Files)
+	}
+	return row + 1 + d.file
+}
+
+// dupeRows is the number of rows the overlay shows.
+func (m Model) dupeRows() int {
+	return maxInt(3, m.height-9)
+}
+
+// dupeStatus describes the progress of a running search.
+func dupeStatus(p *dupes.Progress) (string, float64) {
+	files, total := p.Files.Load(), p.Total.Load()
+	switch dupes.Stage(p.Stage.Load()) {
+	case dupes.StageWalk:
+		return "listing files… " + formatCount(int(files)) + " files", -1
+	case dupes.StagePartial:
+		return fmt.Sprintf("comparing first bytes… %s/%s files", formatCount(int(files)), formatCount(int(total))),
+			float64(files) / float64(max(total, 1)) * 100
+	default:
+		return fmt.Sprintf("comparing contents… %s/%s files, %s read", formatCount(int(files)), formatCount(int(total)), formatSize(p.Bytes.Load())),
+			float64(files) / float64(max(total, 1)) * 100
+	}
+}
+
+// renderDupes renders the duplicate finder overlay.
+func renderDupes(m Model) string {
+	d := m.dupes
+	var b strings.Builder
+	w := minInt(110, m.width-4)
+
+	b.WriteString(helpTitleStyle.Render("Duplicates in " + shortenPath(d.root)))
+	b.WriteString("\n")
+
+	var wasted int64
+	for _, g := range d.groups {
+		wasted += g.Wasted()
+	}
+	switch {
+	case d.cancel != nil:
+		text, pct := dupeStatus(d.prog)
+		line := m.spinner.View() + rowDimStyle.Render(" "+text)
+		if pct >= 0 {
+			line += " " + m.bar(pct, 20)
+		}
+		b.WriteString(line + "\n")
+	case len(d.groups) == 0:
+		b.WriteString(rowDimStyle.Render("no duplicate files") + "\n")
+	default:
+		b.WriteString(rowDimStyle.Render(fmt.Sprintf("%s, %s wasted", countNoun(len(d.groups), "group", "groups"), formatSize(wasted))) + "\n")
+	}
+	b.WriteString("\n")
+
+	// Header rows get a bar of the group's share of all wasted bytes
+	rows := m.dupeRows()
+	barW := minInt(20, w/6)
+	nameW := maxInt(8, w-16)
+	row := 0
+	for gi, g := range d.groups {
+		if row >= d.offset+rows {
+			break
+		}
+		if row >= d.offset {
+			pct := float64(g.Wasted()) / float64(max(wasted, 1)) * 100
+			b.WriteString(rowDimStyle.Render(padLeft(formatSize(g.Wasted()), 10)) + " " +
+				m.bar(pct, barW) + " " +
+				rowMetaStyle.Render(strconv.Itoa(len(g.Files))+" × "+formatSize(g.Size)))
+			b.WriteString("\n")
+		}
+		row++
+		for fi, f := range g.Files {
+			if row >= d.offset && row < d.offset+rows {
+				pointer, nameSt, mark := "  ", rowNameStyle, "  "
+				if gi == d.group {
+					mark = "× "
+					if fi == d.file {
+						pointer, nameSt, mark = "▶ ", rowNameSelStyle, "✓ "
+					}
+				}
+				b.WriteString(rowPointerActiveStyle.Render(pointer) + rowDimStyle.Render(mark))
+				b.WriteString(nameSt.Render(padRightVisual(truncateStrVisual(f.Name, nameW-4), nameW-4)))
+				b.WriteString(rowMetaStyle.Render(padLeft(formatAge(f.ModTime, time.Now()), 8)))
+				b.WriteString("\n")
+			}
+			row++
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case d.confirm != dupeNone:
+		others := countNoun(len(d.groups[d.group].Files)-1, "other copy", "other copies")
+		verbs := map[dupeAction]string{dupeTrash: "Trash", dupeLink: "Hard-link", dupeReflink: "Reflink"}
+		b.WriteString(searchPromptStyle.Render(fmt.Sprintf("%s %s of %s? ", verbs[d.confirm], others, d.groups[d.group].Files[d.file].Name)) +
+			footerDescStyle.Render("y confirm  any other key cancel"))
+	case d.busy:
+		b.WriteString(m.spinner.View() + footerStyle.Render(" working…"))
+	case d.err != nil:
+		b.WriteString(errorStyle.Render(truncateStrVisual(d.err.Error(), w)))
+	case d.status != "":
+		b.WriteString(footerStyle.Render(d.status))
+	default:
+		b.WriteString(footerStyle.Render("✓ keep  × others:  d trash  h hard-link  r reflink  ⏎ show  " + m.keys.closeLabel(m.keys.Dupes) + " close"))
+	}
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorCyan).
+		Padding(0, 1).
+		Width(w)
+
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(b.String()))
+}
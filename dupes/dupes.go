@@ -0,0 +1,295 @@
+// Package dupes finds files with identical contents in a tree over any
+// scan.FS. Candidates are narrowed in stages so most files are never read
+// in full: files are grouped by size, groups are split by a hash of each
+// file's first bytes, and only what is left is hashed completely.
+//
+//	groups, err := dupes.Find(ctx, scan.DirFS("/data"), ".", dupes.Options{})
+//	for _, g := range groups {
+//		fmt.Println(g.Wasted(), g.Files)
+//	}
+//
+// Hard links to one inode count as one file, so files already linked
+// together are not reported again. Inodes are told apart by device too, so
+// a tree spanning several filesystems is searched correctly.
+package dupes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"io/fs"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// PartialSize is how many leading bytes the partial hash reads.
+const PartialSize = 16 << 10
+
+// Stage is how far a search has got.
+type Stage int32
+
+const (
+	StageWalk    Stage = iota // listing files and grouping them by size
+	StagePartial              // hashing the first PartialSize bytes
+	StageFull                 // hashing whole files
+)
+
+// Progress holds live counters updated while Find runs.
+// Safe to read via atomic loads from another goroutine.
+type Progress struct {
+	Stage atomic.Int32 // a Stage
+	Files atomic.Int64 // files listed while walking, then hashed in the current stage
+	Total atomic.Int64 // files to hash in the current stage
+	Bytes atomic.Int64 // bytes read for hashing, over all stages
+}
+
+// Options configures Find.
+type Options struct {
+	// Progress, if non-nil, is updated as the search runs.
+	Progress *Progress
+
+	// MinSize is the size of the smallest file considered; 0 means 1, so
+	// empty files are never reported.
+	MinSize int64
+
+	// Concurrency bounds how many files are hashed in parallel.
+	// 0 uses runtime.NumCPU(), capped at 8.
+	Concurrency int
+}
+
+// File is one copy in a Group.
+type File struct {
+	Name    string // slash-separated path relative to the searched directory
+	ModTime time.Time
+}
+
+// Group is a set of files with the same contents.
+type Group struct {
+	Size  int64  // size of each file
+	Files []File // sorted by name
+}
+
+// Wasted returns the bytes the copies beyond the first take up.
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// candidate is a file that may have duplicates.
+type candidate struct {
+	File
+	size int64
+	hash [sha256.Size]byte
+	ok   bool // hashed without error
+}
+
+// Find returns the groups of identical files below name in fsys, most
+// wasted bytes first. Unreadable files and directories are skipped. If ctx
+// is cancelled, Find stops early and returns ctx.Err().
+func Find(ctx context.Context, fsys scan.FS, name string, opts Options) ([]Group, error) {
+	prog := opts.Progress
+	if prog == nil {
+		prog = &Progress{}
+	}
+	minSize := max(opts.MinSize, 1)
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = min(runtime.NumCPU(), 8)
+	}
+
+	// Walk, keeping one path per inode; inode numbers repeat across devices
+	type fileID struct{ dev, ino uint64 }
+	bySize := make(map[int64][]*candidate)
+	seen := make(map[fileID]bool)
+	prog.Stage.Store(int32(StageWalk))
+	err := fs.WalkDir(fsys, name, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if p == name {
+				return err
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() < minSize {
+			return nil
+		}
+		if x := scan.StatExt(fsys, p, info); x.Inode != 0 {
+			id := fileID{x.Dev, x.Inode}
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+		prog.Files.Add(1)
+		c := &candidate{File: File{Name: rel(name, p), ModTime: info.ModTime()}, size: info.Size()}
+		bySize[c.size] = append(bySize[c.size], c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var groups [][]*candidate
+	for _, cs := range bySize {
+		if len(cs) > 1 {
+			groups = append(groups, cs)
+		}
+	}
+
+	// Split by the hash of the first bytes, then of everything
+	for _, stage := range []Stage{StagePartial, StageFull} {
+		limit := int64(PartialSize)
+		var todo []*candidate
+		for _, g := range groups {
+			if stage == StageFull {
+				if g[0].size <= PartialSize {
+					continue // the partial hash read it all
+				}
+				limit = -1
+			}
+			todo = append(todo, g...)
+		}
+		prog.Stage.Store(int32(stage))
+		prog.Files.Store(0)
+		prog.Total.Store(int64(len(todo)))
+		if err := hashAll(ctx, fsys, name, todo, limit, workers, prog); err != nil {
+			return nil, err
+		}
+		groups = splitByHash(groups, stage)
+	}
+
+	result := make([]Group, len(groups))
+	for i, cs := range groups {
+		g := Group{Size: cs[0].size, Files: make([]File, len(cs))}
+		for j, c := range cs {
+			g.Files[j] = c.File
+		}
+		sort.Slice(g.Files, func(a, b int) bool { return g.Files[a].Name < g.Files[b].Name })
+		result[i] = g
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Wasted() != b.Wasted() {
+			return a.Wasted() > b.Wasted()
+		}
+		return a.Files[0].Name < b.Files[0].Name
+	})
+	return result, nil
+}
+
+// splitByHash splits each group into groups of files with the same hash,
+// dropping files that could not be read and groups left with one file. In
+// the full stage, groups of small files were not rehashed and are kept.
+func splitByHash(groups [][]*candidate, stage Stage) [][]*candidate {
+	var out [][]*candidate
+	for _, g := range groups {
+		if stage == StageFull && g[0].size <= PartialSize {
+			out = append(out, g)
+			continue
+		}
+		byHash := make(map[[sha256.Size]byte][]*candidate)
+		var order [][sha256.Size]byte
+		for _, c := range g {
+			if !c.ok {
+				continue
+			}
+			if _, ok := byHash[c.hash]; !ok {
+				order = append(order, c.hash)
+			}
+			byHash[c.hash] = append(byHash[c.hash], c)
+		}
+		for _, h := range order {
+			if cs := byHash[h]; len(cs) > 1 {
+				out = append(out, cs)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i][0].hash[:], out[j][0].hash[:]) < 0 })
+	return out
+}
+
+// hashAll hashes up to limit bytes (all of it if negative) of every
+// candidate with workers goroutines.
+func hashAll(ctx context.Context, fsys scan.FS, root string, todo []*candidate, limit int64, workers int, prog *Progress) error {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range min(workers, len(todo)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := next.Add(1) - 1
+				if i >= int64(len(todo)) {
+					return
+				}
+				c := todo[i]
+				c.hash, c.ok = hashFile(ctx, fsys, join(root, c.Name), limit, prog)
+				prog.Files.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// hashFile returns the SHA-256 of up to limit bytes of name.
+func hashFile(ctx context.Context, fsys scan.FS, name string, limit int64, prog *Progress) ([sha256.Size]byte, bool) {
+	var sum [sha256.Size]byte
+	f, err := fsys.Open(name)
+	if err != nil {
+		return sum, false
+	}
+	defer f.Close()
+	var r io.Reader = &countingReader{ctx: ctx, r: f, n: &prog.Bytes}
+	if limit >= 0 {
+		r = io.LimitReader(r, limit)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return sum, false
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, true
+}
+
+// countingReader adds what it reads to n and stops when ctx is cancelled.
+type countingReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// rel returns p relative to root, both io/fs paths.
+func rel(root, p string) string {
+	if root == "." {
+		return p
+	}
+	return p[len(root)+1:]
+}
+
+// join is the inverse of rel.
+func join(root, name string) string {
+	if root == "." {
+		return name
+	}
+	return root + "/" + name
+}
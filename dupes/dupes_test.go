@@ -0,0 +1,136 @@
+package dupes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+func testFS() fstest.MapFS {
+	head := bytes.Repeat([]byte("x"), PartialSize)
+	big := append(append([]byte{}, head...), "same tail"...)
+	return fstest.MapFS{
+		"a/big.bin":       {Data: big},
+		"b/big copy.bin":  {Data: big},
+		"b/big other.bin": {Data: append(append([]byte{}, head...), "diff tail"...)},
+		"notes.txt":       {Data: []byte("hello")},
+		"a/notes.txt":     {Data: []byte("hello")},
+		"c/notes.bak":     {Data: []byte("hello")},
+		"c/hullo.txt":     {Data: []byte("hullo")},
+		"unique.txt":      {Data: []byte("only one of these")},
+		"empty1":          {},
+		"empty2":          {},
+	}
+}
+
+func names(g Group) []string {
+	var out []string
+	for _, f := range g.Files {
+		out = append(out, f.Name)
+	}
+	return out
+}
+
+func TestFind(t *testing.T) {
+	prog := &Progress{}
+	groups, err := Find(context.Background(), testFS(), ".", Options{Progress: prog, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups: %+v", len(groups), groups)
+	}
+	// Most wasted bytes first; files sharing only their first bytes differ
+	if got := names(groups[0]); !reflect.DeepEqual(got, []string{"a/big.bin", "b/big copy.bin"}) {
+		t.Errorf("big group %v", got)
+	}
+	if groups[0].Size != PartialSize+9 || groups[0].Wasted() != PartialSize+9 {
+		t.Errorf("big group size %d wasted %d", groups[0].Size, groups[0].Wasted())
+	}
+	if got := names(groups[1]); !reflect.DeepEqual(got, []string{"a/notes.txt", "c/notes.bak", "notes.txt"}) {
+		t.Errorf("small group %v", got)
+	}
+	if groups[1].Wasted() != 10 {
+		t.Errorf("small group wasted %d", groups[1].Wasted())
+	}
+	if Stage(prog.Stage.Load()) != StageFull || prog.Total.Load() != 3 {
+		t.Errorf("progress: stage %d, %d files in the full stage", prog.Stage.Load(), prog.Total.Load())
+	}
+
+	// A subdirectory, with names relative to it
+	groups, err = Find(context.Background(), testFS(), "b", Options{})
+	if err != nil || len(groups) != 0 {
+		t.Errorf("b: %+v %v", groups, err)
+	}
+	groups, err = Find(context.Background(), testFS(), ".", Options{MinSize: 100})
+	if err != nil || len(groups) != 1 || groups[0].Size < 100 {
+		t.Errorf("min size: %+v %v", groups, err)
+	}
+}
+
+func TestFindCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Find(ctx, testFS(), ".", Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+// devFS reports the given device and inode numbers for its files.
+type devFS struct {
+	fstest.MapFS
+	ext map[string]scan.ExtStat
+}
+
+func (d devFS) ExtStat(name string, _ fs.FileInfo) scan.ExtStat {
+	return d.ext[name]
+}
+
+func TestFindInodesPerDevice(t *testing.T) {
+	fsys := devFS{
+		MapFS: fstest.MapFS{
+			"a":     {Data: []byte("same")},
+			"b":     {Data: []byte("same")},
+			"mnt/c": {Data: []byte("same")},
+		},
+		ext: map[string]scan.ExtStat{
+			"a":     {Dev: 1, Inode: 7},
+			"b":     {Dev: 1, Inode: 7}, // a hard link to a
+			"mnt/c": {Dev: 2, Inode: 7}, // another filesystem's inode 7
+		},
+	}
+	groups, err := Find(context.Background(), fsys, ".", Options{})
+	if err != nil || len(groups) != 1 || !reflect.DeepEqual(names(groups[0]), []string{"a", "mnt/c"}) {
+		t.Errorf("got %+v %v, want a and mnt/c", groups, err)
+	}
+}
+
+func TestFindHardLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes are not known on Windows")
+	}
+	dir := t.TempDir()
+	data := []byte("linked contents")
+	os.WriteFile(filepath.Join(dir, "a"), data, 0o644)
+	if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "b")); err != nil {
+		t.Skip("hard links not supported:", err)
+	}
+	groups, err := Find(context.Background(), scan.DirFS(dir), ".", Options{})
+	if err != nil || len(groups) != 0 {
+		t.Fatalf("links of one file reported: %+v %v", groups, err)
+	}
+	os.WriteFile(filepath.Join(dir, "c"), data, 0o644)
+	groups, err = Find(context.Background(), scan.DirFS(dir), ".", Options{})
+	if err != nil || len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Errorf("got %+v %v, want one group of two", groups, err)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/dupes"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+func TestDupesHardLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes are not known on Windows")
+	}
+	root := t.TempDir()
+	data := []byte(strings.Repeat("dataset row\n", 100))
+	os.MkdirAll(filepath.Join(root, "a"), 0o755)
+	os.WriteFile(filepath.Join(root, "a", "set.csv"), data, 0o644)
+	os.WriteFile(filepath.Join(root, "set.csv"), data, 0o644)
+	os.WriteFile(filepath.Join(root, "set copy.csv"), data, 0o644)
+	os.WriteFile(filepath.Join(root, "other.csv"), []byte("different"), 0o644)
+
+	m := NewModel(root)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanResultMsg{path: root})
+	m = next.(Model)
+	m, _ = m.openDupes()
+	next, _ = m.Update(dupesCmd(context.Background(), root, m.dupes.prog)())
+	m = next.(Model)
+	if m.dupes.cancel != nil || len(m.dupes.groups) != 1 || len(m.dupes.groups[0].Files) != 3 {
+		t.Fatalf("groups %+v", m.dupes.groups)
+	}
+	if !strings.Contains(renderDupes(m), "1 group, "+formatSize(2*int64(len(data)))+" wasted") {
+		t.Errorf("no summary in\n%s", renderDupes(m))
+	}
+
+	// Keep set.csv, the third in name order, and link the others to it
+	press := func(k string) tea.Cmd {
+		var cmd tea.Cmd
+		var next tea.Model
+		if k == "down" {
+			next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		} else {
+			next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+		m = next.(Model)
+		return cmd
+	}
+	press("down")
+	press("down")
+	press("h")
+	if m.dupes.confirm != dupeLink || !strings.Contains(renderDupes(m), "Hard-link 2 other copies of set.csv?") {
+		t.Fatalf("confirm %v", m.dupes.confirm)
+	}
+	press("y")
+	g := m.dupes.groups[0]
+	next, _ = m.Update(dupeActionCmd(root, 0, g, m.dupes.file, dupeLink)())
+	m = next.(Model)
+	if m.dupes.err != nil || len(m.dupes.groups) != 0 || !m.dupes.changed {
+		t.Fatalf("after linking: err %v, groups %+v", m.dupes.err, m.dupes.groups)
+	}
+	kept, _ := os.Stat(filepath.Join(root, "set.csv"))
+	for _, name := range []string{"a/set.csv", "set copy.csv"} {
+		if info, err := os.Stat(filepath.Join(root, name)); err != nil || !os.SameFile(info, kept) {
+			t.Errorf("%s is not a link to set.csv (%v)", name, err)
+		}
+	}
+}
+
+func TestDupeActionRefusesChanged(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, "a"), []byte("same"), 0o644)
+	os.WriteFile(filepath.Join(root, "b"), []byte("same"), 0o644)
+	groups, err := dupes.Find(context.Background(), scan.DirFS(root), ".", dupes.Options{})
+	if err != nil || len(groups) != 1 {
+		t.Fatalf("Find: %v %+v", err, groups)
+	}
+	os.WriteFile(filepath.Join(root, "b"), []byte("diff"), 0o644)
+	os.Chtimes(filepath.Join(root, "b"), time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+
+	msg := dupeActionCmd(root, 0, groups[0], 0, dupeLink)().(dupeActionMsg)
+	if msg.err == nil || len(msg.done) != 0 {
+		t.Fatalf("linked a changed file: %+v", msg)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "b")); string(data) != "diff" {
+		t.Errorf("b = %q", data)
+	}
+}
+
+func TestReplaceWithClone(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	os.WriteFile(a, []byte("same"), 0o644)
+	os.WriteFile(b, []byte("same"), 0o600)
+	err := replaceWithClone(a, b)
+	if err != nil && !errors.Is(err, errNoReflink) {
+		t.Fatal(err)
+	}
+	// Whether or not the filesystem clones, b is intact and nothing is left behind
+	info, statErr := os.Stat(b)
+	if statErr != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("b: %v %v", info, statErr)
+	}
+	if _, err := os.Lstat(tempSibling(b)); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestDupesRemappedKey(t *testing.T) {
+	c := config{settings: defaultSettings(), Keys: map[string]keyList{"dupes": {"U"}}}
+	m := NewModel(t.TempDir()).withConfig(c)
+	m.width, m.height = 100, 30
+	press := func(k string) {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = next.(Model)
+	}
+	next, _ := m.Update(scanResultMsg{path: m.path})
+	m = next.(Model)
+	press("U")
+	if m.dupes == nil {
+		t.Fatal("duplicate finder not opened with its remapped key")
+	}
+	press("U")
+	if m.dupes != nil {
+		t.Error("duplicate finder not closed by its remapped key")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// editorDoneMsg is sent when the editor launched on name (relative to dir) exits.
+type editorDoneMsg struct {
+	dir  string
+	name string
+	err  error
+}
+
+// editorCommand returns the command line that opens path in editor, at line
+// when it is positive and the editor has a known way to take it. editor may
+// carry arguments, e.g. "code --wait".
+func editorCommand(editor, path string, line int) []string {
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{defaultEditor()}
+	}
+	if line <= 0 {
+		return append(args, path)
+	}
+	n := strconv.Itoa(line)
+	switch strings.TrimSuffix(filepath.Base(args[0]), ".exe") {
+	case "vi", "vim", "nvim", "gvim", "view", "nano", "emacs", "emacsclient",
+		"micro", "kak", "hx", "helix", "joe", "ne", "mg", "mcedit":
+		return append(args, "+"+n, path)
+	case "code", "code-insiders", "codium", "cursor":
+		return append(args, "-g", path+":"+n)
+	case "subl", "zed":
+		return append(args, path+":"+n)
+	default:
+		return append(args, path)
+	}
+}
+
+// editorFromEnv returns $VISUAL, then $EDITOR, then the platform default.
+func editorFromEnv() string {
+	for _, v := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(v)); e != "" {
+			return e
+		}
+	}
+	return defaultEditor()
+}
+
+func defaultEditor() string {
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editFile suspends the TUI and opens name (relative to m.path) in the
+// user's editor, at line if positive.
+func (m Model) editFile(name string, line int) (Model, tea.Cmd) {
+	if m.vfs != nil {
+		m.err = fmt.Errorf("cannot edit files inside %s", filepath.Base(m.vfs.path))
+		return m, nil
+	}
+	argv := editorCommand(editorFromEnv(), filepath.Join(m.path, name), line)
+	c := exec.Command(argv[0], argv[1:]...)
+	dir := m.path
+	return m, tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorDoneMsg{dir: dir, name: name, err: err}
+	})
+}
+
+// applyEditorDone refreshes the edited entry's size, times and line count,
+// and reloads the pager if it shows the file.
+func (m Model) applyEditorDone(msg editorDoneMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = fmt.Errorf("editor failed: %w", msg.err)
+	}
+	var cmds []tea.Cmd
+	full := filepath.Join(msg.dir, msg.name)
+	if p := m.pager; p != nil && p.path == full {
+		cmds = append(cmds, p.reindex())
+	}
+	if m.preview != nil && m.preview.path == full {
+		m.preview.reset()
+	}
+	if msg.dir != m.path {
+		m.cache.Delete(msg.dir)
+		return m, tea.Batch(cmds...)
+	}
+
+	fresh, err := scan.File(scan.DirFS(m.path), filepath.ToSlash(msg.name))
+	if err != nil {
+		// Deleted or renamed from inside the editor: rescan the directory
+		ctx := m.startScan()
+		return m, tea.Batch(append(cmds, m.scanCmd(ctx, m.path))...)
+	}
+
+	nested := m.tree != nil && strings.ContainsRune(msg.name, filepath.Separator)
+	var e *entry.FileEntry
+	if nested {
+		e = m.tree.treeEntry(msg.name)
+	} else {
+		for i := range m.entries {
+			if m.entries[i].Name == msg.name {
+				e = &m.entries[i]
+				break
+			}
+		}
+	}
+	if e == nil {
+		ctx := m.startScan()
+		return m, tea.Batch(append(cmds, m.scanCmd(ctx, m.path))...)
+	}
+
+	delta, diskDelta := fresh.Size-e.Size, fresh.DiskSize-e.DiskSize
+	e.Size, e.DiskSize = fresh.Size, fresh.DiskSize
+	e.ModTime, e.Mode = fresh.ModTime, fresh.Mode
+	e.IsBinary = fresh.IsBinary
+	e.LineCount = 0
+	text := !e.IsBinary
+	if nested {
+		m.adjustTreeAncestors(filepath.Dir(msg.name), delta, diskDelta, 0, 0)
+	} else {
+		m.totalSize += delta
+		m.recomputePercentages()
+		m.computeDeepTotals()
+	}
+	m.cache.Delete(m.path)
+	selected := m.selectedRel()
+	m.applySort() // e is stale from here on
+	m.selectRel(selected)
+	if text {
+		cmds = append(cmds, countLinesCmd(m.path, msg.name, int64(m.settings.LineCountMax)))
+	}
+	return m, tea.Batch(cmds...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		editor string
+		line   int
+		want   string
+	}{
+		{"vim", 0, "vim /f.txt"},
+		{"nvim", 12, "nvim +12 /f.txt"},
+		{"/usr/bin/nano", 3, "/usr/bin/nano +3 /f.txt"},
+		{"code --wait", 7, "code --wait -g /f.txt:7"},
+		{"subl -w", 2, "subl -w /f.txt:2"},
+		{"unknown-editor", 9, "unknown-editor /f.txt"},
+		{"", 0, defaultEditor() + " /f.txt"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(editorCommand(tt.editor, "/f.txt", tt.line), " ")
+		if got != tt.want {
+			t.Errorf("editorCommand(%q, %d) = %q, want %q", tt.editor, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestEditorFromEnv(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano")
+	if got := editorFromEnv(); got != "nano" {
+		t.Errorf("got %q, want $EDITOR", got)
+	}
+	t.Setenv("VISUAL", "hx")
+	if got := editorFromEnv(); got != "hx" {
+		t.Errorf("got %q, want $VISUAL to win", got)
+	}
+}
+
+func TestApplyEditorDoneRefreshesEntry(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("two two\n"), 0o644)
+	m := NewModel(dir)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model)
+	before := m.totalSize
+
+	// The editor grew a.txt past b.txt
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\nfour\n"), 0o644)
+	m, cmd := m.applyEditorDone(editorDoneMsg{dir: dir, name: "a.txt"})
+	if m.totalSize != before+15 {
+		t.Errorf("total size %d, want %d", m.totalSize, before+15)
+	}
+	if m.entries[0].Name != "a.txt" || m.entries[0].Size != 19 {
+		t.Errorf("a.txt not refreshed and re-sorted: %+v", m.entries[0])
+	}
+	if cmd == nil {
+		t.Fatal("no line recount scheduled")
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if m.entries[0].LineCount != 4 {
+		t.Errorf("line count %d, want 4", m.entries[0].LineCount)
+	}
+}
@@ -0,0 +1,123 @@
+// Package entry defines FileEntry, the per-file record shared by dirgo's
+// scanner and UI, together with sorting and filtering helpers.
+package entry
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileEntry represents a file or directory with its metadata.
+type FileEntry struct {
+	Name       string
+	Size       int64
+	IsDir      bool
+	IsHidden   bool
+	IsBinary   bool
+	IsSymlink  bool
+	LineCount  int // 0 if unknown/binary/dir
+	Percentage float64
+	ChildFiles int // only for dirs
+	ChildDirs  int // only for dirs
+	ModTime    time.Time
+	DiskSize   int64 // allocated bytes (compressed bytes for archive members); 0 if unknown
+	Mode       fs.FileMode
+	UID        int // owning user id; -1 if unknown
+	GID        int // owning group id; -1 if unknown
+}
+
+// New returns a FileEntry for name with hidden-ness set and ownership unknown.
+func New(name string) FileEntry {
+	return FileEntry{
+		Name:     name,
+		IsHidden: strings.HasPrefix(name, "."),
+		UID:      -1,
+		GID:      -1,
+	}
+}
+
+// Items returns the number of inodes the entry accounts for: itself plus,
+// for directories, every descendant file and directory.
+func (e *FileEntry) Items() int {
+	return 1 + e.ChildFiles + e.ChildDirs
+}
+
+// SortBySize sorts entries by size descending.
+func SortBySize(entries []FileEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Size > entries[j].Size
+	})
+}
+
+// ViewFilter controls which entry types are visible.
+type ViewFilter int
+
+const (
+	FilterAll       ViewFilter = iota // show all entries
+	FilterDirsOnly                    // dirs only
+	FilterFilesOnly                   // files only
+)
+
+// Filter returns a filtered slice based on visibility and view filter settings.
+func Filter(entries []FileEntry, showHidden bool, filter ViewFilter, search string) []FileEntry {
+	return FilterInto(make([]FileEntry, 0, len(entries)), entries, showHidden, filter, search)
+}
+
+// FilterInto appends filtered entries into dst, allowing callers to reuse slices.
+func FilterInto(dst []FileEntry, entries []FileEntry, showHidden bool, filter ViewFilter, search string) []FileEntry {
+	var match func(*FileEntry) bool
+	if search != "" {
+		match = func(e *FileEntry) bool { return FuzzyMatch(e.Name, search) }
+	}
+	return FilterFuncInto(dst, entries, showHidden, filter, match)
+}
+
+// FilterFuncInto is FilterInto with a predicate in place of the search
+// pattern; a nil match keeps every entry.
+func FilterFuncInto(dst []FileEntry, entries []FileEntry, showHidden bool, filter ViewFilter, match func(*FileEntry) bool) []FileEntry {
+	for i := range entries {
+		e := &entries[i]
+		if !showHidden && e.IsHidden {
+			continue
+		}
+		if filter == FilterDirsOnly && !e.IsDir {
+			continue
+		}
+		if filter == FilterFilesOnly && e.IsDir {
+			continue
+		}
+		if match != nil && !match(e) {
+			continue
+		}
+		dst = append(dst, *e)
+	}
+	return dst
+}
+
+// toLower returns the ASCII-lowered byte (only for A-Z).
+func toLower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 32
+	}
+	return b
+}
+
+// IsBinaryExt returns true if the file extension suggests a binary file.
+func IsBinaryExt(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".webp", ".svg",
+		".mp3", ".mp4", ".wav", ".avi", ".mov", ".mkv", ".flac", ".ogg",
+		".zip", ".tar", ".gz", ".bz2", ".xz", ".7z", ".rar", ".zst",
+		".exe", ".dll", ".so", ".dylib", ".bin", ".o", ".a",
+		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
+		".ttf", ".otf", ".woff", ".woff2", ".eot",
+		".pyc", ".pyo", ".class", ".wasm",
+		".db", ".sqlite", ".sqlite3":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package entry
+
+import (
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	entries := []FileEntry{
+		{Name: "visible.go", IsDir: false},
+		{Name: ".hidden", IsDir: false, IsHidden: true},
+		{Name: "src", IsDir: true},
+		{Name: ".git", IsDir: true, IsHidden: true},
+	}
+
+	// No hidden, no dir-only
+	f := Filter(entries, false, FilterAll, "")
+	if len(f) != 2 {
+		t.Errorf("expected 2, got %d", len(f))
+	}
+
+	// Show hidden
+	f = Filter(entries, true, FilterAll, "")
+	if len(f) != 4 {
+		t.Errorf("expected 4, got %d", len(f))
+	}
+
+	// Dir only
+	f = Filter(entries, false, FilterDirsOnly, "")
+	if len(f) != 1 {
+		t.Errorf("expected 1 dir, got %d", len(f))
+	}
+
+	// Search filter
+	f = Filter(entries, true, FilterAll, "git")
+	if len(f) != 1 || f[0].Name != ".git" {
+		t.Errorf("expected .git match, got %v", f)
+	}
+}
+
+func TestFilterFuncInto(t *testing.T) {
+	entries := []FileEntry{
+		{Name: "a.log", Size: 10},
+		{Name: "b.log", Size: 300},
+		{Name: ".c.log", Size: 500, IsHidden: true},
+		{Name: "d", Size: 900, IsDir: true},
+	}
+	big := func(e *FileEntry) bool { return e.Size > 100 }
+	f := FilterFuncInto(nil, entries, false, FilterFilesOnly, big)
+	if len(f) != 1 || f[0].Name != "b.log" {
+		t.Errorf("got %v, want b.log", f)
+	}
+	if f := FilterFuncInto(nil, entries, true, FilterAll, nil); len(f) != 4 {
+		t.Errorf("nil match: got %d entries, want 4", len(f))
+	}
+}
+
+func TestSortBySize(t *testing.T) {
+	entries := []FileEntry{
+		{Name: "small", Size: 100},
+		{Name: "big", Size: 10000},
+		{Name: "medium", Size: 1000},
+	}
+	SortBySize(entries)
+	if entries[0].Name != "big" || entries[2].Name != "small" {
+		t.Errorf("sort order wrong: %v", entries)
+	}
+}
+
+func TestIsBinaryExt(t *testing.T) {
+	binExts := []string{".png", ".jpg", ".zip", ".exe", ".pdf", ".wasm"}
+	for _, ext := range binExts {
+		if !IsBinaryExt("file" + ext) {
+			t.Errorf("expected %s to be binary", ext)
+		}
+	}
+	textExts := []string{".go", ".txt", ".md", ".json", ".yaml"}
+	for _, ext := range textExts {
+		if IsBinaryExt("file" + ext) {
+			t.Errorf("expected %s to be text", ext)
+		}
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name, pattern string
+		want          bool
+	}{
+		{"README.md", "read", true},
+		{"main.go", "MAIN", true},
+		{"scanner.go", "xyz", false},
+		{"test", "testing", false},
+		// Subsequence matching (new behavior)
+		{"model.go", "mgo", true},
+		{"README.md", "rdm", true},
+		{"scanner_test.go", "stg", true},
+		{"abcdef", "ace", true},
+		{"abcdef", "afc", false},
+		{"", "a", false},
+		{"a", "", true},
+		// Unicode case folding
+		{"Été.txt", "ÉTÉ", true},
+		{"ΣΟΦΙΑ", "σοφ", true},
+		{"5\u212Aresistor", "k", true},
+		{"straße", "STRA", true},
+		{"naïve", "naive", false},
+	}
+	for _, tt := range tests {
+		got := FuzzyMatch(tt.name, tt.pattern)
+		if got != tt.want {
+			t.Errorf("FuzzyMatch(%q, %q) = %v, want %v", tt.name, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// --- Benchmarks ---
+
+func BenchmarkFuzzyMatch(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		FuzzyMatch("some_long_filename_for_testing.go", "test")
+	}
+}
@@ -0,0 +1,195 @@
+package entry
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fuzzy matching finds the characters of a pattern in a name, in order.
+// Scoring prefers the alignment a person would expect: characters at the
+// start of words and in unbroken runs score more, gaps between them cost a
+// little, and typing the same case breaks ties. Case is folded for all of
+// Unicode, so "ÉTÉ" finds "été" and "k" finds the Kelvin sign.
+
+const (
+	scoreMatch       = 16
+	bonusStart       = 10 // first character of the name
+	bonusBoundary    = 8  // after a separator: / \ _ - . or space
+	bonusCamel       = 7  // upper case after lower case, or a digit after a non-digit
+	bonusConsecutive = 6  // directly after the previous matched character
+	bonusCase        = 1  // same case as typed
+	penaltyGapStart  = 3
+	penaltyGapExtend = 1
+)
+
+// FuzzyMatch reports whether the characters of pattern appear in name in
+// order, ignoring case. E.g. "mgo" matches "model.go", "rdm" matches "README.md".
+func FuzzyMatch(name, pattern string) bool {
+	if isASCII(name) && isASCII(pattern) {
+		pi := 0
+		for ni := 0; ni < len(name) && pi < len(pattern); ni++ {
+			if toLower(name[ni]) == toLower(pattern[pi]) {
+				pi++
+			}
+		}
+		return pi == len(pattern)
+	}
+	for _, r := range name {
+		if pattern == "" {
+			break
+		}
+		p, n := utf8.DecodeRuneInString(pattern)
+		if fold(r) == fold(p) {
+			pattern = pattern[n:]
+		}
+	}
+	return pattern == ""
+}
+
+// FuzzyScore returns the score of the best match of pattern in name, higher
+// being better, and whether it matches at all. An empty pattern scores 0.
+func FuzzyScore(name, pattern string) (int, bool) {
+	score, _, ok := fuzzy(name, pattern, false)
+	return score, ok
+}
+
+// FuzzyPositions returns the rune indexes in name of the characters of the
+// best match of pattern, in order, or nil if it does not match.
+func FuzzyPositions(name, pattern string) []int {
+	_, pos, _ := fuzzy(name, pattern, true)
+	return pos
+}
+
+// fuzzy scores the best alignment of pattern in name by dynamic
+// programming over (pattern rune, name rune) pairs, keeping where each
+// pattern rune's predecessor matched if positions are wanted.
+func fuzzy(name, pattern string, positions bool) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+	if !FuzzyMatch(name, pattern) {
+		return 0, nil, false
+	}
+	nr, pr := []rune(name), []rune(pattern)
+	n, m := len(nr), len(pr)
+	const none = math.MinInt / 2
+
+	bonus := make([]int, n)
+	for j := range nr {
+		bonus[j] = boundaryBonus(nr, j)
+	}
+	// prev[j] is the best score with the previous pattern rune matched at
+	// name rune j, cur[j] the same for the current one
+	prev, cur := make([]int, n), make([]int, n)
+	var from []int
+	if positions {
+		from = make([]int, m*n)
+	}
+	for i := 0; i < m; i++ {
+		pf := fold(pr[i])
+		// Best predecessor at least two runes back, i.e. after a gap, with
+		// the gap's cost for its distance folded in
+		gapBest, gapAt := none, -1
+		for j := 0; j < n; j++ {
+			if i > 0 && j >= 2 && prev[j-2] > none {
+				if v := prev[j-2] + penaltyGapExtend*(j-2); v > gapBest {
+					gapBest, gapAt = v, j-2
+				}
+			}
+			cur[j] = none
+			if fold(nr[j]) != pf {
+				continue
+			}
+			s := scoreMatch + bonus[j]
+			if nr[j] == pr[i] {
+				s += bonusCase
+			}
+			if i == 0 {
+				cur[j] = s
+				continue
+			}
+			total, at := none, -1
+			if j >= 1 && prev[j-1] > none {
+				total, at = prev[j-1]+bonusConsecutive, j-1
+			}
+			if gapAt >= 0 {
+				if v := gapBest - penaltyGapStart - penaltyGapExtend*(j-2); v > total {
+					total, at = v, gapAt
+				}
+			}
+			if at < 0 {
+				continue
+			}
+			cur[j] = total + s
+			if positions {
+				from[i*n+j] = at
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	score, end := none, -1
+	for j, v := range prev {
+		if v > score {
+			score, end = v, j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	var pos []int
+	if positions {
+		pos = make([]int, m)
+		for i := m - 1; i >= 0; i-- {
+			pos[i] = end
+			end = from[i*n+end]
+		}
+	}
+	return score, pos, true
+}
+
+// boundaryBonus is the bonus for matching name rune j.
+func boundaryBonus(r []rune, j int) int {
+	if j == 0 {
+		return bonusStart
+	}
+	p, c := r[j-1], r[j]
+	switch {
+	case p == '/' || p == '\\' || p == '_' || p == '-' || p == '.' || unicode.IsSpace(p):
+		return bonusBoundary
+	case unicode.IsLower(p) && unicode.IsUpper(c):
+		return bonusCamel
+	case !unicode.IsDigit(p) && unicode.IsDigit(c):
+		return bonusCamel
+	}
+	return 0
+}
+
+// fold maps r to a representative of its case-folding orbit: the smallest
+// rune in it, so ASCII letters fold to upper case.
+func fold(r rune) rune {
+	if r < utf8.RuneSelf {
+		if r >= 'a' && r <= 'z' {
+			return r - 32
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// isASCII reports whether s is all ASCII.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
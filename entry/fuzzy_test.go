@@ -0,0 +1,62 @@
+package entry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyScoreOrder(t *testing.T) {
+	// Each name should score higher than the next for the pattern
+	tests := []struct {
+		pattern string
+		names   []string
+	}{
+		{"main", []string{"main.go", "domain.go"}},
+		{"rm", []string{"rm.sh", "README.md", "program"}},
+		{"ft", []string{"fuzzy_test.go", "fast.go"}},
+		{"Map", []string{"Map.go", "map.go"}},
+		{"tm", []string{"treeMap.go", "timer.go"}},
+	}
+	for _, tt := range tests {
+		prev, prevName := 0, ""
+		for i, name := range tt.names {
+			s, ok := FuzzyScore(name, tt.pattern)
+			if !ok {
+				t.Errorf("%q does not match %q", tt.pattern, name)
+				continue
+			}
+			if i > 0 && s >= prev {
+				t.Errorf("%q: %s scores %d, not below %s (%d)", tt.pattern, name, s, prevName, prev)
+			}
+			prev, prevName = s, name
+		}
+	}
+	if _, ok := FuzzyScore("main.go", "xyz"); ok {
+		t.Error("xyz matched main.go")
+	}
+}
+
+func TestFuzzyPositions(t *testing.T) {
+	tests := []struct {
+		name, pattern string
+		want          []int
+	}{
+		{"fuzzy_test.go", "test", []int{6, 7, 8, 9}},
+		{"scanner_test.go", "stg", []int{0, 8, 13}},
+		{"treeMap.go", "tm", []int{0, 4}},
+		{"Été.txt", "ét", []int{0, 1}},
+		{"abc", "", nil},
+		{"abc", "x", nil},
+	}
+	for _, tt := range tests {
+		if got := FuzzyPositions(tt.name, tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyPositions(%q, %q) = %v, want %v", tt.name, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkFuzzyScore(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		FuzzyScore("some_long_filename_for_testing.go", "test")
+	}
+}
@@ -0,0 +1,179 @@
+package entry
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortKey selects the field entries are ordered by.
+type SortKey int
+
+const (
+	SortSize    SortKey = iota // apparent size
+	SortName                   // natural name order ("file2" before "file10")
+	SortModTime                // modification time
+	SortFiles                  // recursive child file count
+	SortDirs                   // recursive child dir count
+	SortExt                    // file extension, then name
+	SortLines                  // line count (0 when unknown)
+
+	numSortKeys
+)
+
+// String returns the short label shown in the header badge.
+func (k SortKey) String() string {
+	switch k {
+	case SortSize:
+		return "size"
+	case SortName:
+		return "name"
+	case SortModTime:
+		return "mtime"
+	case SortFiles:
+		return "files"
+	case SortDirs:
+		return "dirs"
+	case SortExt:
+		return "ext"
+	case SortLines:
+		return "lines"
+	default:
+		return ""
+	}
+}
+
+// Next returns the key after k in the sort cycle, wrapping around.
+func (k SortKey) Next() SortKey {
+	return (k + 1) % numSortKeys
+}
+
+// DefaultDesc reports whether k is most useful in descending order:
+// largest, newest and busiest first; names and extensions A→Z.
+func (k SortKey) DefaultDesc() bool {
+	return k != SortName && k != SortExt
+}
+
+// SortMode is a sort key together with its direction.
+type SortMode struct {
+	Key  SortKey
+	Desc bool
+}
+
+// DefaultSort is the ordering entries come out of the scanner in.
+var DefaultSort = SortMode{Key: SortSize, Desc: true}
+
+// String returns the badge label, e.g. "mtime↓".
+func (s SortMode) String() string {
+	if s.Desc {
+		return s.Key.String() + "↓"
+	}
+	return s.Key.String() + "↑"
+}
+
+// Sort orders entries in place by mode. Ties are broken by natural name
+// order so the result is deterministic regardless of direction.
+func Sort(entries []FileEntry, mode SortMode) {
+	if mode == DefaultSort {
+		SortBySize(entries)
+		return
+	}
+	cmp := compareFunc(mode.Key)
+	sort.SliceStable(entries, func(i, j int) bool {
+		c := cmp(&entries[i], &entries[j])
+		if c == 0 {
+			return NaturalLess(entries[i].Name, entries[j].Name)
+		}
+		if mode.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareFunc returns a three-way comparison for key.
+func compareFunc(key SortKey) func(a, b *FileEntry) int {
+	switch key {
+	case SortName:
+		return func(a, b *FileEntry) int { return naturalCompare(a.Name, b.Name) }
+	case SortModTime:
+		return func(a, b *FileEntry) int { return a.ModTime.Compare(b.ModTime) }
+	case SortFiles:
+		return func(a, b *FileEntry) int { return compareInt(int64(a.ChildFiles), int64(b.ChildFiles)) }
+	case SortDirs:
+		return func(a, b *FileEntry) int { return compareInt(int64(a.ChildDirs), int64(b.ChildDirs)) }
+	case SortExt:
+		return func(a, b *FileEntry) int { return strings.Compare(extOf(a), extOf(b)) }
+	case SortLines:
+		return func(a, b *FileEntry) int { return compareInt(int64(a.LineCount), int64(b.LineCount)) }
+	default:
+		return func(a, b *FileEntry) int { return compareInt(a.Size, b.Size) }
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// extOf returns the lower-cased extension of a file; directories have none.
+func extOf(e *FileEntry) string {
+	if e.IsDir {
+		return ""
+	}
+	return strings.ToLower(filepath.Ext(e.Name))
+}
+
+// NaturalLess reports whether a sorts before b in natural order: letters are
+// compared case-insensitively and runs of digits by numeric value.
+func NaturalLess(a, b string) bool {
+	return naturalCompare(a, b) < 0
+}
+
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ca, cb := a[i], b[j]
+		if isDigit(ca) && isDigit(cb) {
+			// Compare digit runs by value: skip leading zeros, then longer wins,
+			// then lexical order of equal-length runs.
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			na := strings.TrimLeft(a[si:i], "0")
+			nb := strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return compareInt(int64(len(na)), int64(len(nb)))
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		la, lb := toLower(ca), toLower(cb)
+		if la != lb {
+			return compareInt(int64(la), int64(lb))
+		}
+		i++
+		j++
+	}
+	if c := compareInt(int64(len(a)-i), int64(len(b)-j)); c != 0 {
+		return c
+	}
+	// Equal ignoring case and zero padding: fall back to byte order for stability
+	return strings.Compare(a, b)
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,96 @@
+package entry
+
+import (
+	"testing"
+	"time"
+)
+
+func names(entries []FileEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func equalNames(got []FileEntry, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if got[i].Name != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"file2", "file10", true},
+		{"file10", "file2", false},
+		{"File1", "file2", true},
+		{"a", "B", true},
+		{"img007", "img7", true}, // equal value: byte order decides
+		{"v1.9", "v1.10", true},
+		{"abc", "abcd", true},
+		{"x", "x", false},
+	}
+	for _, tt := range tests {
+		if got := NaturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("NaturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortModes(t *testing.T) {
+	now := time.Now()
+	base := []FileEntry{
+		{Name: "b10.log", Size: 300, ModTime: now.Add(-time.Hour), LineCount: 5},
+		{Name: "src", IsDir: true, Size: 200, ModTime: now, ChildFiles: 40, ChildDirs: 3},
+		{Name: "b2.txt", Size: 100, ModTime: now.Add(-2 * time.Hour), LineCount: 50},
+		{Name: "docs", IsDir: true, Size: 50, ModTime: now.Add(-time.Minute), ChildFiles: 2, ChildDirs: 7},
+	}
+	tests := []struct {
+		mode SortMode
+		want []string
+	}{
+		{DefaultSort, []string{"b10.log", "src", "b2.txt", "docs"}},
+		{SortMode{Key: SortSize}, []string{"docs", "b2.txt", "src", "b10.log"}},
+		{SortMode{Key: SortName}, []string{"b2.txt", "b10.log", "docs", "src"}},
+		{SortMode{Key: SortName, Desc: true}, []string{"src", "docs", "b10.log", "b2.txt"}},
+		{SortMode{Key: SortModTime, Desc: true}, []string{"src", "docs", "b10.log", "b2.txt"}},
+		{SortMode{Key: SortFiles, Desc: true}, []string{"src", "docs", "b2.txt", "b10.log"}},
+		{SortMode{Key: SortDirs, Desc: true}, []string{"docs", "src", "b2.txt", "b10.log"}},
+		{SortMode{Key: SortExt}, []string{"docs", "src", "b10.log", "b2.txt"}},
+		{SortMode{Key: SortLines, Desc: true}, []string{"b2.txt", "b10.log", "docs", "src"}},
+	}
+	for _, tt := range tests {
+		entries := append([]FileEntry(nil), base...)
+		Sort(entries, tt.mode)
+		if !equalNames(entries, tt.want...) {
+			t.Errorf("Sort(%v) = %v, want %v", tt.mode, names(entries), tt.want)
+		}
+	}
+}
+
+func TestSortKeyCycle(t *testing.T) {
+	k := SortSize
+	seen := map[SortKey]bool{}
+	for i := 0; i < int(numSortKeys); i++ {
+		if k.String() == "" {
+			t.Errorf("sort key %d has no label", k)
+		}
+		seen[k] = true
+		k = k.Next()
+	}
+	if k != SortSize || len(seen) != int(numSortKeys) {
+		t.Errorf("cycle did not visit every key once: ended at %v, saw %d", k, len(seen))
+	}
+	if SortName.DefaultDesc() || !SortModTime.DefaultDesc() {
+		t.Error("unexpected default directions")
+	}
+}
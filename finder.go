@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// finderKind is what a finder searches for.
+type finderKind int
+
+const (
+	finderJump   finderKind = iota // directories, to browse into (J)
+	finderSearch                   // files and directories by size, to select in their parent (F)
+)
+
+// finder is the overlay searching the tree index of the current directory:
+// the fuzzy directory jump (J) and the recursive search (F). It opens at
+// once and fills in when the index scan finishes.
+type finder struct {
+	kind    finderKind
+	input   textinput.Model
+	results []int // indexes into the tree index's items
+	cursor  int
+	offset  int
+	err     error // why the typed query does not parse
+	query   query // the query results were found with
+
+	prog   *scan.Progress     // index scan progress
+	cancel context.CancelFunc // stops the index scan; nil once it is done
+}
+
+// openFinder opens a finder of kind, scanning the tree first unless it is
+// already indexed.
+func (m Model) openFinder(kind finderKind) (Model, tea.Cmd) {
+	if m.loading {
+		return m, nil
+	}
+	in := textinput.New()
+	in.Placeholder = "directory..."
+	if kind == finderSearch {
+		in.Placeholder = "path, size>100M, ext:log..."
+	}
+	in.CharLimit = 256
+	in.Width = 50
+	f := &finder{kind: kind, input: in}
+	m.finder = f
+	cmds := []tea.Cmd{f.input.Focus()}
+	if m.index == nil || m.index.root != m.path {
+		m.index = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		f.prog, f.cancel = &scan.Progress{}, cancel
+		cmds = append(cmds, m.indexCmd(ctx, f.prog), m.spinner.Tick)
+	} else {
+		m.refreshFinder()
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// closeFinder closes the overlay, stopping an index scan still running.
+func (m Model) closeFinder() Model {
+	if m.finder.cancel != nil {
+		m.finder.cancel()
+	}
+	m.finder = nil
+	return m
+}
+
+// applyIndex stores a finished index scan and shows its results.
+func (m Model) applyIndex(msg indexMsg) Model {
+	if msg.root != m.path {
+		return m
+	}
+	if m.finder != nil {
+		m.finder.cancel = nil
+	}
+	if msg.err != nil {
+		if m.finder != nil {
+			m.err = msg.err
+			m.finder = nil
+		}
+		return m
+	}
+	m.index = msg.index
+	if m.finder != nil {
+		m.refreshFinder()
+	}
+	return m
+}
+
+// refreshFinder recomputes the results for the typed query, whose bare
+// words fuzzy-match the relative path. The jump lists directories in tree
+// order, largest first within each level; the search lists everything,
+// largest first. With ranking on, the best matches come first instead. A
+// query that does not parse keeps the results it had.
+func (m *Model) refreshFinder() {
+	f := m.finder
+	q, err := parseQuery(f.input.Value(), m.config.Filters, time.Now())
+	if f.err = err; err != nil {
+		return
+	}
+	f.query = q
+	f.results = f.results[:0]
+	order := m.index.tree()
+	if f.kind == finderSearch {
+		order = m.index.bySize()
+	}
+	for _, i := range order {
+		it := m.index.items[i]
+		if f.kind == finderJump && !it.node.IsDir {
+			continue
+		}
+		if !m.showHidden && strings.Contains("/"+it.rel, "/.") {
+			continue
+		}
+		if !q.match(it.rel, &it.node.FileEntry) {
+			continue
+		}
+		f.results = append(f.results, i)
+	}
+	if m.rankSearch && q.ranked() {
+		scores := make(map[int]int, len(f.results))
+		for _, i := range f.results {
+			scores[i] = q.score(m.index.items[i].rel)
+		}
+		sort.SliceStable(f.results, func(a, b int) bool {
+			return scores[f.results[a]] > scores[f.results[b]]
+		})
+	}
+	f.cursor, f.offset = 0, 0
+}
+
+// finderRows is the number of result rows the overlay shows.
+func (m Model) finderRows() int {
+	return maxInt(1, m.height-8)
+}
+
+// updateFinder handles keys while the finder is open: typing filters, ↑↓
+// move, tab toggles ranking, enter goes to the selected directory, esc closes.
+func (m Model) updateFinder(msg tea.KeyMsg) (Model, tea.Cmd) {
+	f := m.finder
+	switch msg.String() {
+	case "esc":
+		return m.closeFinder(), nil
+	case "up", "ctrl+p":
+		f.move(-1, m.finderRows())
+		return m, nil
+	case "down", "ctrl+n":
+		f.move(1, m.finderRows())
+		return m, nil
+	case "pgup":
+		f.move(-m.finderRows(), m.finderRows())
+		return m, nil
+	case "pgdown":
+		f.move(m.finderRows(), m.finderRows())
+		return m, nil
+	case "tab":
+		m.rankSearch = !m.rankSearch
+		if m.index != nil {
+			m.refreshFinder()
+		}
+		return m, nil
+	case "enter":
+		if m.index == nil || len(f.results) == 0 {
+			return m, nil
+		}
+		target := m.index.path(f.results[f.cursor])
+		m = m.closeFinder()
+		if f.kind == finderSearch {
+			return m.reveal(target)
+		}
+		return m.navigateTo(target)
+	}
+	var cmd tea.Cmd
+	prev := f.input.Value()
+	f.input, cmd = f.input.Update(msg)
+	if f.input.Value() != prev && m.index != nil {
+		m.refreshFinder()
+	}
+	return m, cmd
+}
+
+// reveal browses to the directory containing target and selects it,
+// clearing the search, filter and top view that could hide it.
+func (m Model) reveal(target string) (Model, tea.Cmd) {
+	dir, name := filepath.Dir(target), filepath.Base(target)
+	m.searchInput.SetValue("")
+	m.viewFilter = entry.FilterAll
+	m.topMode = false
+	if dir != m.path {
+		m.rememberCursor(dir, name)
+		return m.navigateTo(dir)
+	}
+	m.applyFilter()
+	m.selectRel(name)
+	return m, m.lineCountForSelected()
+}
+
+// move moves the cursor by delta, keeping it within rows of the offset.
+func (f *finder) move(delta, rows int) {
+	f.cursor = max(0, min(f.cursor+delta, len(f.results)-1))
+	if f.cursor < f.offset {
+		f.offset = f.cursor
+	}
+	if f.cursor >= f.offset+rows {
+		f.offset = f.cursor - rows + 1
+	}
+}
+
+// renderFinder renders the finder overlay.
+func renderFinder(m Model) string {
+	f := m.finder
+	var b strings.Builder
+	w := minInt(100, m.width-4)
+
+	title := "Jump to directory"
+	if f.kind == finderSearch {
+		title = "Search " + shortenPath(m.path)
+	}
+	b.WriteString(helpTitleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(searchPromptStyle.Render(modePrompt(f.input.Value(), "› ")) + f.input.View())
+	b.WriteString("\n\n")
+
+	rows := m.finderRows()
+	switch {
+	case m.index == nil:
+		files, size := int64(0), int64(0)
+		if f.prog != nil {
+			files, size = f.prog.Files.Load(), f.prog.Size.Load()
+		}
+		b.WriteString(m.spinner.View() + rowDimStyle.Render(" indexing "+shortenPath(m.path)+"… "+
+			formatCount(int(files))+" files, "+formatSize(size)))
+		b.WriteString("\n")
+	case len(f.results) == 0:
+		b.WriteString(rowDimStyle.Render("  no matches"))
+		b.WriteString("\n")
+	}
+	// Search results get a bar of their share of the whole tree
+	barW := 0
+	if f.kind == finderSearch {
+		barW = minInt(20, w/6)
+	}
+	nameW := maxInt(8, w-16-barW)
+	for i := f.offset; i < len(f.results) && i < f.offset+rows; i++ {
+		it := m.index.items[f.results[i]]
+		pointer := "  "
+		nameSt := rowNameStyle
+		if i == f.cursor {
+			pointer = "▶ "
+			nameSt = rowNameSelStyle
+		}
+		name := it.rel
+		if it.node.IsDir {
+			name += "/"
+		}
+		b.WriteString(rowPointerActiveStyle.Render(pointer))
+		b.WriteString(highlightSeg(padRightVisual(truncateStrVisual(name, nameW), nameW), name, f.query.highlights(it.rel), nameSt, false))
+		b.WriteString(rowDimStyle.Render(padLeft(formatSize(it.node.Size), 10)))
+		if barW > 0 {
+			pct := 0.0
+			if m.index.node.Size > 0 {
+				pct = float64(it.node.Size) / float64(m.index.node.Size) * 100
+			}
+			b.WriteString(" " + m.bar(pct, barW))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	order := "tab best match"
+	if m.rankSearch {
+		order = "tab tree order"
+		if f.kind == finderSearch {
+			order = "tab size order"
+		}
+	}
+	status := "⏎ go  ↑↓ move  " + order + "  esc close"
+	if f.kind == finderSearch {
+		status = "⏎ show in folder  ↑↓ move  " + order + "  esc close"
+	}
+	if m.index != nil {
+		unit := " dirs  "
+		if f.kind == finderSearch {
+			unit = " matches  "
+		}
+		status = strconv.Itoa(len(f.results)) + unit + status
+	}
+	if f.err != nil {
+		b.WriteString(errorStyle.Render(truncateStrVisual(f.err.Error(), w)))
+	} else {
+		b.WriteString(footerStyle.Render(status))
+	}
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorCyan).
+		Padding(0, 1).
+		Width(w)
+
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(b.String()))
+}
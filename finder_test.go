@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+func TestFinderJump(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{"src/app/models", "src/lib", "docs", ".git/objects"} {
+		os.MkdirAll(filepath.Join(root, d), 0o755)
+	}
+	os.WriteFile(filepath.Join(root, "src/app/models/user.go"), make([]byte, 100), 0o644)
+
+	m := NewModel(root)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanResultMsg{path: root})
+	m = next.(Model)
+	m.showHidden = false
+
+	m, _ = m.openFinder(finderJump)
+	if m.finder == nil || m.finder.cancel == nil {
+		t.Fatal("finder should be indexing")
+	}
+	msg := m.indexCmd(context.Background(), &scan.Progress{})()
+	next, _ = m.Update(msg)
+	m = next.(Model)
+	if m.index == nil || m.finder.cancel != nil {
+		t.Fatal("index not applied")
+	}
+	// Directories only, with hidden ones left out
+	if n := len(m.finder.results); n != 5 {
+		t.Errorf("got %d results, want 5", n)
+	}
+
+	for _, r := range "apmod" {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	if len(m.finder.results) != 1 || m.index.items[m.finder.results[0]].rel != "src/app/models" {
+		t.Fatalf("results for apmod: %v", m.finder.results)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if m.finder != nil || m.path != filepath.Join(root, "src", "app", "models") {
+		t.Errorf("after enter at %s", m.path)
+	}
+}
+
+func TestFinderSearchReveal(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "logs", "old"), 0o755)
+	os.WriteFile(filepath.Join(root, "logs", "old", "app.log"), make([]byte, 5000), 0o644)
+	os.WriteFile(filepath.Join(root, "logs", "app.log"), make([]byte, 300), 0o644)
+	os.WriteFile(filepath.Join(root, "logs", "old", "core.bin"), make([]byte, 9000), 0o644)
+	os.WriteFile(filepath.Join(root, "notes.txt"), make([]byte, 10), 0o644)
+
+	m := NewModel(root)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanResultMsg{path: root})
+	m = next.(Model)
+	m, _ = m.openFinder(finderSearch)
+	next, _ = m.Update(m.indexCmd(context.Background(), &scan.Progress{})())
+	m = next.(Model)
+
+	for _, r := range "app" {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	// Files too, largest first, with relative paths
+	var got []string
+	for _, i := range m.finder.results {
+		got = append(got, m.index.items[i].rel)
+	}
+	if len(got) != 2 || got[0] != "logs/old/app.log" || got[1] != "logs/app.log" {
+		t.Fatalf("results %v", got)
+	}
+	// Query terms narrow the search too
+	for _, r := range " size>1K" {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	if len(m.finder.results) != 1 || m.finder.err != nil {
+		t.Fatalf("size>1K: %d results, %v", len(m.finder.results), m.finder.err)
+	}
+
+	// A dirs-only listing would hide the file, so revealing it shows all
+	m.viewFilter = entry.FilterDirsOnly
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	dir := filepath.Join(root, "logs", "old")
+	if m.path != dir || m.cursorHistory[dir] != "app.log" {
+		t.Fatalf("at %s, remembered %q", m.path, m.cursorHistory[dir])
+	}
+	next, _ = m.Update(m.scanCmd(context.Background(), dir)())
+	m = next.(Model)
+	if m.viewFilter != entry.FilterAll || m.filtered[m.cursor].Name != "app.log" {
+		t.Errorf("selected %q with filter %v", m.filtered[m.cursor].Name, m.viewFilter)
+	}
+}
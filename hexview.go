@@ -0,0 +1,594 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/cache"
+)
+
+// The hex viewer shows a file of any size full-screen. Bytes are read on
+// demand in fixed pages through ReadAt, so only what is on screen (plus a
+// small page cache) is ever in memory.
+
+const (
+	hexPageSize   = 4096
+	hexCachePages = 64      // 256 KiB of recently shown pages
+	hexSearchStep = 1 << 20 // bytes read per search step
+)
+
+// hexLayout selects how bytes are laid out on each line.
+type hexLayout int
+
+const (
+	hexLayoutCanonical hexLayout = iota // offset, hex, ASCII
+	hexLayoutHex                        // offset, hex only
+	hexLayoutASCII                      // offset, ASCII only
+	hexLayoutBytes                      // one byte per line: hex, decimal, octal, binary, char
+	numHexLayouts
+)
+
+func (l hexLayout) String() string {
+	switch l {
+	case hexLayoutCanonical:
+		return "hex+ascii"
+	case hexLayoutHex:
+		return "hex"
+	case hexLayoutASCII:
+		return "ascii"
+	case hexLayoutBytes:
+		return "bytes"
+	default:
+		return ""
+	}
+}
+
+// perLine returns how many bytes a line of width w holds in layout l.
+func (l hexLayout) perLine(w int) int {
+	avail := w - 11 // offset (8) + gaps
+	var n int
+	switch l {
+	case hexLayoutBytes:
+		return 1
+	case hexLayoutHex:
+		n = avail / 3
+	case hexLayoutASCII:
+		n = avail
+	default:
+		n = (avail - 2) / 4
+	}
+	n -= n % 8
+	return maxInt(8, minInt(n, 64))
+}
+
+// hexPrompt is the text prompt open in the hex viewer, if any.
+type hexPrompt int
+
+const (
+	hexPromptNone hexPrompt = iota
+	hexPromptOffset
+	hexPromptSearch
+)
+
+// hexViewer is the state of the open hex viewer.
+type hexViewer struct {
+	path   string
+	f      *os.File
+	size   int64
+	top    int64 // offset of the first shown byte, a multiple of the line width
+	layout hexLayout
+	pages  *cache.LRU[[]byte]
+
+	prompt hexPrompt
+	input  textinput.Model
+
+	pattern   []byte // last search pattern
+	mark      int64  // start of the highlighted match or jump target; -1 for none
+	markLen   int
+	searching context.CancelFunc // non-nil while a search runs
+	status    string             // last result or error
+
+	// The bytes on screen, read by load so rendering does no I/O
+	data     []byte
+	dataTop  int64
+	dataWant int // bytes asked for; 0 until a read succeeds
+}
+
+// hexSearchMsg reports the result of a search: the match offset, or -1.
+type hexSearchMsg struct {
+	path    string
+	pattern []byte
+	at      int64
+	err     error
+}
+
+// hexView opens the selected file in the hex viewer.
+func (m Model) hexView() (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	sel := m.filtered[m.cursor]
+	if sel.IsDir {
+		return m, nil
+	}
+	if m.vfs != nil {
+		m.err = fmt.Errorf("hex view is not available inside %s", filepath.Base(m.vfs.path))
+		return m, nil
+	}
+	h, err := openHexViewer(filepath.Join(m.path, sel.Name))
+	if err != nil {
+		m.err = fmt.Errorf("hex view failed: %w", err)
+		return m, nil
+	}
+	m.hex = h
+	return m, nil
+}
+
+func openHexViewer(path string) (*hexViewer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	in := textinput.New()
+	in.CharLimit = 256
+	in.Width = 40
+	return &hexViewer{
+		path:  path,
+		f:     f,
+		size:  info.Size(),
+		pages: cache.New[[]byte](hexCachePages),
+		input: in,
+		mark:  -1,
+	}, nil
+}
+
+// close stops a running search and closes the file.
+func (h *hexViewer) close() {
+	if h.searching != nil {
+		h.searching()
+	}
+	h.f.Close()
+}
+
+// readAt returns up to n bytes at off, assembled from cached pages.
+func (h *hexViewer) readAt(off int64, n int) ([]byte, error) {
+	if off >= h.size {
+		return nil, nil
+	}
+	if rest := h.size - off; int64(n) > rest {
+		n = int(rest)
+	}
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		pos := off + int64(len(out))
+		page, err := h.page(pos / hexPageSize)
+		if err != nil {
+			return out, err
+		}
+		start := int(pos % hexPageSize)
+		if start >= len(page) {
+			break // file shrank since it was opened
+		}
+		out = append(out, page[start:minInt(len(page), start+n-len(out))]...)
+	}
+	return out, nil
+}
+
+func (h *hexViewer) page(i int64) ([]byte, error) {
+	key := strconv.FormatInt(i, 10)
+	if p, ok := h.pages.Get(key); ok {
+		return p, nil
+	}
+	buf := make([]byte, hexPageSize)
+	n, err := h.f.ReadAt(buf, i*hexPageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	h.pages.Put(key, buf[:n])
+	return buf[:n], nil
+}
+
+// hexArea returns the dump's width and number of lines.
+func hexArea(m Model) (int, int) {
+	return m.width, maxInt(1, m.height-4)
+}
+
+// load reads the bytes on screen for m's window unless the last read
+// already covers them. Update calls it after every message.
+func (h *hexViewer) load(m Model) {
+	w, rows := hexArea(m)
+	per := h.layout.perLine(w)
+	top, want := h.clampTop(h.top, per, rows), per*rows
+	if top == h.dataTop && want == h.dataWant {
+		return
+	}
+	data, err := h.readAt(top, want)
+	h.data, h.dataTop, h.dataWant = data, top, want
+	if err != nil {
+		h.dataWant = 0 // try again on the next message
+		h.status = err.Error()
+	}
+}
+
+// scroll moves the view by delta lines, clamped to the file.
+func (h *hexViewer) scroll(delta int64, per, rows int) {
+	h.top = h.clampTop(h.top+delta*int64(per), per, rows)
+}
+
+// clampTop aligns top to a line start and keeps the last page full.
+func (h *hexViewer) clampTop(top int64, per, rows int) int64 {
+	last := (h.size - 1) / int64(per) * int64(per)
+	maxTop := last - int64(rows-1)*int64(per)
+	if top > maxTop {
+		top = maxTop
+	}
+	if top < 0 {
+		top = 0
+	}
+	return top - top%int64(per)
+}
+
+// show scrolls so that off is visible, a third of the way down the screen.
+func (h *hexViewer) show(off int64, per, rows int) {
+	if off >= h.top && off < h.top+int64(per*rows) {
+		return
+	}
+	h.top = h.clampTop(off-int64(rows/3*per), per, rows)
+}
+
+// updateHex handles keys while the hex viewer is open.
+func (m Model) updateHex(msg tea.KeyMsg) (Model, tea.Cmd) {
+	h := m.hex
+	w, rows := hexArea(m)
+	per := h.layout.perLine(w)
+
+	if h.prompt != hexPromptNone {
+		switch msg.Type {
+		case tea.KeyEsc:
+			h.prompt = hexPromptNone
+			h.input.Blur()
+			return m, nil
+		case tea.KeyEnter:
+			prompt, value := h.prompt, strings.TrimSpace(h.input.Value())
+			h.prompt = hexPromptNone
+			h.input.Blur()
+			if value == "" {
+				return m, nil
+			}
+			if prompt == hexPromptOffset {
+				off, err := parseOffset(value, h.top, h.size)
+				if err != nil {
+					h.status = err.Error()
+					return m, nil
+				}
+				h.mark, h.markLen, h.status = off, 1, ""
+				h.show(off, per, rows)
+				return m, nil
+			}
+			pattern, err := parsePattern(value)
+			if err != nil {
+				h.status = err.Error()
+				return m, nil
+			}
+			h.pattern = pattern
+			return m, h.searchCmd(false)
+		}
+		var cmd tea.Cmd
+		h.input, cmd = h.input.Update(msg)
+		return m, cmd
+	}
+
+	h.status = ""
+	if key.Matches(msg, m.keys.HexView, m.keys.Escape) {
+		h.close()
+		m.hex = nil
+		return m, nil
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		h.close()
+		return m, tea.Quit
+	case "esc", "q":
+		h.close()
+		m.hex = nil
+	case "up", "k":
+		h.scroll(-1, per, rows)
+	case "down", "j":
+		h.scroll(1, per, rows)
+	case "pgup", "ctrl+u", "b":
+		h.scroll(-int64(rows), per, rows)
+	case "pgdown", "ctrl+d", " ", "f":
+		h.scroll(int64(rows), per, rows)
+	case "home", "g":
+		h.top = 0
+	case "end", "G":
+		h.top = h.clampTop(h.size, per, rows)
+	case "tab":
+		h.layout = (h.layout + 1) % numHexLayouts
+		h.top = h.clampTop(h.top, h.layout.perLine(w), rows)
+	case "o", ":":
+		return m, h.openPrompt(hexPromptOffset, "offset: 0x1f0, 4096, 50%, +0x100")
+	case "/":
+		return m, h.openPrompt(hexPromptSearch, "text, or x: followed by hex bytes")
+	case "n", "N":
+		if h.pattern == nil {
+			h.status = "no search pattern"
+			return m, nil
+		}
+		return m, h.searchCmd(msg.String() == "N")
+	}
+	return m, nil
+}
+
+func (h *hexViewer) openPrompt(p hexPrompt, placeholder string) tea.Cmd {
+	h.prompt = p
+	h.input.Placeholder = placeholder
+	h.input.SetValue("")
+	h.input.Focus()
+	return textinput.Blink
+}
+
+// searchCmd searches for h.pattern after the current match (or the top of
+// the screen), or before it when backward is set.
+func (h *hexViewer) searchCmd(backward bool) tea.Cmd {
+	if h.searching != nil {
+		h.searching()
+	}
+	from := h.top
+	if h.mark >= 0 {
+		from = h.mark
+		if !backward {
+			from++
+		}
+	}
+	if backward && from > 0 {
+		from--
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.searching = cancel
+	h.status = "searching…"
+	f, size, pattern, path := h.f, h.size, h.pattern, h.path
+	return func() tea.Msg {
+		at, err := findBytes(ctx, f, size, pattern, from, backward)
+		if ctx.Err() != nil {
+			return nil
+		}
+		return hexSearchMsg{path: path, pattern: pattern, at: at, err: err}
+	}
+}
+
+// applyHexSearch shows a finished search's result.
+func (m Model) applyHexSearch(msg hexSearchMsg) Model {
+	h := m.hex
+	if h == nil || h.path != msg.path || !bytes.Equal(h.pattern, msg.pattern) {
+		return m
+	}
+	h.searching = nil
+	switch {
+	case msg.err != nil:
+		h.status = msg.err.Error()
+	case msg.at < 0:
+		h.status = "pattern not found"
+	default:
+		w, rows := hexArea(m)
+		h.mark, h.markLen = msg.at, len(msg.pattern)
+		h.status = fmt.Sprintf("found at 0x%x", msg.at)
+		h.show(msg.at, h.layout.perLine(w), rows)
+	}
+	return m
+}
+
+// findBytes returns the offset of the first occurrence of pattern at or
+// after from, or with backward set the last one starting at or before from;
+// -1 if there is none. The file is read in steps that overlap by
+// len(pattern)-1 bytes so matches across step boundaries are found.
+func findBytes(ctx context.Context, r io.ReaderAt, size int64, pattern []byte, from int64, backward bool) (int64, error) {
+	if len(pattern) == 0 || from < 0 || from >= size {
+		return -1, nil
+	}
+	overlap := int64(len(pattern) - 1)
+	buf := make([]byte, hexSearchStep+overlap)
+	if !backward {
+		for off := from; off < size; off += hexSearchStep {
+			if err := ctx.Err(); err != nil {
+				return -1, err
+			}
+			n, err := r.ReadAt(buf, off)
+			if err != nil && err != io.EOF {
+				return -1, err
+			}
+			if i := bytes.Index(buf[:n], pattern); i >= 0 {
+				return off + int64(i), nil
+			}
+		}
+		return -1, nil
+	}
+	// Backward: windows end at from+len(pattern) so a match may start at from
+	end := min(from+int64(len(pattern)), size)
+	for end > 0 {
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+		start := max(0, end-int64(len(buf)))
+		n, err := r.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return -1, err
+		}
+		if i := bytes.LastIndex(buf[:n], pattern); i >= 0 {
+			return start + int64(i), nil
+		}
+		if start == 0 {
+			break
+		}
+		end = start + overlap
+	}
+	return -1, nil
+}
+
+// parseOffset parses a jump target: decimal ("4096"), hex ("0x1000"), a
+// percentage of the file ("50%"), or any of these relative to cur when
+// prefixed with + or -. The result is clamped to the file.
+func parseOffset(s string, cur, size int64) (int64, error) {
+	rel := 0
+	switch {
+	case strings.HasPrefix(s, "+"):
+		rel, s = 1, s[1:]
+	case strings.HasPrefix(s, "-"):
+		rel, s = -1, s[1:]
+	}
+	var n int64
+	var err error
+	switch {
+	case strings.HasSuffix(s, "%"):
+		var pct float64
+		pct, err = strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		n = int64(pct / 100 * float64(size))
+	case strings.HasPrefix(strings.ToLower(s), "0x"):
+		n, err = strconv.ParseInt(s[2:], 16, 64)
+	default:
+		n, err = strconv.ParseInt(s, 10, 64)
+	}
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	if rel != 0 {
+		n = cur + int64(rel)*n
+	}
+	return max(0, min(n, size-1)), nil
+}
+
+// parsePattern parses a search pattern: "x:" followed by hex bytes (spaces
+// allowed, e.g. "x: 7f 45 4c 46"), otherwise the literal ASCII text.
+func parsePattern(s string) ([]byte, error) {
+	rest, ok := strings.CutPrefix(s, "x:")
+	if !ok {
+		return []byte(s), nil
+	}
+	b, err := hex.DecodeString(strings.Join(strings.Fields(rest), ""))
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid hex pattern")
+	}
+	return b, nil
+}
+
+// renderHexView draws the hex viewer full-screen.
+func renderHexView(m Model) string {
+	h := m.hex
+	w, rows := hexArea(m)
+	per := h.layout.perLine(w)
+	top := h.clampTop(h.top, per, rows)
+
+	var b strings.Builder
+	pct := 100.0
+	if h.size > 0 {
+		pct = float64(min(top+int64(per*rows), h.size)) / float64(h.size) * 100
+	}
+	title := headerPathStyle.Render(truncatePath(shortenPath(h.path), w/2)) +
+		headerStatStyle.Render(fmt.Sprintf("  %s · 0x%x · %.0f%%", formatSize(h.size), top, pct)) +
+		"  " + headerBadgeStyle.Render(h.layout.String())
+	b.WriteString(headerStyle.Width(m.width).Render(title))
+	b.WriteString("\n")
+	b.WriteString(m.cachedSep)
+	b.WriteString("\n")
+
+	var data []byte
+	if h.dataTop == top {
+		data = h.data
+	}
+	for i := 0; i < rows; i++ {
+		lo := i * per
+		if lo < len(data) {
+			line := data[lo:minInt(lo+per, len(data))]
+			b.WriteString(renderHexLine(h, top+int64(lo), line, per))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.cachedSep)
+	b.WriteString("\n")
+	switch {
+	case h.prompt == hexPromptOffset:
+		b.WriteString(searchPromptStyle.Render(" goto ") + h.input.View())
+	case h.prompt == hexPromptSearch:
+		b.WriteString(searchPromptStyle.Render(" / ") + h.input.View())
+	case h.status != "":
+		b.WriteString(footerDescStyle.Render(" " + h.status))
+	default:
+		b.WriteString(footerStyle.Width(m.width).Render(
+			footerKeyStyle.Render("↑↓") + " " + footerDescStyle.Render("scroll") + "  " +
+				footerKeyStyle.Render("o") + " " + footerDescStyle.Render("offset") + "  " +
+				footerKeyStyle.Render("/") + " " + footerDescStyle.Render("search") + "  " +
+				footerKeyStyle.Render("n/N") + " " + footerDescStyle.Render("next/prev") + "  " +
+				footerKeyStyle.Render("tab") + " " + footerDescStyle.Render("layout") + "  " +
+				footerKeyStyle.Render(m.keys.closeLabel(m.keys.HexView)) + " " + footerDescStyle.Render("close")))
+	}
+	return b.String()
+}
+
+// renderHexLine renders the bytes of one line starting at off, highlighting
+// the marked range.
+func renderHexLine(h *hexViewer, off int64, data []byte, per int) string {
+	marked := func(i int) bool {
+		p := off + int64(i)
+		return h.mark >= 0 && p >= h.mark && p < h.mark+int64(maxInt(1, h.markLen))
+	}
+	cell := func(i int, s string) string {
+		if marked(i) {
+			return matchStyle.Render(s)
+		}
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteString(rowDimStyle.Render(fmt.Sprintf("%08x", off)))
+	b.WriteString("  ")
+	if h.layout == hexLayoutBytes {
+		c := data[0]
+		b.WriteString(cell(0, fmt.Sprintf("%02x  %3d  %03o  %08b  %s", c, c, c, c, printable(c))))
+		return b.String()
+	}
+	if h.layout != hexLayoutASCII {
+		for i := 0; i < per; i++ {
+			if i > 0 && i%8 == 0 {
+				b.WriteByte(' ')
+			}
+			if i < len(data) {
+				b.WriteString(cell(i, fmt.Sprintf("%02x", data[i])))
+			} else {
+				b.WriteString("  ")
+			}
+			b.WriteByte(' ')
+		}
+	}
+	if h.layout != hexLayoutHex {
+		b.WriteString(" ")
+		for i, c := range data {
+			b.WriteString(cell(i, printable(c)))
+		}
+	}
+	return b.String()
+}
+
+// printable returns c as a one-character string, or "." if it is not printable ASCII.
+func printable(c byte) string {
+	if c >= 0x20 && c < 0x7f {
+		return string(rune(c))
+	}
+	return "."
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestFindBytes(t *testing.T) {
+	// A match straddling the first search step boundary, plus one near the start
+	data := make([]byte, hexSearchStep+4096)
+	copy(data[100:], "MAGIC")
+	copy(data[hexSearchStep-2:], "MAGIC")
+	r := bytes.NewReader(data)
+	size := int64(len(data))
+	ctx := context.Background()
+
+	tests := []struct {
+		from     int64
+		backward bool
+		want     int64
+	}{
+		{0, false, 100},
+		{101, false, hexSearchStep - 2},
+		{hexSearchStep, false, -1},
+		{size - 1, true, hexSearchStep - 2},
+		{hexSearchStep - 3, true, 100},
+		{100, true, 100},
+		{99, true, -1},
+	}
+	for _, tt := range tests {
+		got, err := findBytes(ctx, r, size, []byte("MAGIC"), tt.from, tt.backward)
+		if err != nil || got != tt.want {
+			t.Errorf("findBytes(from=%d, backward=%v) = %d, %v; want %d", tt.from, tt.backward, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseOffset(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"4096", 4096},
+		{"0x10", 16},
+		{"50%", 5000},
+		{"+0x10", 1016},
+		{"-24", 976},
+		{"-5000", 0},
+		{"999999", 9999},
+	}
+	for _, tt := range tests {
+		got, err := parseOffset(tt.in, 1000, 10000)
+		if err != nil || got != tt.want {
+			t.Errorf("parseOffset(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "zz", "0xg", "+"} {
+		if _, err := parseOffset(bad, 0, 100); err == nil {
+			t.Errorf("parseOffset(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	if p, err := parsePattern("x: 7f 45 4c46"); err != nil || !bytes.Equal(p, []byte{0x7f, 'E', 'L', 'F'}) {
+		t.Errorf("hex pattern = %x, %v", p, err)
+	}
+	if p, _ := parsePattern("ELF"); string(p) != "ELF" {
+		t.Errorf("ASCII pattern = %q", p)
+	}
+	if _, err := parsePattern("x:abc"); err == nil {
+		t.Error("odd-length hex pattern accepted")
+	}
+}
+
+func TestHexViewer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	data := make([]byte, 3*hexPageSize+10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	copy(data[2*hexPageSize+5:], "needle")
+	os.WriteFile(path, data, 0o644)
+
+	m := makeTestModel(3)
+	m.cachedSep = strings.Repeat("─", m.width)
+	h, err := openHexViewer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.hex = h
+	defer h.close()
+
+	// Reads spanning pages come back intact
+	got, err := h.readAt(hexPageSize-3, 10)
+	if err != nil || !bytes.Equal(got, data[hexPageSize-3:hexPageSize+7]) {
+		t.Fatalf("readAt across pages = %v, %v", got, err)
+	}
+	if got, _ := h.readAt(int64(len(data)-4), 100); len(got) != 4 {
+		t.Errorf("readAt at EOF returned %d bytes", len(got))
+	}
+
+	// Every layout fits the terminal
+	for l := hexLayout(0); l < numHexLayouts; l++ {
+		h.layout = l
+		h.load(m)
+		for i, line := range strings.Split(renderHexView(m), "\n") {
+			if w := lipgloss.Width(line); w > m.width {
+				t.Fatalf("layout %s line %d is %d wide", l, i, w)
+			}
+		}
+	}
+	h.layout = hexLayoutCanonical
+
+	// Search moves the view to the match
+	h.pattern = []byte("needle")
+	msg := h.searchCmd(false)().(hexSearchMsg)
+	m = m.applyHexSearch(msg)
+	if h.mark != 2*hexPageSize+5 || h.markLen != 6 {
+		t.Fatalf("mark = %d+%d, want match at %d", h.mark, h.markLen, 2*hexPageSize+5)
+	}
+	w, rows := hexArea(m)
+	if per := int64(h.layout.perLine(w)); h.mark < h.top || h.mark >= h.top+per*int64(rows) {
+		t.Errorf("match at %d not on screen (top %d)", h.mark, h.top)
+	}
+
+	// Rendering shows what load read, without reading the file
+	h.load(m)
+	h.f.Close()
+	h.status = ""
+	if view := renderHexView(m); h.status != "" || !strings.Contains(view, "needle") {
+		t.Errorf("render read the file: status %q", h.status)
+	}
+
+	// esc closes the viewer
+	m, _ = m.updateHex(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.hex != nil {
+		t.Error("esc did not close the viewer")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// navHistory is the browser-style list of visited directories. Back and
+// forward move pos; browsing to a new directory from anywhere but the end
+// drops the directories after pos. Only directories on disk are recorded,
+// not ones inside archives or imports.
+type navHistory struct {
+	paths []string
+	pos   int // index of the current directory in paths
+}
+
+// visit records path as the current directory, keeping at most max paths.
+func (h *navHistory) visit(path string, max int) {
+	if h.pos < len(h.paths) && h.paths[h.pos] == path {
+		return
+	}
+	keep := min(h.pos+1, len(h.paths))
+	// Full slice expression: the model is copied by value, so never append
+	// into a backing array an older copy may still use
+	h.paths = append(h.paths[:keep:keep], path)
+	if over := len(h.paths) - max; over > 0 {
+		h.paths = h.paths[over:]
+	}
+	h.pos = len(h.paths) - 1
+}
+
+// recent returns the indexes of the distinct directories in paths, most
+// recently visited first.
+func (h navHistory) recent() []int {
+	seen := make(map[string]bool, len(h.paths))
+	var idx []int
+	for i := len(h.paths) - 1; i >= 0; i-- {
+		if !seen[h.paths[i]] {
+			seen[h.paths[i]] = true
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// recordVisit adds m.path to the history when browsing reaches a new
+// directory on disk.
+func (m Model) recordVisit() Model {
+	if m.vfs == nil && m.path != "" {
+		m.history.visit(m.path, m.settings.HistorySize)
+	}
+	return m
+}
+
+// goHistory browses to the directory at index i of the history. If it is
+// gone, the history stays where it was and the error is shown.
+func (m Model) goHistory(i int) (Model, tea.Cmd) {
+	if i < 0 || i >= len(m.history.paths) || i == m.history.pos {
+		return m, nil
+	}
+	target, prev := m.history.paths[i], m.history.pos
+	m.history.pos = i
+	m, cmd := m.navigateTo(target)
+	if m.path != target {
+		m.history.pos = prev
+	}
+	return m, cmd
+}
+
+// historyPath is the file the history is kept in between sessions.
+func historyPath() string {
+	d := stateDir()
+	if d == "" {
+		return ""
+	}
+	return filepath.Join(d, "history")
+}
+
+// loadHistory reads a history saved by saveHistory, one path per line,
+// oldest first. A missing or unreadable file is an empty history.
+func loadHistory(path string) navHistory {
+	var h navHistory
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := sc.Text(); filepath.IsAbs(line) {
+			h.paths = append(h.paths, line)
+		}
+	}
+	h.pos = max(len(h.paths)-1, 0)
+	return h
+}
+
+// saveHistory writes the history up to the current directory; directories
+// ahead of it are forgotten like on the next visit.
+func saveHistory(path string, h navHistory) error {
+	if path == "" || len(h.paths) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, p := range h.paths[:min(h.pos+1, len(h.paths))] {
+		if !strings.ContainsAny(p, "\r\n") {
+			b.WriteString(p)
+			b.WriteByte('\n')
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// historyList is the history popup: the distinct visited directories, most
+// recent first.
+type historyList struct {
+	items  []int // indexes into the history's paths
+	cursor int
+}
+
+// updateHistoryList handles keys while the history popup is open: ↑↓ move,
+// the open keys jump, escape, quit or the history key close.
+func (m Model) updateHistoryList(msg tea.KeyMsg) (Model, tea.Cmd) {
+	l := m.historyList
+	switch k := msg.String(); {
+	case key.Matches(msg, m.keys.History, m.keys.Escape, m.keys.Quit):
+		m.historyList = nil
+	case key.Matches(msg, m.keys.Up):
+		if l.cursor > 0 {
+			l.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if l.cursor < len(l.items)-1 {
+			l.cursor++
+		}
+	case k == "home" || key.Matches(msg, m.keys.Top):
+		l.cursor = 0
+	case k == "end" || key.Matches(msg, m.keys.Bottom):
+		l.cursor = max(len(l.items)-1, 0)
+	case key.Matches(msg, m.keys.Right):
+		m.historyList = nil
+		if len(l.items) > 0 {
+			return m.goHistory(l.items[l.cursor])
+		}
+	}
+	return m, nil
+}
+
+// renderHistoryList renders the history popup.
+func renderHistoryList(m Model) string {
+	l := m.historyList
+	var b strings.Builder
+	b.WriteString("\n")
+	b.WriteString(helpTitleStyle.Render("  History"))
+	b.WriteString("\n\n")
+
+	w := minInt(80, m.width-4)
+	rows := maxInt(1, m.height-10)
+	start := 0
+	if l.cursor >= rows {
+		start = l.cursor - rows + 1
+	}
+	if len(l.items) == 0 {
+		b.WriteString(rowDimStyle.Render("  no directories visited yet"))
+		b.WriteString("\n")
+	}
+	for i := start; i < len(l.items) && i < start+rows; i++ {
+		idx := l.items[i]
+		pointer := "  "
+		if i == l.cursor {
+			pointer = "▶ "
+		}
+		size := ""
+		if cached, ok := m.cache.Peek(m.history.paths[idx]); ok {
+			size = formatSize(cached.totalSize)
+		}
+		nameSt := rowNameStyle
+		if idx == m.history.pos {
+			nameSt = rowNameSelStyle
+		}
+		name := truncateStrVisual(shortenPath(m.history.paths[idx]), maxInt(8, w-16))
+		b.WriteString(rowPointerActiveStyle.Render(pointer))
+		b.WriteString(nameSt.Render(padRightVisual(name, maxInt(8, w-16))))
+		b.WriteString(rowDimStyle.Render(padLeft(size, 9)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(footerStyle.Render("  ⏎ go  " + m.keys.closeLabel(m.keys.History) + " close"))
+	b.WriteString("\n")
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorCyan).
+		Padding(0, 2).
+		Width(w)
+
+	return lipgloss.Place(m.width, m.height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(b.String()))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNavHistory(t *testing.T) {
+	var h navHistory
+	for _, p := range []string{"/a", "/b", "/b", "/c"} {
+		h.visit(p, 10)
+	}
+	if !reflect.DeepEqual(h.paths, []string{"/a", "/b", "/c"}) || h.pos != 2 {
+		t.Fatalf("got %v at %d", h.paths, h.pos)
+	}
+
+	// Visiting from the middle drops what was ahead, without touching
+	// an older copy's backing array
+	old := h
+	h.pos = 0
+	h.visit("/d", 10)
+	if !reflect.DeepEqual(h.paths, []string{"/a", "/d"}) || h.pos != 1 {
+		t.Errorf("got %v at %d", h.paths, h.pos)
+	}
+	if old.paths[1] != "/b" {
+		t.Errorf("older copy changed: %v", old.paths)
+	}
+
+	h.visit("/a", 10)
+	if got := h.recent(); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("recent = %v, want [2 1]", got)
+	}
+
+	// Oldest entries are dropped past the limit
+	h.visit("/e", 3)
+	if !reflect.DeepEqual(h.paths, []string{"/d", "/a", "/e"}) || h.pos != 2 {
+		t.Errorf("got %v at %d", h.paths, h.pos)
+	}
+}
+
+func TestHistorySaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "history")
+	h := navHistory{paths: []string{"/a", "/b", "/c"}, pos: 1}
+	if err := saveHistory(path, h); err != nil {
+		t.Fatal(err)
+	}
+	got := loadHistory(path)
+	if !reflect.DeepEqual(got.paths, []string{"/a", "/b"}) || got.pos != 1 {
+		t.Errorf("got %v at %d", got.paths, got.pos)
+	}
+	if h := loadHistory(filepath.Join(t.TempDir(), "none")); len(h.paths) != 0 {
+		t.Errorf("missing file: %v", h.paths)
+	}
+}
+
+func TestBackForward(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	os.Mkdir(a, 0o755)
+	os.Mkdir(b, 0o755)
+
+	m := NewModel(root)
+	m.width, m.height = 80, 24
+	step := func(msg tea.Msg) {
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	step(scanResultMsg{path: root})
+	m, _ = m.navigateTo(a)
+	step(scanResultMsg{path: a})
+	m, _ = m.navigateTo(b)
+	step(scanResultMsg{path: b})
+
+	step(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	step(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	if m.path != root {
+		t.Fatalf("after two backs at %s, want %s", m.path, root)
+	}
+	step(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	if m.path != a || len(m.history.paths) != 3 {
+		t.Fatalf("after forward at %s with %v", m.path, m.history.paths)
+	}
+
+	// A directory that is gone leaves the history where it was
+	os.Remove(b)
+	step(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	if m.path != a || m.history.pos != 1 || m.err == nil {
+		t.Errorf("at %s pos %d err %v", m.path, m.history.pos, m.err)
+	}
+
+	// The popup starts on the most recent directory other than this one
+	os.Mkdir(b, 0o755)
+	step(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	if m.historyList == nil || m.historyList.cursor != 0 {
+		t.Fatalf("popup: %+v", m.historyList)
+	}
+	step(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.historyList != nil || m.path != b || m.history.pos != 2 {
+		t.Errorf("after enter at %s pos %d", m.path, m.history.pos)
+	}
+}
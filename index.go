@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// treeIndex is every path below a directory. Listings keep only two levels
+// of the scanned tree, so the fuzzy jump (J) and recursive search (F) scan
+// the whole tree once more and keep it until browsing leaves the directory.
+type treeIndex struct {
+	root  string      // directory the paths are relative to
+	node  *scan.Node  // the whole tree
+	items []indexItem // every descendant, depth-first in size order
+
+	order []int // 0..len(items)-1, for tree order
+	sized []int // items by size, largest first; built on first use
+}
+
+// indexItem is one path in a treeIndex.
+type indexItem struct {
+	rel  string // slash-separated path relative to the root
+	node *scan.Node
+}
+
+// indexMsg is sent when an index scan finishes.
+type indexMsg struct {
+	root  string
+	index *treeIndex
+	err   error
+}
+
+// newTreeIndex flattens the tree under node, which is displayed at root.
+func newTreeIndex(root string, node *scan.Node) *treeIndex {
+	idx := &treeIndex{root: root, node: node}
+	node.Walk(func(rel string, n *scan.Node) bool {
+		if rel != "" {
+			idx.items = append(idx.items, indexItem{rel: rel, node: n})
+		}
+		return true
+	})
+	return idx
+}
+
+// tree returns the indexes of all items in tree order.
+func (idx *treeIndex) tree() []int {
+	if idx.order == nil {
+		idx.order = make([]int, len(idx.items))
+		for i := range idx.order {
+			idx.order[i] = i
+		}
+	}
+	return idx.order
+}
+
+// bySize returns the indexes of all items, largest first.
+func (idx *treeIndex) bySize() []int {
+	if idx.sized == nil {
+		idx.sized = append([]int(nil), idx.tree()...)
+		sort.SliceStable(idx.sized, func(i, j int) bool {
+			return idx.items[idx.sized[i]].node.Size > idx.items[idx.sized[j]].node.Size
+		})
+	}
+	return idx.sized
+}
+
+// path returns the full path of item i.
+func (idx *treeIndex) path(i int) string {
+	return filepath.Join(idx.root, filepath.FromSlash(idx.items[i].rel))
+}
+
+// indexCmd scans the whole tree under m.path, inside the archive or import
+// being browsed if there is one.
+func (m Model) indexCmd(ctx context.Context, prog *scan.Progress) tea.Cmd {
+	root := m.path
+	var fsys scan.FS
+	dir := "."
+	if m.vfs != nil && m.vfs.contains(root) {
+		fsys, dir = m.vfs.fsys, m.vfs.name(root)
+	} else {
+		fsys = scan.DirFS(root)
+	}
+	return func() tea.Msg {
+		node, err := scan.Dir(ctx, fsys, dir, scan.Options{Progress: prog})
+		if err != nil {
+			return indexMsg{root: root, err: err}
+		}
+		return indexMsg{root: root, index: newTreeIndex(root, node)}
+	}
+}
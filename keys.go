@@ -1,123 +1,389 @@
 package main
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
-// KeyMap defines all keybindings.
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// KeyMap defines all keybindings. A binding's help key lists its keys and
+// its help description is the line shown in the help overlay.
+//
+// Keys are Bubble Tea key names ("j", "ctrl+d", "pgup", " " for space).
+// A key may also be a sequence of keys separated by spaces, such as "g g";
+// see keySeq.
 type KeyMap struct {
-	Up        key.Binding
-	Down      key.Binding
-	Left      key.Binding
-	Right     key.Binding
-	Top       key.Binding
-	Bottom    key.Binding
-	PageUp    key.Binding
-	PageDown  key.Binding
-	QuickLook key.Binding
-	Refresh   key.Binding
-	TopView   key.Binding
-	Open      key.Binding
-	Search    key.Binding
-	Hidden    key.Binding
-	DirOnly   key.Binding
-	Help      key.Binding
-	Quit      key.Binding
-	Escape    key.Binding
-	CountAll  key.Binding
-	GoTo      key.Binding
-	Delete    key.Binding
-	HexView   key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	Left         key.Binding
+	Right        key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	QuickLook    key.Binding
+	Refresh      key.Binding
+	TopView      key.Binding
+	Open         key.Binding
+	Search       key.Binding
+	Hidden       key.Binding
+	DirOnly      key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+	Escape       key.Binding
+	CountAll     key.Binding
+	GoTo         key.Binding
+	Delete       key.Binding
+	HexView      key.Binding
+	Compress     key.Binding
+	Sort         key.Binding
+	SortOrder    key.Binding
+	Columns      key.Binding
+	Tree         key.Binding
+	Treemap      key.Binding
+	ChartPane    key.Binding
+	PreviewPane  key.Binding
+	Pager        key.Binding
+	Edit         key.Binding
+	Mark         key.Binding
+	ActionOutput key.Binding
+	Back         key.Binding
+	Forward      key.Binding
+	History      key.Binding
+	SetBookmark  key.Binding
+	JumpBookmark key.Binding
+	Bookmarks    key.Binding
+	Jump         key.Binding
+	Find         key.Binding
+	Dupes        key.Binding
+}
+
+// bind returns a binding for keys with desc as its help text.
+func bind(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keysLabel(keys), desc))
 }
 
 // DefaultKeyMap returns the default key bindings.
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Up: key.NewBinding(
-			key.WithKeys("up", "k"),
-			key.WithHelp("↑/k", "move up"),
-		),
-		Down: key.NewBinding(
-			key.WithKeys("down", "j"),
-			key.WithHelp("↓/j", "move down"),
-		),
-		Left: key.NewBinding(
-			key.WithKeys("left", "backspace"),
-			key.WithHelp("←", "parent dir"),
-		),
-		Right: key.NewBinding(
-			key.WithKeys("right", "l", "enter"),
-			key.WithHelp("→/l", "open dir"),
-		),
-		Top: key.NewBinding(
-			key.WithKeys("g"),
-			key.WithHelp("g", "go to top"),
-		),
-		Bottom: key.NewBinding(
-			key.WithKeys("G"),
-			key.WithHelp("G", "go to bottom"),
-		),
-		PageUp: key.NewBinding(
-			key.WithKeys("pgup", "ctrl+u"),
-			key.WithHelp("PgUp/^U", "page up"),
-		),
-		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", "ctrl+d"),
-			key.WithHelp("PgDn/^D", "page down"),
-		),
-		QuickLook: key.NewBinding(
-			key.WithKeys(" "),
-			key.WithHelp("space", "quick look"),
-		),
-		Refresh: key.NewBinding(
-			key.WithKeys("r"),
-			key.WithHelp("r", "refresh"),
-		),
-		TopView: key.NewBinding(
-			key.WithKeys("t"),
-			key.WithHelp("t", "top 10 view"),
-		),
-		Open: key.NewBinding(
-			key.WithKeys("o"),
-			key.WithHelp("o", "open in finder"),
-		),
-		Search: key.NewBinding(
-			key.WithKeys("/"),
-			key.WithHelp("/", "search/filter"),
-		),
-		Hidden: key.NewBinding(
-			key.WithKeys("h"),
-			key.WithHelp("h", "toggle hidden"),
-		),
-		DirOnly: key.NewBinding(
-			key.WithKeys("f"),
-			key.WithHelp("f", "cycle filter"),
-		),
-		Delete: key.NewBinding(
-			key.WithKeys("d"),
-			key.WithHelp("d", "move to trash"),
-		),
-		Help: key.NewBinding(
-			key.WithKeys("?"),
-			key.WithHelp("?", "help"),
-		),
-		Quit: key.NewBinding(
-			key.WithKeys("q", "ctrl+c"),
-			key.WithHelp("q", "quit"),
-		),
-		Escape: key.NewBinding(
-			key.WithKeys("esc"),
-			key.WithHelp("esc", "cancel"),
-		),
-		CountAll: key.NewBinding(
-			key.WithKeys("s"),
-			key.WithHelp("s", "count lines (all)"),
-		),
-		GoTo: key.NewBinding(
-			key.WithKeys("c"),
-			key.WithHelp("c", "cd to path"),
-		),
-		HexView: key.NewBinding(
-			key.WithKeys("x"),
-			key.WithHelp("x", "hex view (binary)"),
-		),
+		Up:           bind("Move cursor up", "up", "k"),
+		Down:         bind("Move cursor down", "down", "j"),
+		Left:         bind("Go to parent (remembers position)", "left", "backspace"),
+		Right:        bind("Open dir / browse archive / open file", "right", "l", "enter"),
+		Top:          bind("Jump to top of list", "g"),
+		Bottom:       bind("Jump to bottom of list", "G"),
+		PageUp:       bind("Page up", "pgup", "ctrl+u"),
+		PageDown:     bind("Page down", "pgdown", "ctrl+d"),
+		QuickLook:    bind("Quick Look / preview", " "),
+		Refresh:      bind("Refresh (smart — skips if unchanged)", "r"),
+		TopView:      bind("Toggle top view (largest entries)", "t"),
+		Open:         bind("Open in file manager", "o"),
+		Search:       bind("Search / filter files", "/"),
+		Hidden:       bind("Toggle hidden files", "h"),
+		DirOnly:      bind("Cycle filter: all → dirs → files", "f"),
+		Help:         bind("Show this help", "?"),
+		Quit:         bind("Quit", "q", "ctrl+c"),
+		Escape:       bind("Cancel search / close help / clear marks", "esc"),
+		CountAll:     bind("Count lines for all files", "s"),
+		GoTo:         bind("Go to directory (cd)", "c"),
+		Delete:       bind("Move selected entry to Trash", "d"),
+		HexView:      bind("Hex viewer: o offset, / search (x: for bytes), tab layout", "x"),
+		Compress:     bind("Compress to tar.gz/tar.zst, then trash", "z"),
+		Sort:         bind("Cycle sort: size → name → mtime…", "S"),
+		SortOrder:    bind("Reverse sort order", "O"),
+		Columns:      bind("Choose and reorder columns", "C"),
+		Tree:         bind("Tree view: → expand, ← collapse", "T"),
+		Treemap:      bind("Treemap: ⏎ zoom in, BS zoom out", "M"),
+		ChartPane:    bind("Toggle composition chart pane", "b"),
+		PreviewPane:  bind("Toggle preview pane (text, hex, image, archive, directory)", "p"),
+		Pager:        bind("View text file: / search, : line, w wrap, F follow, e edit", "v"),
+		Edit:         bind("Edit file in $VISUAL / $EDITOR", "e"),
+		Mark:         bind("Mark / unmark entry for actions", "tab"),
+		ActionOutput: bind("Show the output of the last background action", "A"),
+		Back:         bind("Back to the previous directory in history", "["),
+		Forward:      bind("Forward in history", "]"),
+		History:      bind("History of visited directories", "H"),
+		SetBookmark:  bind("Bookmark this directory under the next key", "m"),
+		JumpBookmark: bind("Go to the bookmark of the next key", "'"),
+		Bookmarks:    bind("List bookmarks with their sizes", "B"),
+		Jump:         bind("Fuzzy jump to any directory in the tree", "J"),
+		Find:         bind("Search the whole tree, largest first", "F"),
+		Dupes:        bind("Find duplicate files in the tree: trash, hard-link or reflink copies", "D"),
+	}
+}
+
+// namedBinding is a binding with its name in the [keys] config table and
+// its label in the footer ("" to leave it out). Adjacent bindings with the
+// same footer label share one footer entry, e.g. "↑↓ nav".
+type namedBinding struct {
+	name   string
+	b      *key.Binding
+	footer string
+}
+
+// named returns every binding of k in help overlay order.
+func (k *KeyMap) named() []namedBinding {
+	return []namedBinding{
+		{"up", &k.Up, "nav"},
+		{"down", &k.Down, "nav"},
+		{"page_up", &k.PageUp, ""},
+		{"page_down", &k.PageDown, ""},
+		{"left", &k.Left, "back"},
+		{"right", &k.Right, "open"},
+		{"quick_look", &k.QuickLook, "qlook"},
+		{"top", &k.Top, ""},
+		{"bottom", &k.Bottom, ""},
+		{"refresh", &k.Refresh, "refresh"},
+		{"top_view", &k.TopView, "top"},
+		{"open", &k.Open, "open"},
+		{"search", &k.Search, "search"},
+		{"escape", &k.Escape, ""},
+		{"goto", &k.GoTo, "cd"},
+		{"jump", &k.Jump, ""},
+		{"find", &k.Find, "find"},
+		{"dupes", &k.Dupes, ""},
+		{"back", &k.Back, "hist"},
+		{"forward", &k.Forward, "hist"},
+		{"history", &k.History, ""},
+		{"set_bookmark", &k.SetBookmark, ""},
+		{"jump_bookmark", &k.JumpBookmark, ""},
+		{"bookmarks", &k.Bookmarks, "marks"},
+		{"hidden", &k.Hidden, "hidden"},
+		{"filter", &k.DirOnly, "filter"},
+		{"sort", &k.Sort, "sort"},
+		{"sort_order", &k.SortOrder, ""},
+		{"columns", &k.Columns, "cols"},
+		{"tree", &k.Tree, "tree"},
+		{"treemap", &k.Treemap, "map"},
+		{"chart_pane", &k.ChartPane, "chart"},
+		{"preview_pane", &k.PreviewPane, "preview"},
+		{"pager", &k.Pager, "view"},
+		{"edit", &k.Edit, "edit"},
+		{"mark", &k.Mark, "mark"},
+		{"action_output", &k.ActionOutput, ""},
+		{"delete", &k.Delete, "trash"},
+		{"compress", &k.Compress, "compress"},
+		{"count_lines", &k.CountAll, "lines"},
+		{"hex_view", &k.HexView, "hex"},
+		{"help", &k.Help, "help"},
+		{"quit", &k.Quit, "quit"},
+	}
+}
+
+// remap replaces the keys of the binding called name with specs, key names
+// or sequences as written in the config. An empty list disables it.
+func (k *KeyMap) remap(name string, specs []string) error {
+	for _, nb := range k.named() {
+		if nb.name != name {
+			continue
+		}
+		keys := make([]string, len(specs))
+		for i, s := range specs {
+			seq, err := parseKeySpec(s)
+			if err != nil {
+				return err
+			}
+			keys[i] = seq
+		}
+		desc := nb.b.Help().Desc
+		*nb.b = bind(desc, keys...)
+		if len(keys) == 0 {
+			nb.b.SetEnabled(false)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown binding %q", name)
+}
+
+// conflicts reports keys bound twice, and keys that are the start of a
+// sequence bound elsewhere (which would make the sequence unreachable).
+// extra holds more bindings to check, such as custom actions.
+func (k *KeyMap) conflicts(extra []namedBinding) []error {
+	owner := make(map[string]string)
+	var errs []error
+	add := func(name string, keys []string) {
+		for _, ks := range keys {
+			if prev, ok := owner[ks]; ok && prev != name {
+				errs = append(errs, fmt.Errorf("key %q is bound to both %s and %s", keysLabel([]string{ks}), prev, name))
+				continue
+			}
+			owner[ks] = name
+		}
+	}
+	for _, nb := range append(k.named(), extra...) {
+		add(nb.name, nb.b.Keys())
+	}
+	for ks, name := range owner {
+		steps := strings.Split(ks, " ")
+		for i := 1; i < len(steps); i++ {
+			prefix := strings.Join(steps[:i], " ")
+			if other, ok := owner[prefix]; ok {
+				errs = append(errs, fmt.Errorf("key %q (%s) starts the sequence %q (%s)",
+					keysLabel([]string{prefix}), other, keysLabel([]string{ks}), name))
+			}
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// lookup reports whether seq is bound, in k or among extra, and whether it
+// is the start of a longer bound sequence.
+func (k *KeyMap) lookup(seq string, extra []string) (bound, prefix bool) {
+	p := seq + " "
+	check := func(keys []string) {
+		for _, ks := range keys {
+			bound = bound || ks == seq
+			prefix = prefix || strings.HasPrefix(ks, p)
+		}
+	}
+	for _, nb := range k.named() {
+		if nb.b.Enabled() {
+			check(nb.b.Keys())
+		}
+	}
+	check(extra)
+	return bound, prefix
+}
+
+// resolveKey adds msg to the pending key sequence. It returns the key or
+// sequence to dispatch, or pending when msg starts a longer sequence. Keys
+// that do not continue the pending sequence are taken on their own.
+func (m *Model) resolveKey(msg tea.KeyMsg) (ks keySeq, pending bool) {
+	actions := make([]string, len(m.actions))
+	for i, a := range m.actions {
+		actions[i] = a.Key
+	}
+	k := msg.String()
+	if m.pendingKeys != "" {
+		seq := m.pendingKeys + " " + k
+		m.pendingKeys = ""
+		bound, prefix := m.keys.lookup(seq, actions)
+		if prefix {
+			m.pendingKeys = seq
+			return "", true
+		}
+		if bound {
+			return keySeq(seq), false
+		}
+	}
+	if _, prefix := m.keys.lookup(k, actions); prefix {
+		m.pendingKeys = k
+		return "", true
+	}
+	return keySeq(k), false
+}
+
+// keySeq is a key or key sequence for key.Matches.
+type keySeq string
+
+func (k keySeq) String() string { return string(k) }
+
+// namedKeys are the multi-letter key names of Bubble Tea, and "space".
+var namedKeys = map[string]bool{
+	"up": true, "down": true, "left": true, "right": true, "enter": true,
+	"tab": true, "backspace": true, "delete": true, "insert": true, "esc": true,
+	"home": true, "end": true, "pgup": true, "pgdown": true, "space": true,
+}
+
+// parseKeySpec normalizes a key as written in the config: a key name
+// ("j", "ctrl+d", "pgup", "space"), space-separated keys for a sequence
+// ("g g", "ctrl+x ctrl+s"), or a run of plain characters as shorthand for
+// a sequence ("gg", "dd").
+func parseKeySpec(s string) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			return " ", nil // a literal space
+		}
+		return "", fmt.Errorf("empty key")
+	}
+	var steps []string
+	for _, f := range fields {
+		switch {
+		case f == "space" && len(fields) > 1:
+			return "", fmt.Errorf("space cannot be part of a sequence in %q", s)
+		case f == "space":
+			steps = append(steps, " ")
+		case namedKeys[f] || utf8.RuneCountInString(f) == 1 || isFunctionKey(f):
+			steps = append(steps, f)
+		case strings.Contains(f, "+"):
+			mod, rest, _ := strings.Cut(f, "+")
+			if rest == "" || (mod != "ctrl" && mod != "alt" && mod != "shift") {
+				return "", fmt.Errorf("invalid key %q", f)
+			}
+			steps = append(steps, f)
+		default:
+			for _, r := range f {
+				steps = append(steps, string(r))
+			}
+		}
+	}
+	return strings.Join(steps, " "), nil
+}
+
+func isFunctionKey(s string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "f"))
+	return strings.HasPrefix(s, "f") && err == nil && n >= 1 && n <= 20
+}
+
+// keyNames are the labels shown for keys in the footer and help overlay.
+var keyNames = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→", "enter": "⏎",
+	" ": "␣", "backspace": "BS", "esc": "Esc", "tab": "Tab",
+	"pgup": "PgUp", "pgdown": "PgDn", "delete": "Del", "home": "Home", "end": "End",
+}
+
+// keyLabel returns how the key or sequence ks is shown, e.g. "^U" or "gg".
+func keyLabel(ks string) string {
+	steps := strings.Split(ks, " ")
+	if ks == " " {
+		steps = []string{" "}
+	}
+	var b strings.Builder
+	for _, step := range steps {
+		switch {
+		case keyNames[step] != "":
+			b.WriteString(keyNames[step])
+		case strings.HasPrefix(step, "ctrl+") && len(step) == 6:
+			b.WriteString("^" + strings.ToUpper(step[5:]))
+		default:
+			b.WriteString(step)
+		}
+	}
+	return b.String()
+}
+
+// shortLabel is the label of b's first key, or "" if it has none.
+func shortLabel(b key.Binding) string {
+	if len(b.Keys()) == 0 {
+		return ""
+	}
+	return keyLabel(b.Keys()[0])
+}
+
+// closeLabel is the footer label for the keys closing an overlay that b
+// toggles: the first escape key and b's first key, e.g. "esc/H".
+func (k *KeyMap) closeLabel(b key.Binding) string {
+	labels := make([]string, 0, 2)
+	for _, l := range []string{shortLabel(k.Escape), shortLabel(b)} {
+		if l != "" {
+			labels = append(labels, l)
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// keysLabel lists keys for the help overlay, e.g. "↑ / k".
+func keysLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, ks := range keys {
+		labels[i] = keyLabel(ks)
 	}
+	return strings.Join(labels, " / ")
 }
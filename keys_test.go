@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseKeySpec(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"j", "j"},
+		{"ctrl+d", "ctrl+d"},
+		{"pgup", "pgup"},
+		{"space", " "},
+		{" ", " "},
+		{"f12", "f12"},
+		{"gg", "g g"},
+		{"dd", "d d"},
+		{"g g", "g g"},
+		{"ctrl+x ctrl+s", "ctrl+x ctrl+s"},
+	}
+	for _, tt := range tests {
+		got, err := parseKeySpec(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseKeySpec(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "hyper+x", "ctrl+", "g space"} {
+		if _, err := parseKeySpec(bad); err == nil {
+			t.Errorf("parseKeySpec(%q) accepted", bad)
+		}
+	}
+
+	for ks, want := range map[string]string{"g g": "gg", "ctrl+u": "^U", " ": "␣", "up": "↑", "alt+x": "alt+x"} {
+		if got := keyLabel(ks); got != want {
+			t.Errorf("keyLabel(%q) = %q, want %q", ks, got, want)
+		}
+	}
+}
+
+func TestDefaultKeyMapHasNoConflicts(t *testing.T) {
+	km := DefaultKeyMap()
+	if errs := km.conflicts(nil); len(errs) > 0 {
+		t.Errorf("default bindings conflict: %v", errs)
+	}
+}
+
+func TestKeysConfig(t *testing.T) {
+	load := func(keys string) (config, error) {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		os.WriteFile(path, []byte("[keys]\n"+keys), 0o644)
+		return loadConfig(path)
+	}
+
+	c, err := load(`
+left = ["left", "backspace", "h"]
+hidden = "."
+delete = "dd"
+top = "gg"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	km, _ := c.keyMap()
+	if strings.Join(km.Left.Keys(), ",") != "left,backspace,h" || km.Left.Help().Key != "← / BS / h" {
+		t.Errorf("left: %q (%q)", km.Left.Keys(), km.Left.Help().Key)
+	}
+	if km.Delete.Keys()[0] != "d d" || km.Delete.Help().Desc != DefaultKeyMap().Delete.Help().Desc {
+		t.Errorf("delete: %q %q", km.Delete.Keys(), km.Delete.Help().Desc)
+	}
+
+	tests := []struct {
+		keys, want string
+	}{
+		{`left = ["left", "h"]`, `key "h" is bound to both left and hidden`},
+		{`bottom = "g"` + "\n" + `top = "gg"`, `key "g" (bottom) starts the sequence "gg" (top)`},
+		{`teleport = "J"`, `keys.teleport: unknown binding "teleport"`},
+		{`up = 5`, `keys must be a string or a list of strings`},
+	}
+	for _, tt := range tests {
+		_, err := load(tt.keys)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.keys, err, tt.want)
+		}
+	}
+}
+
+func TestKeySequences(t *testing.T) {
+	c := config{settings: defaultSettings(), Keys: map[string]keyList{"top": {"gg"}, "hidden": {"."}}}
+	m := makeTestModel(5).withConfig(c)
+	m.cursor = 3
+
+	press := func(k string) {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = next.(Model)
+	}
+	press("g")
+	if m.pendingKeys != "g" || m.cursor != 3 {
+		t.Fatalf("first g: pending %q, cursor %d", m.pendingKeys, m.cursor)
+	}
+	if !strings.Contains(renderFooter(m), "g…") {
+		t.Error("pending key not shown in the footer")
+	}
+	press("g")
+	if m.pendingKeys != "" || m.cursor != 0 {
+		t.Fatalf("gg: pending %q, cursor %d", m.pendingKeys, m.cursor)
+	}
+
+	// A key that does not continue the sequence is taken on its own
+	press("g")
+	press("j")
+	if m.pendingKeys != "" || m.cursor != 1 {
+		t.Errorf("g j: pending %q, cursor %d", m.pendingKeys, m.cursor)
+	}
+
+	// Footer and help follow the remapped keys
+	footer := renderFooter(m)
+	if !strings.Contains(footer, footerKeyStyle.Render(".")+" "+footerDescStyle.Render("hidden")) ||
+		!strings.Contains(footer, footerKeyStyle.Render("↑↓")) {
+		t.Errorf("footer not built from the key map: %q", footer)
+	}
+	if !strings.Contains(renderHelp(m), "gg") {
+		t.Error("help overlay not built from the key map")
+	}
+}
+
+func TestRemappedOverlayKeys(t *testing.T) {
+	c := config{settings: defaultSettings(), Keys: map[string]keyList{
+		"columns": {"X"}, "treemap": {"W"}, "history": {"Y"}, "escape": {"Z"}, "down": {"N"},
+	}}
+	m := makeTestModel(5).withConfig(c)
+	press := func(k string) {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		m = next.(Model)
+	}
+
+	press("X")
+	if m.colChooser == nil || !strings.Contains(renderColumnChooser(m), "Z/X close") {
+		t.Fatal("column chooser not opened with its remapped key")
+	}
+	press("X")
+	if m.colChooser != nil {
+		t.Error("column chooser not closed by its remapped key")
+	}
+
+	press("W")
+	if !m.treemap || !strings.Contains(renderFooter(m), footerKeyStyle.Render("Z/W")) ||
+		!strings.Contains(renderFooter(m), footerKeyStyle.Render("←↑N→")) {
+		t.Fatal("treemap footer not built from the key map")
+	}
+	press("W")
+	if m.treemap {
+		t.Error("treemap not closed by its remapped key")
+	}
+	press("Y")
+	if m.historyList == nil || !strings.Contains(renderHistoryList(m), "Z/Y close") {
+		t.Fatal("history popup not opened with its remapped key")
+	}
+	press("Y")
+	if m.historyList != nil {
+		t.Error("history popup not closed by its remapped key")
+	}
+
+	// Overlays move and close with the remapped movement and escape keys
+	press("X")
+	press("N")
+	if m.colChooser == nil || m.colChooser.cursor != 1 {
+		t.Fatal("column chooser cursor not moved by the remapped down key")
+	}
+	press("Z")
+	if m.colChooser != nil {
+		t.Error("column chooser not closed by the remapped escape key")
+	}
+	press("W")
+	press("Z")
+	if m.treemap {
+		t.Error("treemap not closed by the remapped escape key")
+	}
+}
@@ -16,6 +16,10 @@ var version = "dev"
 func main() {
 	profileFlag := flag.Bool("profile", false, "enable CPU profiling (writes cpu.prof)")
 	versionFlag := flag.Bool("version", false, "print version and exit")
+	importFlag := flag.String("import", "", "browse an ncdu JSON export (ncdu -o file) instead of scanning")
+	columnsFlag := flag.String("columns", "", "comma-separated columns right of the name (default size,lines; see C in the app)")
+	configFlag := flag.String("config", defaultConfigPath(), "settings file (TOML)")
+	themeFlag := flag.String("theme", "", "dark, light, high-contrast, monochrome, a theme file, or auto (default from the config)")
 	flag.Parse()
 
 	if *versionFlag {
@@ -37,6 +41,55 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *themeFlag != "" {
+		cfg.Theme = *themeFlag
+	}
+	t, err := loadTheme(cfg.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: theme: %v\n", err)
+		os.Exit(1)
+	}
+	applyTheme(t)
+
+	if *columnsFlag != "" {
+		cols, err := parseColumns(*columnsFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --columns: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Columns = make([]string, len(cols))
+		for i, id := range cols {
+			cfg.Columns[i] = columns[id].key
+		}
+	}
+
+	// dirgo config dump prints the settings in effect
+	if args := flag.Args(); len(args) == 2 && args[0] == "config" && args[1] == "dump" {
+		if err := cfg.dump(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *importFlag != "" {
+		view, err := ncduLoader(*importFlag)(nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		model := NewModel(view.path).withConfig(cfg)
+		model.vfs = view
+		run(model)
+		return
+	}
+
 	// Determine target path from positional args or default to current directory.
 	path := "."
 	args := flag.Args()
@@ -61,11 +114,31 @@ func main() {
 		os.Exit(1)
 	}
 
-	model := NewModel(absPath)
+	run(NewModel(absPath).withConfig(cfg))
+}
+
+// run starts the Bubble Tea program for model and exits on failure. The
+// navigation history is restored from the last session and saved on exit,
+// and bookmarks are loaded.
+func run(model Model) {
+	model.history = loadHistory(historyPath())
+	model.bookmarksPath = bookmarksPath()
+	bookmarks, err := loadBookmarks(model.bookmarksPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+	model.bookmarks = bookmarks
 	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	if m, ok := final.(Model); ok {
+		if err := saveHistory(historyPath(), m.history); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: saving history: %v\n", err)
+		}
+	}
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// memNode is a file or directory in an in-memory tree. Archive listings and
+// imported ncdu dumps are both loaded into memNode trees and browsed via memFS.
+// Directory sizes and counts are aggregated from descendants by finalize.
+type memNode struct {
+	name      string
+	isDir     bool
+	isSymlink bool
+	size      int64 // apparent bytes (uncompressed for archive members)
+	diskSize  int64 // allocated or compressed bytes; 0 if unknown
+	inode     uint64
+	nlink     uint64
+	modTime   time.Time
+	files     int // descendant files (dirs only)
+	dirs      int // descendant dirs (dirs only)
+	children  map[string]*memNode
+}
+
+// newMemDir returns an empty directory node.
+func newMemDir(name string) *memNode {
+	return &memNode{name: name, isDir: true, children: make(map[string]*memNode)}
+}
+
+// child returns the named child directory, creating it if needed.
+func (n *memNode) child(name string) *memNode {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+	c := newMemDir(name)
+	n.children[name] = c
+	return c
+}
+
+// add inserts a node at the given slash-separated path, creating parent dirs.
+// Paths escaping the root ("../x") are skipped; leading "/" and "./" are ignored.
+func (n *memNode) add(p string, member *memNode) {
+	p = path.Clean(strings.TrimLeft(p, "/"))
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return
+	}
+	parts := strings.Split(p, "/")
+	dir := n
+	for _, part := range parts[:len(parts)-1] {
+		dir = dir.child(part)
+	}
+	last := parts[len(parts)-1]
+	if member.isDir {
+		// Keep children already added via implied paths; take the explicit metadata.
+		d := dir.child(last)
+		d.modTime = member.modTime
+		return
+	}
+	member.name = last
+	dir.children[last] = member
+}
+
+// finalize aggregates sizes and counts bottom-up.
+func (n *memNode) finalize() {
+	if !n.isDir {
+		return
+	}
+	n.size, n.diskSize, n.files, n.dirs = 0, 0, 0, 0
+	for _, c := range n.children {
+		c.finalize()
+		n.size += c.size
+		n.diskSize += c.diskSize
+		if c.isDir {
+			n.dirs += 1 + c.dirs
+			n.files += c.files
+		} else {
+			n.files++
+		}
+	}
+}
+
+// lookup returns the node at the slash-separated fs path, or nil.
+func (n *memNode) lookup(name string) *memNode {
+	if name == "" || name == "." {
+		return n
+	}
+	cur := n
+	for _, part := range strings.Split(name, "/") {
+		c, ok := cur.children[part]
+		if !ok {
+			return nil
+		}
+		cur = c
+	}
+	return cur
+}
+
+// fs.FileInfo and fs.DirEntry implementation. Directories report size 0 like
+// the scanner expects; their aggregate size lives in n.size.
+
+func (n *memNode) Name() string { return n.name }
+func (n *memNode) Size() int64 {
+	if n.isDir {
+		return 0
+	}
+	return n.size
+}
+func (n *memNode) Mode() fs.FileMode {
+	switch {
+	case n.isDir:
+		return fs.ModeDir | 0o755
+	case n.isSymlink:
+		return fs.ModeSymlink | 0o777
+	default:
+		return 0o644
+	}
+}
+func (n *memNode) ModTime() time.Time         { return n.modTime }
+func (n *memNode) IsDir() bool                { return n.isDir }
+func (n *memNode) Sys() any                   { return n }
+func (n *memNode) Type() fs.FileMode          { return n.Mode().Type() }
+func (n *memNode) Info() (fs.FileInfo, error) { return n, nil }
+
+// errNoContent is returned when reading member data from a memFS.
+var errNoContent = errors.New("contents not available")
+
+// memFS exposes a memNode tree as a read-only scan.FS. Only listings and
+// metadata are available; opening a file yields a handle whose Read fails.
+type memFS struct {
+	root *memNode
+}
+
+func (m memFS) node(op, name string) (*memNode, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	n := m.root.lookup(name)
+	if n == nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return n, nil
+}
+
+func (m memFS) Open(name string) (fs.File, error) {
+	n, err := m.node("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return memFile{n}, nil
+}
+
+func (m memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := m.node("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	out := make([]fs.DirEntry, 0, len(n.children))
+	for _, c := range n.children {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out, nil
+}
+
+func (m memFS) Stat(name string) (fs.FileInfo, error) {
+	return m.node("stat", name)
+}
+
+func (m memFS) ExtStat(_ string, info fs.FileInfo) scan.ExtStat {
+	n, ok := info.Sys().(*memNode)
+	if !ok {
+		return scan.ExtStat{UID: -1, GID: -1}
+	}
+	return scan.ExtStat{DiskSize: n.diskSize, Inode: n.inode, Nlink: n.nlink, UID: -1, GID: -1}
+}
+
+// memFile is the fs.File handle returned by memFS.Open.
+type memFile struct{ n *memNode }
+
+func (f memFile) Stat() (fs.FileInfo, error) { return f.n, nil }
+func (f memFile) Close() error               { return nil }
+func (f memFile) Read([]byte) (int, error) {
+	if f.n.isDir {
+		return 0, io.EOF
+	}
+	return 0, errNoContent
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -15,14 +17,18 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/cache"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
 )
 
 // Model holds the entire application state.
 type Model struct {
 	// Directory state
 	path       string
-	entries    []FileEntry // all entries (unfiltered)
-	filtered   []FileEntry // entries after filter/search
+	root       *scan.Node        // scanned tree of path (two levels), nil if unknown
+	entries    []entry.FileEntry // all entries (unfiltered)
+	filtered   []entry.FileEntry // entries after filter/search
 	totalSize  int64
 	totalFiles int
 	totalDirs  int
@@ -36,11 +42,13 @@ type Model struct {
 	offset int
 
 	// Scan cache: LRU with bounded size
-	cache *lruCache
+	cache *cache.LRU[scanResultMsg]
 
 	// Scan progress: shared with scanner goroutine
-	scanProg      *ScanProgress
-	scanProgFiles int64 // snapshot for display
+	scanProg      *scan.Progress
+	scanCancel    context.CancelFunc // stops the listing scan in flight
+	initCtx       context.Context    // for Init's scan, made with scanCancel
+	scanProgFiles int64              // snapshot for display
 	scanProgDirs  int64
 	scanProgSize  int64
 
@@ -59,10 +67,31 @@ type Model struct {
 	cursorHistory      map[string]string
 	pendingCursorEntry string
 
+	// Sort order of the listing, remembered per directory path like cursorHistory
+	sortMode    entry.SortMode
+	sortHistory map[string]entry.SortMode
+
+	// Back/forward history of visited directories and its popup (nil when closed)
+	history     navHistory
+	historyList *historyList
+
+	// Bookmarked directories by key, the file they are saved in, the key
+	// awaited after m or ', and the bookmark list (nil when closed)
+	bookmarks      map[string]bookmark
+	bookmarksPath  string
+	bookmarkPrompt bookmarkPrompt
+	bookmarkList   *bookmarkList
+
+	// Whole-tree index of path for the fuzzy jump and recursive search, and
+	// their overlay (nil when closed)
+	index  *treeIndex
+	finder *finder
+	dupes  *dupeView
+
 	// Modes
 	loading    bool
 	showHidden bool
-	viewFilter ViewFilter
+	viewFilter entry.ViewFilter
 	topMode    bool
 	helpMode   bool
 	searchMode bool
@@ -71,11 +100,55 @@ type Model struct {
 	// Stale cache indicator: true when viewing cached (not freshly scanned) data
 	fromCache bool
 
+	// Inline tree view (nil in the flat list)
+	tree *treeState
+
+	// Treemap view of the listing in place of the rows
+	treemap bool
+
+	// Right-hand side pane next to the list
+	pane    paneKind
+	preview *previewState // preview pane content, allocated when first shown
+
+	// Full-screen viewers, nil when closed
+	hex   *hexViewer
+	pager *pager
+
+	// Optional columns right of the name, in display order
+	columns    []columnID
+	colChooser *columnChooser // column chooser overlay (nil when closed)
+
+	// Compress action state (nil when inactive)
+	compress *compressState
+
+	// Custom actions from the config, entries marked for {marked}, and the
+	// number of background actions still running
+	actions []actionConfig
+	marked  map[string]bool
+	running int
+	output  *actionOutput // last background output, until viewed
+
+	// Settings from the config file: config as loaded, settings in effect
+	// for path, and which [[dir]] override they came from (-1 for none)
+	config       config
+	settings     settings
+	dirOverride  int
+	settingsPath string // path settings were last looked up for
+
+	// Virtual filesystem being browsed, e.g. an archive or ncdu import (nil on the OS filesystem)
+	vfs *vfsView
+
 	// Components
 	spinner     spinner.Model
 	searchInput textinput.Model
+	query       query  // last valid query typed into searchInput
+	queryText   string // text query was parsed from
+	queryErr    error  // why the text typed now does not parse
+	rankSearch  bool   // order matches by score while a query has words
 	gotoInput   textinput.Model
+	cd          cdPrompt // cd prompt history and completion
 	keys        KeyMap
+	pendingKeys string // start of a key sequence typed so far
 
 	// Error
 	err error
@@ -88,17 +161,16 @@ func NewModel(path string) Model {
 	s.Style = spinnerStyle
 
 	ti := textinput.New()
-	ti.Placeholder = "type to filter..."
-	ti.CharLimit = 64
-	ti.Width = 30
+	ti.Placeholder = "name, size>100M, age>90d, ext:log..."
+	ti.CharLimit = 256
+	ti.Width = 40
 
 	gi := textinput.New()
 	gi.Placeholder = "path (e.g. ~/Downloads, /tmp)..."
 	gi.CharLimit = 256
 	gi.Width = 50
 
-	cache := newLRUCache(100)
-
+	ctx, cancel := context.WithCancel(context.Background())
 	return Model{
 		path:          path,
 		loading:       true,
@@ -108,17 +180,38 @@ func NewModel(path string) Model {
 		searchInput:   ti,
 		gotoInput:     gi,
 		cursorHistory: make(map[string]string),
-		cache:         cache,
+		sortMode:      entry.DefaultSort,
+		sortHistory:   make(map[string]entry.SortMode),
+		marked:        make(map[string]bool),
+		config:        config{settings: defaultSettings()},
+		settings:      defaultSettings(),
+		dirOverride:   -1,
+		columns:       append([]columnID(nil), defaultColumns...),
+		cache:         cache.New[scanResultMsg](100),
 		viewBuf:       &strings.Builder{},
-		scanProg:      &ScanProgress{},
+		scanProg:      &scan.Progress{},
+		scanCancel:    cancel,
+		initCtx:       ctx,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(scanDirectory(m.path, m.scanProg), m.spinner.Tick)
+	return tea.Batch(m.scanCmd(m.initCtx, m.path), m.spinner.Tick)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m = m.applyDirSettings().recordVisit()
+	if m.hex != nil {
+		m.hex.load(m)
+	}
+	if pc := m.previewCmd(); pc != nil {
+		return m, tea.Batch(cmd, pc)
+	}
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -147,6 +240,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case scanResultMsg:
 		// Phase 1 complete — populate entries immediately
 		m.cache.Put(msg.path, msg)
+		m.index = nil // the listing changed or browsing moved on
+		if m.tree != nil {
+			// Expansions belong to one directory; a refresh reloads them
+			if m.tree.path != msg.path {
+				m.tree = newTreeState(msg.path)
+			} else {
+				clear(m.tree.children)
+				for rel := range m.tree.expanded {
+					cmds = append(cmds, m.treeChildrenCmd(rel))
+				}
+			}
+		}
 		m.loading = false
 		m.fromCache = false
 		m.scanProg = nil
@@ -154,6 +259,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.scanProgDirs = 0
 		m.scanProgSize = 0
 		m.path = msg.path
+		m.root = msg.root
+		if m.preview != nil {
+			m.preview.reset() // contents may have changed on refresh
+		}
 		m.entries = msg.entries
 		m.totalSize = msg.totalSize
 		m.totalFiles = msg.totalFiles
@@ -169,6 +278,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		m.restoreSort(msg.path)
+
 		m.cursor = 0
 		m.offset = 0
 		m.applyFilter()
@@ -186,31 +297,91 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Trigger line count for the selected entry
-		if len(m.filtered) > 0 && m.cursor < len(m.filtered) {
-			e := m.filtered[m.cursor]
-			if !e.IsDir && !e.IsBinary {
-				cmds = append(cmds, countLinesCmd(m.path, e.Name))
-			}
-		}
+		cmds = append(cmds, m.lineCountForSelected())
 
 		return m, tea.Batch(cmds...)
 
+	case vfsResultMsg:
+		// Archive or import loaded into memory — browse it like a directory
+		m.vfs = msg.view
+		return m.update(msg.scan)
+
+	case treeChildrenMsg:
+		m = m.applyTreeChildren(msg)
+		return m, nil
+
 	case scanUpToDateMsg:
 		// Smart refresh: nothing changed
 		m.loading = false
 		return m, nil
 
+	case actionDoneMsg:
+		return m.applyActionDone(msg)
+
+	case indexMsg:
+		return m.applyIndex(msg), nil
+
+	case dupesMsg:
+		return m.applyDupes(msg), nil
+
+	case dupeActionMsg:
+		return m.applyDupeAction(msg), nil
+
+	case editorDoneMsg:
+		return m.applyEditorDone(msg)
+
+	case pagerIndexMsg:
+		return m.applyPagerIndex(msg)
+
+	case pagerFollowMsg:
+		return m.applyPagerFollow(msg)
+
+	case pagerSearchMsg:
+		return m.applyPagerSearch(msg), nil
+
+	case hexSearchMsg:
+		return m.applyHexSearch(msg), nil
+
+	case previewMsg:
+		return m.applyPreview(msg), nil
+
+	case bookmarkSizesSavedMsg:
+		if msg.err != nil && m.bookmarkList != nil {
+			m.bookmarkList.err = msg.err
+		}
+		return m, nil
+
 	case scanErrorMsg:
+		if errors.Is(msg.err, context.Canceled) {
+			return m, nil // replaced by a newer scan
+		}
 		m.loading = false
 		m.err = msg.err
 		return m, nil
 
+	case compressResultMsg:
+		m = m.applyCompressResult(msg)
+		return m, m.lineCountForSelected()
+
 	case trashResultMsg:
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
 		m.err = nil
+		delete(m.marked, filepath.Join(m.path, msg.name))
+		if m.tree != nil && strings.ContainsRune(msg.name, filepath.Separator) {
+			// Nested row of the tree view: adjust its ancestors instead
+			m.removeTreeEntry(msg.name)
+			m.root = nil // retained scan tree is stale below the top level
+			m.applyFilter()
+			if m.cursor >= len(m.filtered) {
+				m.cursor = max(len(m.filtered)-1, 0)
+			}
+			m.ensureVisible()
+			m.cache.Delete(m.path)
+			return m, m.lineCountForSelected()
+		}
 		// Remove deleted entry locally (avoid expensive full rescan)
 		for i, e := range m.entries {
 			if e.Name == msg.name {
@@ -228,16 +399,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.totalFiles--
 		}
-		// Recompute percentages
-		if m.totalSize > 0 {
-			for i := range m.entries {
-				m.entries[i].Percentage = float64(m.entries[i].Size) / float64(m.totalSize) * 100
-			}
-		} else {
-			for i := range m.entries {
-				m.entries[i].Percentage = 0
-			}
-		}
+		m.recomputePercentages()
 		m.computeDeepTotals()
 		m.applyFilter()
 		// Adjust cursor to stay in bounds
@@ -266,6 +428,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
+		if m.tree != nil {
+			if e := m.tree.treeEntry(msg.name); e != nil {
+				e.LineCount = msg.lines
+			}
+		}
 		// Write-back into cache
 		if cached, ok := m.cache.Get(m.path); ok {
 			for i := range cached.entries {
@@ -299,10 +466,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.cache.Put(m.path, cached)
 		}
+		if m.sortMode.Key == entry.SortLines {
+			m.applySort()
+		}
 		return m, nil
 
 	case spinner.TickMsg:
-		if m.loading {
+		if m.loading || (m.compress != nil && m.compress.stage == compressRunning) ||
+			(m.finder != nil && m.finder.cancel != nil) ||
+			(m.dupes != nil && (m.dupes.cancel != nil || m.dupes.busy)) {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			// Read scan progress for display
@@ -316,28 +488,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Batch(cmds...)
 
 	case tea.KeyMsg:
+		if m.hex != nil {
+			return m.updateHex(msg)
+		}
+		if m.pager != nil {
+			return m.updatePager(msg)
+		}
+
+		// Compress prompt/progress/confirm captures all keys while active
+		if m.compress != nil {
+			return m.updateCompress(msg)
+		}
+
+		if m.colChooser != nil {
+			return m.updateColumnChooser(msg)
+		}
+
+		if m.historyList != nil {
+			return m.updateHistoryList(msg)
+		}
+		if m.bookmarkList != nil {
+			return m.updateBookmarkList(msg)
+		}
+		if m.finder != nil {
+			return m.updateFinder(msg)
+		}
+		if m.dupes != nil {
+			return m.updateDupes(msg)
+		}
+		if m.bookmarkPrompt != bookmarkNone {
+			return m.updateBookmarkPrompt(msg)
+		}
+
 		// If in goto mode, handle text input first
 		if m.gotoMode {
-			switch {
-			case key.Matches(msg, m.keys.Escape):
-				m.gotoMode = false
-				m.gotoInput.SetValue("")
-				m.gotoInput.Blur()
-				return m, nil
-			case msg.Type == tea.KeyEnter:
-				m.gotoMode = false
-				m.gotoInput.Blur()
-				target := m.gotoInput.Value()
-				m.gotoInput.SetValue("")
-				if target == "" {
-					return m, nil
-				}
-				return m.navigateTo(target)
-			default:
-				var cmd tea.Cmd
-				m.gotoInput, cmd = m.gotoInput.Update(msg)
-				return m, cmd
-			}
+			return m.updateGoto(msg)
 		}
 
 		// If in search mode, handle text input first
@@ -352,9 +537,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.offset = 0
 				return m, nil
 			case msg.Type == tea.KeyEnter:
+				if m.queryErr != nil {
+					return m, nil
+				}
 				m.searchMode = false
 				m.searchInput.Blur()
 				return m, nil
+			case msg.Type == tea.KeyTab:
+				m.rankSearch = !m.rankSearch
+				m.applyFilter()
+				m.cursor = 0
+				m.offset = 0
+				return m, nil
 			case key.Matches(msg, m.keys.Up):
 				m = m.moveCursor(-1)
 				return m, m.lineCountForSelected()
@@ -379,40 +573,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Multi-key sequences such as "g g" wait for their next key
+		ks, pending := m.resolveKey(msg)
+		if pending {
+			return m, nil
+		}
+
+		if m.treemap {
+			if next, cmd, ok := m.updateTreemap(msg); ok {
+				return next, cmd
+			}
+		}
+
 		switch {
-		case key.Matches(msg, m.keys.Quit):
+		case key.Matches(ks, m.keys.Quit):
 			return m, tea.Quit
 
-		case key.Matches(msg, m.keys.Up):
+		case key.Matches(ks, m.keys.Up):
 			m = m.moveCursor(-1)
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.Down):
+		case key.Matches(ks, m.keys.Down):
 			m = m.moveCursor(1)
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.Top):
+		case key.Matches(ks, m.keys.Top):
 			m.cursor = 0
 			m.offset = 0
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.Bottom):
+		case key.Matches(ks, m.keys.Bottom):
 			if len(m.filtered) > 0 {
 				m.cursor = len(m.filtered) - 1
 				m.ensureVisible()
 			}
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.Left):
+		case key.Matches(ks, m.keys.Left):
+			if m.tree != nil {
+				return m.treeLeft()
+			}
 			return m.navigateUp()
 
-		case key.Matches(msg, m.keys.Right):
+		case key.Matches(ks, m.keys.Right):
+			if m.tree != nil {
+				return m.treeRight()
+			}
 			return m.navigateIn()
 
-		case key.Matches(msg, m.keys.QuickLook):
+		case key.Matches(ks, m.keys.Tree):
+			m.treemap = false
+			m = m.toggleTree()
+			return m, m.lineCountForSelected()
+
+		case key.Matches(ks, m.keys.ChartPane):
+			m = m.togglePane(paneChart)
+			return m, nil
+
+		case key.Matches(ks, m.keys.PreviewPane):
+			m = m.togglePane(panePreview)
+			return m, nil
+
+		case key.Matches(ks, m.keys.Treemap):
+			if m.tree != nil {
+				// The treemap lays out one level; nested rows would overlap
+				m = m.toggleTree()
+			}
+			m.treemap = true
+			return m, nil
+
+		case key.Matches(ks, m.keys.QuickLook):
+			if m.vfs != nil {
+				return m, nil
+			}
 			return m.quickLook()
 
-		case key.Matches(msg, m.keys.PageUp):
+		case key.Matches(ks, m.keys.PageUp):
 			pageSize := m.height - 5
 			if pageSize < 1 {
 				pageSize = 1
@@ -420,7 +656,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m = m.moveCursor(-pageSize)
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.PageDown):
+		case key.Matches(ks, m.keys.PageDown):
 			pageSize := m.height - 5
 			if pageSize < 1 {
 				pageSize = 1
@@ -428,47 +664,94 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m = m.moveCursor(pageSize)
 			return m, m.lineCountForSelected()
 
-		case key.Matches(msg, m.keys.Refresh):
+		case key.Matches(ks, m.keys.Refresh):
 			// Smart refresh: check modtime before full rescan
 			m.err = nil
-			m.scanProg = &ScanProgress{}
+			ctx := m.startScan()
+			if m.vfs != nil && m.vfs.reload != nil {
+				// Re-read the archive / import from disk
+				m.loading = true
+				return m, tea.Batch(loadVFSCmd(ctx, m.vfs.reload, m.path, m.scanProg), m.spinner.Tick)
+			}
 			if cached, ok := m.cache.Get(m.path); ok {
 				m.loading = true
-				return m, tea.Batch(smartRefreshCmd(m.path, cached, m.scanProg), m.spinner.Tick)
+				return m, tea.Batch(smartRefreshCmd(ctx, m.path, cached, m.scanProg), m.spinner.Tick)
 			}
 			m.loading = true
-			return m, tea.Batch(scanDirectory(m.path, m.scanProg), m.spinner.Tick)
+			return m, tea.Batch(scanDirectory(ctx, m.path, m.scanProg), m.spinner.Tick)
 
-		case key.Matches(msg, m.keys.TopView):
+		case key.Matches(ks, m.keys.TopView):
 			m.topMode = !m.topMode
 			m.applyFilter()
 			m.cursor = 0
 			m.offset = 0
 			return m, nil
 
-		case key.Matches(msg, m.keys.Open):
+		case key.Matches(ks, m.keys.Open):
+			if m.vfs != nil {
+				if !m.vfs.detached {
+					openPath(filepath.Dir(m.vfs.path))
+				}
+				return m, nil
+			}
 			openPath(m.path)
 			return m, nil
 
-		case key.Matches(msg, m.keys.Search):
+		case key.Matches(ks, m.keys.Search):
 			m.searchMode = true
 			m.searchInput.Focus()
 			return m, textinput.Blink
 
-		case key.Matches(msg, m.keys.GoTo):
-			m.gotoMode = true
-			m.gotoInput.SetValue("")
-			m.gotoInput.Focus()
-			return m, textinput.Blink
+		case key.Matches(ks, m.keys.Back):
+			return m.goHistory(m.history.pos - 1)
+
+		case key.Matches(ks, m.keys.Forward):
+			return m.goHistory(m.history.pos + 1)
+
+		case key.Matches(ks, m.keys.History):
+			m.historyList = &historyList{items: m.history.recent()}
+			// Start on the most recent directory other than this one
+			for i, idx := range m.historyList.items {
+				if m.history.paths[idx] != m.path {
+					m.historyList.cursor = i
+					break
+				}
+			}
+			return m, nil
+
+		case key.Matches(ks, m.keys.SetBookmark):
+			m.bookmarkPrompt = bookmarkSet
+			return m, nil
 
-		case key.Matches(msg, m.keys.Hidden):
+		case key.Matches(ks, m.keys.JumpBookmark):
+			m.bookmarkPrompt = bookmarkJump
+			return m, nil
+
+		case key.Matches(ks, m.keys.Bookmarks):
+			m, cmd := m.refreshBookmarkSizes()
+			m.bookmarkList = &bookmarkList{}
+			return m, cmd
+
+		case key.Matches(ks, m.keys.GoTo):
+			return m.openGoto()
+
+		case key.Matches(ks, m.keys.Jump):
+			return m.openFinder(finderJump)
+
+		case key.Matches(ks, m.keys.Find):
+			return m.openFinder(finderSearch)
+
+		case key.Matches(ks, m.keys.Dupes):
+			return m.openDupes()
+
+		case key.Matches(ks, m.keys.Hidden):
 			m.showHidden = !m.showHidden
 			m.applyFilter()
 			m.cursor = 0
 			m.offset = 0
 			return m, nil
 
-		case key.Matches(msg, m.keys.DirOnly):
+		case key.Matches(ks, m.keys.DirOnly):
 			// Cycle: all → dirs only → files only → all
 			m.viewFilter = (m.viewFilter + 1) % 3
 			m.applyFilter()
@@ -476,46 +759,139 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.offset = 0
 			return m, nil
 
-		case key.Matches(msg, m.keys.Delete):
+		case key.Matches(ks, m.keys.Sort):
+			// Cycle: size → name → mtime → files → dirs → ext → lines
+			next := m.sortMode.Key.Next()
+			m.sortMode = entry.SortMode{Key: next, Desc: next.DefaultDesc()}
+			m.rememberSort(m.path, m.sortMode)
+			m.applySort()
+			if next == entry.SortLines && m.vfs == nil {
+				// Line counts are otherwise only known for files visited so far
+				return m, countAllLinesCmd(m.entries, m.path, int64(m.settings.LineCountMax))
+			}
+			return m, nil
+
+		case key.Matches(ks, m.keys.SortOrder):
+			m.sortMode.Desc = !m.sortMode.Desc
+			m.rememberSort(m.path, m.sortMode)
+			m.applySort()
+			return m, nil
+
+		case key.Matches(ks, m.keys.Columns):
+			m.colChooser = &columnChooser{}
+			return m, nil
+
+		case key.Matches(ks, m.keys.Delete):
+			if m.vfs != nil {
+				m.err = fmt.Errorf("trash is not available inside %s", filepath.Base(m.vfs.path))
+				return m, nil
+			}
 			if len(m.filtered) > 0 {
-				entry := m.filtered[m.cursor]
-				fullPath := filepath.Join(m.path, entry.Name)
-				return m, trashCmd(fullPath, entry.Name, entry.Size, entry.IsDir)
+				e := m.filtered[m.cursor]
+				fullPath := filepath.Join(m.path, e.Name)
+				return m, trashCmd(fullPath, e.Name, e.Size, e.IsDir)
 			}
 			return m, nil
 
-		case key.Matches(msg, m.keys.CountAll):
+		case key.Matches(ks, m.keys.CountAll):
+			if m.vfs != nil {
+				return m, nil
+			}
 			// Batch count lines for all visible entries
-			return m, countAllLinesCmd(m.filtered, m.path)
+			return m, countAllLinesCmd(m.filtered, m.path, int64(m.settings.LineCountMax))
 
-		case key.Matches(msg, m.keys.HexView):
+		case key.Matches(ks, m.keys.HexView):
 			return m.hexView()
 
-		case key.Matches(msg, m.keys.Help):
+		case key.Matches(ks, m.keys.Pager):
+			return m.viewText()
+
+		case key.Matches(ks, m.keys.Edit):
+			if len(m.filtered) == 0 || m.filtered[m.cursor].IsDir {
+				return m, nil
+			}
+			return m.editFile(m.filtered[m.cursor].Name, 0)
+
+		case key.Matches(ks, m.keys.Mark):
+			m = m.toggleMark()
+			return m, m.lineCountForSelected()
+
+		case key.Matches(ks, m.keys.ActionOutput):
+			return m.showActionOutput()
+
+		case key.Matches(ks, m.keys.Compress):
+			if m.vfs != nil {
+				m.err = fmt.Errorf("compress is not available inside %s", filepath.Base(m.vfs.path))
+				return m, nil
+			}
+			if m.tree != nil && strings.ContainsRune(m.selectedRel(), filepath.Separator) {
+				m.err = fmt.Errorf("open %s to compress entries inside it", filepath.Dir(m.selectedRel()))
+				return m, nil
+			}
+			return m.startCompress()
+
+		case key.Matches(ks, m.keys.Help):
 			m.helpMode = true
 			return m, nil
 
-		case key.Matches(msg, m.keys.Escape):
+		case key.Matches(ks, m.keys.Escape):
 			if m.topMode {
 				m.topMode = false
 				m.applyFilter()
+			} else {
+				clear(m.marked)
 			}
 			return m, nil
+
+		default:
+			if a, ok := m.actionFor(string(ks)); ok {
+				return m.runAction(a)
+			}
 		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// overlayOpen reports whether a viewer, overlay, prompt or the treemap has
+// the keyboard.
+func (m Model) overlayOpen() bool {
+	return m.hex != nil || m.pager != nil || m.helpMode || m.colChooser != nil ||
+		m.historyList != nil || m.bookmarkList != nil || m.finder != nil || m.dupes != nil ||
+		m.compress != nil || m.searchMode || m.gotoMode || m.bookmarkPrompt != bookmarkNone || m.treemap
+}
+
 func (m Model) View() string {
 	if m.width == 0 || m.height == 0 {
 		return ""
 	}
 
+	if m.hex != nil {
+		return renderHexView(m)
+	}
+	if m.pager != nil {
+		return renderPager(m)
+	}
+
 	// Help overlay
 	if m.helpMode {
 		return renderHelp(m)
 	}
+	if m.colChooser != nil {
+		return renderColumnChooser(m)
+	}
+	if m.historyList != nil {
+		return renderHistoryList(m)
+	}
+	if m.bookmarkList != nil {
+		return renderBookmarkList(m)
+	}
+	if m.finder != nil {
+		return renderFinder(m)
+	}
+	if m.dupes != nil {
+		return renderDupes(m)
+	}
 
 	m.viewBuf.Reset()
 
@@ -578,19 +954,44 @@ func (m Model) View() string {
 			m.viewBuf.WriteString("\n")
 		}
 	} else {
-		// Render visible rows
-		visibleEnd := minInt(m.offset+listHeight, len(m.filtered))
-		rendered := 0
-		for i := m.offset; i < visibleEnd; i++ {
-			selected := i == m.cursor
-			m.viewBuf.WriteString(renderRow(m, i, m.filtered[i], selected))
-			m.viewBuf.WriteString("\n")
-			rendered++
+		// The list (rows or treemap) shares the width with the side pane
+		pw := m.paneWidth()
+		lm := m
+		lm.width = m.width - pw
+		var pane []string
+		if pw > 0 {
+			pane = renderPane(m, pw, listHeight)
 		}
-		// Fill remaining space
-		for i := rendered; i < listHeight; i++ {
+		writeLine := func(i int, s string) {
+			m.viewBuf.WriteString(s)
+			if pane != nil {
+				m.viewBuf.WriteString(pane[i])
+			}
 			m.viewBuf.WriteString("\n")
 		}
+		blank := ""
+		if pane != nil {
+			blank = strings.Repeat(" ", lm.width)
+		}
+
+		if m.treemap {
+			for i, line := range strings.Split(renderTreemap(lm, lm.width, listHeight), "\n") {
+				writeLine(i, line)
+			}
+		} else {
+			// Render visible rows
+			visibleEnd := minInt(m.offset+listHeight, len(m.filtered))
+			rendered := 0
+			for i := m.offset; i < visibleEnd; i++ {
+				selected := i == m.cursor
+				writeLine(rendered, renderRow(lm, i, m.filtered[i], selected))
+				rendered++
+			}
+			// Fill remaining space
+			for i := rendered; i < listHeight; i++ {
+				writeLine(i, blank)
+			}
+		}
 	}
 
 	// Footer separator
@@ -634,21 +1035,34 @@ func (m *Model) ensureVisible() {
 }
 
 func (m *Model) applyFilter() {
-	search := m.searchInput.Value()
+	m.parseSearch()
+	match := m.query.matcher()
+	if m.tree != nil {
+		top := entry.FilterFuncInto(m.tree.top[:0], m.entries, m.showHidden, m.viewFilter, match)
+		if m.topMode && len(top) > m.settings.TopN {
+			top = top[:m.settings.TopN]
+		}
+		if m.rankSearch && m.query.ranked() {
+			m.query.rank(top)
+		}
+		m.tree.top = top
+		m.flattenTree(top)
+		return
+	}
 	// Reuse underlying array to reduce GC pressure
-	m.filtered = filterEntriesInto(m.filtered[:0], m.entries, m.showHidden, m.viewFilter, search)
-	if m.topMode && len(m.filtered) > 10 {
-		m.filtered = m.filtered[:10]
+	m.filtered = entry.FilterFuncInto(m.filtered[:0], m.entries, m.showHidden, m.viewFilter, match)
+	if m.topMode && len(m.filtered) > m.settings.TopN {
+		m.filtered = m.filtered[:m.settings.TopN]
+	}
+	if m.rankSearch && m.query.ranked() {
+		m.query.rank(m.filtered)
 	}
 }
 
-// maxCursorHistory caps the cursorHistory map to prevent unbounded growth.
-const maxCursorHistory = 500
-
 // rememberCursor saves the current cursor position for a directory path,
-// evicting a random entry if the map exceeds maxCursorHistory.
+// evicting a random entry if the map exceeds the history_size setting.
 func (m *Model) rememberCursor(path, name string) {
-	if len(m.cursorHistory) >= maxCursorHistory {
+	if len(m.cursorHistory) >= m.settings.HistorySize {
 		// Evict one arbitrary entry (Go map iteration is random)
 		for k := range m.cursorHistory {
 			delete(m.cursorHistory, k)
@@ -658,6 +1072,45 @@ func (m *Model) rememberCursor(path, name string) {
 	m.cursorHistory[path] = name
 }
 
+// rememberSort saves the sort mode chosen for a directory path, with the same
+// bound and eviction as rememberCursor.
+func (m *Model) rememberSort(path string, mode entry.SortMode) {
+	if _, ok := m.sortHistory[path]; !ok && len(m.sortHistory) >= m.settings.HistorySize {
+		for k := range m.sortHistory {
+			delete(m.sortHistory, k)
+			break
+		}
+	}
+	m.sortHistory[path] = mode
+}
+
+// restoreSort sorts a freshly loaded listing of path by the order
+// remembered for it. Directories without one keep the current order.
+func (m *Model) restoreSort(path string) {
+	if mode, ok := m.sortHistory[path]; ok {
+		m.sortMode = mode
+	}
+	entry.Sort(m.entries, m.sortMode)
+}
+
+// applySort re-sorts entries by the current sort mode, keeping the selected
+// entry under the cursor.
+func (m *Model) applySort() {
+	selected := ""
+	if m.cursor < len(m.filtered) {
+		selected = m.filtered[m.cursor].Name
+	}
+	entry.Sort(m.entries, m.sortMode)
+	m.applyFilter()
+	for i, fe := range m.filtered {
+		if fe.Name == selected {
+			m.cursor = i
+			break
+		}
+	}
+	m.ensureVisible()
+}
+
 func (m Model) navigateUp() (Model, tea.Cmd) {
 	parent := filepath.Dir(m.path)
 	if parent == m.path {
@@ -667,6 +1120,15 @@ func (m Model) navigateUp() (Model, tea.Cmd) {
 	// Remember which directory we came from so parent highlights it
 	childName := filepath.Base(m.path)
 
+	// Leaving an archive root returns to the real directory containing it;
+	// an imported tree has nothing above its root.
+	if m.vfs != nil && !m.vfs.contains(parent) {
+		if m.vfs.detached {
+			return m, nil
+		}
+		m.vfs = nil
+	}
+
 	// Save current cursor position for this directory
 	if len(m.filtered) > 0 && m.cursor < len(m.filtered) {
 		m.rememberCursor(m.path, m.filtered[m.cursor].Name)
@@ -679,11 +1141,13 @@ func (m Model) navigateUp() (Model, tea.Cmd) {
 	if cached, ok := m.cache.Get(parent); ok {
 		m.loading = false
 		m.fromCache = true
+		m.root = cached.root
 		m.entries = cached.entries
 		m.totalSize = cached.totalSize
 		m.totalFiles = cached.totalFiles
 		m.totalDirs = cached.totalDirs
 		m.computeDeepTotals()
+		m.restoreSort(parent)
 		m.cursor = 0
 		m.offset = 0
 		m.applyFilter()
@@ -700,33 +1164,50 @@ func (m Model) navigateUp() (Model, tea.Cmd) {
 	// For async scan, remember to restore cursor when results arrive
 	m.pendingCursorEntry = childName
 	m.loading = true
-	m.scanProg = &ScanProgress{}
-	return m, tea.Batch(scanDirectory(parent, m.scanProg), m.spinner.Tick)
+	ctx := m.startScan()
+	return m, tea.Batch(m.scanCmd(ctx, parent), m.spinner.Tick)
 }
 
 func (m Model) navigateIn() (Model, tea.Cmd) {
 	if len(m.filtered) == 0 {
 		return m, nil
 	}
-	entry := m.filtered[m.cursor]
+	sel := m.filtered[m.cursor]
 
 	// For files, open with default application
-	if !entry.IsDir {
+	if !sel.IsDir {
+		if m.vfs != nil {
+			m.err = fmt.Errorf("cannot open %s — contents are not available inside %s", sel.Name, filepath.Base(m.vfs.path))
+			return m, nil
+		}
+		if detectArchive(sel.Name) != archiveNone {
+			return m.openArchive(sel.Name)
+		}
+		// Text files open in the built-in pager; binaries go to the OS
+		if !sel.IsBinary && !entry.IsBinaryExt(sel.Name) {
+			next, cmd, err := m.openPager()
+			if err == nil {
+				return next, cmd
+			}
+			if !errors.Is(err, errBinaryFile) {
+				m.err = fmt.Errorf("cannot view %s: %w", sel.Name, err)
+				return m, nil
+			}
+		}
 		// Block opening very large binary files to avoid freezing the system
-		const maxOpenSize = 100 * 1024 * 1024 // 100 MB
-		if entry.IsBinary && entry.Size > maxOpenSize {
-			m.err = fmt.Errorf("file too large to open (%s) — use 'x' for hex view", formatSize(entry.Size))
+		if sel.IsBinary && sel.Size > int64(m.settings.MaxOpenSize) {
+			m.err = fmt.Errorf("file too large to open (%s) — use 'x' for hex view", formatSize(sel.Size))
 			return m, nil
 		}
-		filePath := filepath.Join(m.path, entry.Name)
+		filePath := filepath.Join(m.path, sel.Name)
 		openPath(filePath)
 		return m, nil
 	}
 
 	// Save current cursor position for this directory
-	m.rememberCursor(m.path, entry.Name)
+	m.rememberCursor(m.path, sel.Name)
 
-	target := filepath.Join(m.path, entry.Name)
+	target := filepath.Join(m.path, sel.Name)
 	m.path = target
 	m.err = nil
 	m.searchInput.SetValue("")
@@ -738,11 +1219,13 @@ func (m Model) navigateIn() (Model, tea.Cmd) {
 	if cached, ok := m.cache.Get(target); ok {
 		m.loading = false
 		m.fromCache = true
+		m.root = cached.root
 		m.entries = cached.entries
 		m.totalSize = cached.totalSize
 		m.totalFiles = cached.totalFiles
 		m.totalDirs = cached.totalDirs
 		m.computeDeepTotals()
+		m.restoreSort(target)
 		m.cursor = 0
 		m.offset = 0
 		m.applyFilter()
@@ -762,19 +1245,13 @@ func (m Model) navigateIn() (Model, tea.Cmd) {
 		m.pendingCursorEntry = pendingEntry
 	}
 	m.loading = true
-	m.scanProg = &ScanProgress{}
-	return m, tea.Batch(scanDirectory(target, m.scanProg), m.spinner.Tick)
+	ctx := m.startScan()
+	return m, tea.Batch(m.scanCmd(ctx, target), m.spinner.Tick)
 }
 
+// navigateTo browses to target, taken literally; relative paths are
+// resolved against the current directory.
 func (m Model) navigateTo(target string) (Model, tea.Cmd) {
-	// Expand ~ to home directory
-	if strings.HasPrefix(target, "~") {
-		home, err := os.UserHomeDir()
-		if err == nil {
-			target = filepath.Join(home, target[1:])
-		}
-	}
-
 	// Resolve relative paths
 	if !filepath.IsAbs(target) {
 		target = filepath.Join(m.path, target)
@@ -783,15 +1260,32 @@ func (m Model) navigateTo(target string) (Model, tea.Cmd) {
 	// Clean the path
 	target = filepath.Clean(target)
 
-	// Verify it exists and is a directory
-	info, err := os.Stat(target)
-	if err != nil {
-		m.err = fmt.Errorf("cannot navigate: %w", err)
-		return m, nil
-	}
-	if !info.IsDir() {
-		m.err = fmt.Errorf("not a directory: %s", target)
-		return m, nil
+	if m.vfs != nil && m.vfs.contains(target) {
+		// Stay inside the open archive / import
+		if info, err := m.vfs.fsys.Stat(m.vfs.name(target)); err != nil || !info.IsDir() {
+			m.err = fmt.Errorf("not a directory in %s: %s", filepath.Base(m.vfs.path), target)
+			return m, nil
+		}
+	} else {
+		// Verify it exists and is a directory
+		info, err := os.Stat(target)
+		if err != nil {
+			m.err = fmt.Errorf("cannot navigate: %w", err)
+			return m, nil
+		}
+		if !info.IsDir() {
+			if detectArchive(target) != archiveNone {
+				if len(m.filtered) > 0 && m.cursor < len(m.filtered) {
+					m.rememberCursor(m.path, m.filtered[m.cursor].Name)
+				}
+				m.vfs = nil
+				m.path = filepath.Dir(target)
+				return m.openArchive(filepath.Base(target))
+			}
+			m.err = fmt.Errorf("not a directory: %s", target)
+			return m, nil
+		}
+		m.vfs = nil
 	}
 
 	// Save current cursor position
@@ -803,23 +1297,103 @@ func (m Model) navigateTo(target string) (Model, tea.Cmd) {
 	m.err = nil
 	m.searchInput.SetValue("")
 	m.searchMode = false
+	pendingEntry := m.cursorHistory[target]
 
 	if cached, ok := m.cache.Get(target); ok {
 		m.loading = false
 		m.fromCache = true
+		m.root = cached.root
 		m.entries = cached.entries
 		m.totalSize = cached.totalSize
 		m.totalFiles = cached.totalFiles
 		m.totalDirs = cached.totalDirs
 		m.computeDeepTotals()
+		m.restoreSort(target)
 		m.cursor = 0
 		m.offset = 0
 		m.applyFilter()
+		// Restore cursor to previously selected entry
+		if pendingEntry != "" {
+			for i, e := range m.filtered {
+				if e.Name == pendingEntry {
+					m.cursor = i
+					m.ensureVisible()
+					break
+				}
+			}
+		}
 		return m, m.lineCountForSelected()
 	}
+	m.pendingCursorEntry = pendingEntry
+	m.loading = true
+	ctx := m.startScan()
+	return m, tea.Batch(m.scanCmd(ctx, target), m.spinner.Tick)
+}
+
+// recomputePercentages refreshes each entry's share of totalSize.
+func (m *Model) recomputePercentages() {
+	for i := range m.entries {
+		if m.totalSize > 0 {
+			m.entries[i].Percentage = float64(m.entries[i].Size) / float64(m.totalSize) * 100
+		} else {
+			m.entries[i].Percentage = 0
+		}
+	}
+}
+
+// addEntry inserts a new file entry into the current listing (e.g. a freshly
+// written archive), keeping totals, order and the selection consistent
+// without a full rescan.
+func (m *Model) addEntry(e entry.FileEntry) {
+	e.IsHidden = strings.HasPrefix(e.Name, ".")
+	m.entries = append(m.entries, e)
+	m.totalSize += e.Size
+	if e.IsDir {
+		m.totalDirs++
+	} else {
+		m.totalFiles++
+	}
+	m.recomputePercentages()
+	m.computeDeepTotals()
+	m.applySort()
+	// Invalidate stale cache for this directory
+	m.cache.Delete(m.path)
+}
+
+// startScan stops the listing scan in flight, if any, and returns the
+// context for its replacement, resetting the progress counters.
+func (m *Model) startScan() context.Context {
+	if m.scanCancel != nil {
+		m.scanCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.scanCancel = cancel
+	m.scanProg = &scan.Progress{}
+	return ctx
+}
+
+// scanCmd returns the command that lists path, scanning the open virtual
+// filesystem when path is inside it.
+func (m Model) scanCmd(ctx context.Context, path string) tea.Cmd {
+	if m.vfs != nil && m.vfs.contains(path) {
+		return scanFSCmd(ctx, m.vfs.fsys, m.vfs.name(path), path, m.scanProg)
+	}
+	return scanDirectory(ctx, path, m.scanProg)
+}
+
+// openArchive starts reading the named archive in the current directory so it
+// can be browsed as a virtual directory.
+func (m Model) openArchive(name string) (Model, tea.Cmd) {
+	m.rememberCursor(m.path, name)
+	target := filepath.Join(m.path, name)
+	m.pendingCursorEntry = m.cursorHistory[target]
+	m.path = target
+	m.err = nil
+	m.searchInput.SetValue("")
+	m.searchMode = false
 	m.loading = true
-	m.scanProg = &ScanProgress{}
-	return m, tea.Batch(scanDirectory(target, m.scanProg), m.spinner.Tick)
+	ctx := m.startScan()
+	return m, tea.Batch(loadVFSCmd(ctx, archiveLoader(target), target, m.scanProg), m.spinner.Tick)
 }
 
 func (m *Model) computeDeepTotals() {
@@ -837,8 +1411,8 @@ func (m Model) quickLook() (Model, tea.Cmd) {
 	if len(m.filtered) == 0 {
 		return m, nil
 	}
-	entry := m.filtered[m.cursor]
-	targetPath := filepath.Join(m.path, entry.Name)
+	sel := m.filtered[m.cursor]
+	targetPath := filepath.Join(m.path, sel.Name)
 
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
@@ -870,71 +1444,15 @@ func (m Model) quickLook() (Model, tea.Cmd) {
 	return m, nil
 }
 
-// maxHexViewSize is the maximum file size (50 MB) for hex view to prevent
-// loading extremely large files into the pager.
-const maxHexViewSize = 50 * 1024 * 1024
-
-func (m Model) hexView() (Model, tea.Cmd) {
-	if len(m.filtered) == 0 {
-		return m, nil
-	}
-	entry := m.filtered[m.cursor]
-	if entry.IsDir {
-		return m, nil
-	}
-	if entry.Size > maxHexViewSize {
-		m.err = fmt.Errorf("file too large for hex view (%s, max %s)", formatSize(entry.Size), formatSize(maxHexViewSize))
-		return m, nil
-	}
-
-	targetPath := filepath.Join(m.path, entry.Name)
-
-	var c *exec.Cmd
-	if runtime.GOOS == "windows" {
-		// Windows: use PowerShell's Format-Hex
-		c = exec.Command("powershell", "-Command", fmt.Sprintf("Format-Hex -Path '%s' | more", targetPath))
-	} else {
-		// Find hex dump tool
-		var hexCmd string
-		for _, tool := range []string{"xxd", "hexdump"} {
-			if _, err := exec.LookPath(tool); err == nil {
-				hexCmd = tool
-				break
-			}
-		}
-		if hexCmd == "" {
-			m.err = fmt.Errorf("no hex viewer found (install xxd or hexdump)")
-			return m, nil
-		}
-
-		// Find pager
-		pager := "less"
-		if p := os.Getenv("PAGER"); p != "" {
-			pager = p
-		}
-
-		// Use shell pipe: xxd file | less
-		shellCmd := fmt.Sprintf("%s %q | %s", hexCmd, targetPath, pager)
-		c = exec.Command("sh", "-c", shellCmd)
-	}
-
-	return m, tea.ExecProcess(c, func(err error) tea.Msg {
-		if err != nil {
-			return scanErrorMsg{err: fmt.Errorf("hex view failed: %w", err)}
-		}
-		return nil
-	})
-}
-
 func (m Model) lineCountForSelected() tea.Cmd {
-	if len(m.filtered) == 0 {
+	if len(m.filtered) == 0 || m.vfs != nil {
 		return nil
 	}
 	e := m.filtered[m.cursor]
 	if e.IsDir || e.IsBinary || e.LineCount > 0 {
 		return nil
 	}
-	return countLinesCmd(m.path, e.Name)
+	return countLinesCmd(m.path, e.Name, int64(m.settings.LineCountMax))
 }
 
 // openPath opens a file or directory with the OS default handler.
@@ -975,26 +1493,30 @@ type trashResultMsg struct {
 // trashCmd moves the given path to the system trash asynchronously.
 func trashCmd(path, name string, size int64, isDir bool) tea.Cmd {
 	return func() tea.Msg {
-		var err error
-		switch runtime.GOOS {
-		case "darwin":
-			// Use AppleScript so Finder properly manages the Trash.
-			// Escape backslashes and double quotes to prevent injection.
-			escaped := strings.ReplaceAll(path, `\`, `\\`)
-			escaped = strings.ReplaceAll(escaped, `"`, `\"`)
-			script := fmt.Sprintf(`tell application "Finder" to delete POSIX file "%s"`, escaped)
-			out, e := exec.Command("osascript", "-e", script).CombinedOutput()
-			if e != nil {
-				err = fmt.Errorf("trash failed: %s", strings.TrimSpace(string(out)))
-			}
-		case "linux":
-			err = trashLinux(path)
-		case "windows":
-			err = trashWindows(path)
-		default:
-			err = fmt.Errorf("trash not supported on %s", runtime.GOOS)
+		return trashResultMsg{err: moveToTrash(path), name: name, size: size, isDir: isDir}
+	}
+}
+
+// moveToTrash moves path to the system trash.
+func moveToTrash(path string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// Use AppleScript so Finder properly manages the Trash.
+		// Escape backslashes and double quotes to prevent injection.
+		escaped := strings.ReplaceAll(path, `\`, `\\`)
+		escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+		script := fmt.Sprintf(`tell application "Finder" to delete POSIX file "%s"`, escaped)
+		out, e := exec.Command("osascript", "-e", script).CombinedOutput()
+		if e != nil {
+			return fmt.Errorf("trash failed: %s", strings.TrimSpace(string(out)))
 		}
-		return trashResultMsg{err: err, name: name, size: size, isDir: isDir}
+		return nil
+	case "linux":
+		return trashLinux(path)
+	case "windows":
+		return trashWindows(path)
+	default:
+		return fmt.Errorf("trash not supported on %s", runtime.GOOS)
 	}
 }
 
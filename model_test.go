@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestSortRememberedPerDirectory(t *testing.T) {
+	m := NewModel("/a")
+	m.width, m.height = 80, 24
+	listing := func(path string) scanResultMsg {
+		return scanResultMsg{path: path, entries: []entry.FileEntry{
+			{Name: "b", Size: 10},
+			{Name: "a", Size: 20},
+		}}
+	}
+
+	next, _ := m.Update(listing("/a"))
+	m = next.(Model)
+	m.sortMode = entry.SortMode{Key: entry.SortName}
+	m.rememberSort("/a", m.sortMode)
+	m.applySort()
+	if m.filtered[0].Name != "a" {
+		t.Fatalf("expected name order, got %q first", m.filtered[0].Name)
+	}
+
+	// A directory without a remembered order inherits the current one
+	next, _ = m.Update(listing("/b"))
+	m = next.(Model)
+	if m.sortMode.Key != entry.SortName {
+		t.Errorf("expected /b to inherit name sort, got %v", m.sortMode)
+	}
+	m.sortMode = entry.DefaultSort
+	m.rememberSort("/b", m.sortMode)
+
+	next, _ = m.Update(listing("/a"))
+	m = next.(Model)
+	if m.sortMode.Key != entry.SortName || m.filtered[0].Name != "a" {
+		t.Errorf("expected /a to restore name sort, got %v with %q first", m.sortMode, m.filtered[0].Name)
+	}
+	next, _ = m.Update(listing("/b"))
+	m = next.(Model)
+	if m.sortMode != entry.DefaultSort || m.filtered[0].Name != "a" {
+		t.Errorf("expected /b to restore size sort, got %v", m.sortMode)
+	}
+}
+
+func TestSortRestoredFromCache(t *testing.T) {
+	m := NewModel("/a")
+	m.width, m.height = 80, 24
+	listing := func(path string) scanResultMsg {
+		return scanResultMsg{path: path, entries: []entry.FileEntry{
+			{Name: "a", Size: 10},
+			{Name: "b", Size: 20, IsDir: true},
+		}}
+	}
+
+	next, _ := m.Update(listing("/a"))
+	m = next.(Model)
+	m.sortMode = entry.SortMode{Key: entry.SortName}
+	m.rememberSort("/a", m.sortMode)
+	m.applySort()
+
+	// Enter /a/b and sort it by size
+	m.cursor = 1
+	m, _ = m.navigateIn()
+	next, _ = m.Update(listing("/a/b"))
+	m = next.(Model)
+	m.sortMode = entry.DefaultSort
+	m.rememberSort("/a/b", m.sortMode)
+	m.applySort()
+	if m.filtered[0].Name != "b" {
+		t.Fatalf("expected size order in /a/b, got %q first", m.filtered[0].Name)
+	}
+
+	// /a comes back from the cache in its own order, not the one left behind
+	m, _ = m.navigateUp()
+	if !m.fromCache {
+		t.Fatal("expected /a to come from the cache")
+	}
+	if m.sortMode.Key != entry.SortName || m.filtered[0].Name != "a" {
+		t.Errorf("expected /a in name order, got %v with %q first", m.sortMode, m.filtered[0].Name)
+	}
+	if m.filtered[m.cursor].Name != "b" {
+		t.Errorf("expected the cursor on b, got %q", m.filtered[m.cursor].Name)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// ncduLoader returns a loader that reads an ncdu JSON export
+// (`ncdu -o file`) into an in-memory tree.
+func ncduLoader(file string) func(prog *scan.Progress) (*vfsView, error) {
+	var load func(prog *scan.Progress) (*vfsView, error)
+	load = func(prog *scan.Progress) (*vfsView, error) {
+		root, err := readNcduDump(file, prog)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import %s: %w", filepath.Base(file), err)
+		}
+		root.finalize()
+		return &vfsView{
+			path:     filepath.Clean(filepath.FromSlash(root.name)),
+			fsys:     memFS{root: root},
+			kind:     "ncdu",
+			size:     root.size,
+			detached: true,
+			reload:   load,
+		}, nil
+	}
+	return load
+}
+
+// readNcduDump parses an ncdu export: [major, minor, {metadata}, [dir...]]
+// where a directory is an array whose first element is its own info object
+// followed by file objects and nested directory arrays. The stream is decoded
+// token by token so large dumps are never held in memory as raw JSON.
+func readNcduDump(file string, prog *scan.Progress) (*memNode, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReaderSize(f, 256*1024))
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+	var major int
+	if err := dec.Decode(&major); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if major != 1 {
+		return nil, fmt.Errorf("unsupported ncdu export version %d", major)
+	}
+	var skip json.RawMessage
+	if err := dec.Decode(&skip); err != nil { // minor version
+		return nil, err
+	}
+	if err := dec.Decode(&skip); err != nil { // metadata object
+		return nil, err
+	}
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+	return readNcduDir(dec, prog)
+}
+
+// ncduInfo is the subset of an ncdu info object used by dirgo.
+type ncduInfo struct {
+	name   string
+	asize  int64
+	dsize  int64
+	ino    uint64
+	nlink  uint64
+	mtime  int64
+	notreg bool
+}
+
+// readNcduDir reads a directory array whose opening '[' was already consumed.
+func readNcduDir(dec *json.Decoder, prog *scan.Progress) (*memNode, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	info, err := readNcduInfo(dec)
+	if err != nil {
+		return nil, err
+	}
+	dir := newMemDir(info.name)
+	dir.inode = info.ino
+	dir.modTime = unixTime(info.mtime)
+	if prog != nil {
+		prog.Dirs.Add(1)
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case json.Delim('['):
+			child, err := readNcduDir(dec, prog)
+			if err != nil {
+				return nil, err
+			}
+			dir.children[child.name] = child
+		case json.Delim('{'):
+			fi, err := readNcduInfo(dec)
+			if err != nil {
+				return nil, err
+			}
+			dir.children[fi.name] = &memNode{
+				name:      fi.name,
+				isSymlink: fi.notreg,
+				size:      fi.asize,
+				diskSize:  fi.dsize,
+				inode:     fi.ino,
+				nlink:     fi.nlink,
+				modTime:   unixTime(fi.mtime),
+			}
+			if prog != nil {
+				prog.Files.Add(1)
+				prog.Size.Add(fi.asize)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected token %v in directory %q", tok, info.name)
+		}
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, err
+	}
+	return dir, nil
+}
+
+// readNcduInfo reads an info object whose opening '{' was already consumed.
+func readNcduInfo(dec *json.Decoder) (ncduInfo, error) {
+	var info ncduInfo
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return info, err
+		}
+		key, _ := keyTok.(string)
+		var val any
+		if err := dec.Decode(&val); err != nil {
+			return info, err
+		}
+		switch key {
+		case "name":
+			info.name, _ = val.(string)
+		case "asize":
+			info.asize = jsonInt(val)
+		case "dsize":
+			info.dsize = jsonInt(val)
+		case "ino":
+			info.ino = uint64(jsonInt(val))
+		case "nlink":
+			info.nlink = uint64(jsonInt(val))
+		case "mtime":
+			info.mtime = jsonInt(val)
+		case "notreg":
+			info.notreg, _ = val.(bool)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return info, err
+	}
+	if info.name == "" {
+		return info, fmt.Errorf("entry without a name")
+	}
+	return info, nil
+}
+
+// expectDelim consumes the next token and checks it is the given delimiter.
+func expectDelim(dec *json.Decoder, d json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != d {
+		return fmt.Errorf("expected %q, got %v", d, tok)
+	}
+	return nil
+}
+
+// jsonInt converts a decoded json.Number to int64, returning 0 otherwise.
+func jsonInt(v any) int64 {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0
+	}
+	i, _ := n.Int64()
+	return i
+}
+
+// unixTime converts seconds since the epoch to time.Time (zero stays zero).
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
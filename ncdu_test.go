@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const ncduSample = `[1,2,{"progname":"ncdu","progver":"1.19","timestamp":1700000000},
+[{"name":"/srv/data","asize":4096,"dsize":4096,"ino":1,"mtime":1700000000},
+ {"name":"big.bin","asize":5000,"dsize":8192,"ino":2},
+ {"name":"link","asize":10,"dsize":0,"notreg":true},
+ [{"name":"logs","asize":4096,"dsize":4096,"ino":3},
+  {"name":"a.log","asize":300,"dsize":4096,"ino":4,"nlink":2,"hlnkc":true},
+  {"name":"b.log","asize":200,"dsize":4096,"ino":5,"extra":{"nested":[1,2]}}
+ ]
+]]`
+
+func TestReadNcduDump(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dump.json")
+	os.WriteFile(file, []byte(ncduSample), 0o644)
+
+	view, err := ncduLoader(file)(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.path != filepath.FromSlash("/srv/data") || !view.detached {
+		t.Errorf("unexpected view: %+v", view)
+	}
+	if view.size != 5510 {
+		t.Errorf("expected total size 5510, got %d", view.size)
+	}
+
+	res, ok := scanFS(context.Background(), view.fsys, ".", view.path, nil).(scanResultMsg)
+	if !ok {
+		t.Fatal("expected scanResultMsg")
+	}
+	if res.totalFiles != 2 || res.totalDirs != 1 {
+		t.Errorf("expected 2 files / 1 dir, got %d / %d", res.totalFiles, res.totalDirs)
+	}
+	for _, e := range res.entries {
+		switch e.Name {
+		case "logs":
+			if e.Size != 500 || e.DiskSize != 8192 || e.ChildFiles != 2 {
+				t.Errorf("unexpected logs entry: %+v", e)
+			}
+		case "link":
+			if !e.IsSymlink {
+				t.Error("notreg entry should be marked as symlink")
+			}
+		}
+	}
+}
+
+func TestReadNcduDumpErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"version.json":   `[2,0,{},[{"name":"/"}]]`,
+		"truncated.json": `[1,2,{},[{"name":"/x"},{"name":"f"`,
+		"noname.json":    `[1,2,{},[{"asize":1}]]`,
+	} {
+		file := filepath.Join(dir, name)
+		os.WriteFile(file, []byte(body), 0o644)
+		if _, err := ncduLoader(file)(nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,651 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// The pager shows a text file full-screen. Instead of loading the file, it
+// streams through it once in the background recording where each line
+// starts; visible lines are then read on demand with ReadAt. The file is
+// usable while indexing runs, and follow mode keeps indexing as it grows.
+
+const (
+	pagerChunk       = 4 << 20 // bytes indexed per step
+	pagerMaxLineRead = 4096    // bytes of a single line read for display
+	pagerFollowEvery = time.Second
+)
+
+// errBinaryFile is returned when a file opened in the pager is not text.
+var errBinaryFile = errors.New("binary file")
+
+// pagerPrompt is the text prompt open in the pager, if any.
+type pagerPrompt int
+
+const (
+	pagerPromptNone pagerPrompt = iota
+	pagerPromptSearch
+	pagerPromptLine
+)
+
+// pager is the state of the open text pager.
+type pager struct {
+	path    string
+	title   string      // shown instead of path when set
+	src     pagerSource // the file, or in-memory text such as command output
+	starts  []int64     // offset of each line start found so far
+	indexed int64       // bytes indexed; the file's size once done
+	done    bool        // indexed up to the end of the file
+	gen     int         // bumped when indexing restarts, to drop stale chunks
+
+	top    int // first visible line
+	left   int // horizontal scroll in cells when not wrapping
+	wrap   bool
+	number bool // show line numbers
+	follow bool // keep indexing and stick to the end as the file grows
+
+	prompt pagerPrompt
+	input  textinput.Model
+
+	query     string // last search
+	matchLine int    // line of the current match; -1 for none
+	searching context.CancelFunc
+	status    string
+}
+
+// pagerSource is what the pager reads lines from. Follow mode and editing
+// need an *os.File.
+type pagerSource interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// pagerIndexMsg carries the line starts found in one indexed chunk.
+type pagerIndexMsg struct {
+	path   string
+	gen    int
+	starts []int64
+	end    int64 // offset indexing reached
+	eof    bool
+	err    error
+}
+
+// pagerFollowMsg asks the pager to check whether the file has grown.
+type pagerFollowMsg struct {
+	path string
+}
+
+// pagerSearchMsg reports the byte offset of a match, or -1.
+type pagerSearchMsg struct {
+	path  string
+	query string
+	at    int64
+	err   error
+}
+
+// openPager opens the selected file in the pager. Binary files are refused
+// with errBinaryFile.
+func (m Model) openPager() (Model, tea.Cmd, error) {
+	sel := m.filtered[m.cursor]
+	p, err := newPager(filepath.Join(m.path, sel.Name))
+	if err != nil {
+		return m, nil, err
+	}
+	m.pager = p
+	return m, p.indexCmd(0), nil
+}
+
+func newPager(path string) (*pager, error) {
+	head, err := scan.Head(path, 512)
+	if err != nil {
+		return nil, err
+	}
+	if scan.IsBinaryContent(head) {
+		return nil, errBinaryFile
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return newPagerFrom(path, f), nil
+}
+
+// outputPager shows in-memory text under title, such as the output of a
+// background action.
+func outputPager(title string, data []byte) *pager {
+	p := newPagerFrom("output:"+title, memSource{bytes.NewReader(data)})
+	p.title = title
+	return p
+}
+
+// memSource is an in-memory pagerSource.
+type memSource struct{ *bytes.Reader }
+
+func (memSource) Close() error { return nil }
+
+func newPagerFrom(path string, src pagerSource) *pager {
+	in := textinput.New()
+	in.CharLimit = 256
+	in.Width = 40
+	return &pager{
+		path:      path,
+		src:       src,
+		starts:    []int64{0},
+		number:    true,
+		input:     in,
+		matchLine: -1,
+	}
+}
+
+// close stops a running search and closes the file. Indexing steps still in
+// flight fail on the closed file and are dropped.
+func (p *pager) close() {
+	if p.searching != nil {
+		p.searching()
+	}
+	p.src.Close()
+}
+
+// indexCmd indexes one chunk starting at from.
+func (p *pager) indexCmd(from int64) tea.Cmd {
+	src, path, gen := p.src, p.path, p.gen
+	return func() tea.Msg {
+		starts, end, eof, err := indexLines(src, from, pagerChunk)
+		return pagerIndexMsg{path: path, gen: gen, starts: starts, end: end, eof: eof, err: err}
+	}
+}
+
+// indexLines reads up to n bytes at from and returns the offsets just after
+// each newline, the offset reached, and whether the end of file was hit.
+func indexLines(r io.ReaderAt, from int64, n int) ([]int64, int64, bool, error) {
+	buf := make([]byte, n)
+	got, err := r.ReadAt(buf, from)
+	eof := err == io.EOF || got < n
+	if err != nil && err != io.EOF {
+		return nil, from, false, err
+	}
+	var starts []int64
+	data := buf[:got]
+	for off := 0; ; {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i < 0 {
+			break
+		}
+		off += i + 1
+		starts = append(starts, from+int64(off))
+	}
+	return starts, from + int64(got), eof, nil
+}
+
+// applyPagerIndex records an indexed chunk and schedules the next one, or a
+// follow check once the end is reached.
+func (m Model) applyPagerIndex(msg pagerIndexMsg) (Model, tea.Cmd) {
+	p := m.pager
+	if p == nil || p.path != msg.path || p.gen != msg.gen {
+		return m, nil
+	}
+	if msg.err != nil {
+		p.status = msg.err.Error()
+		p.done = true
+		return m, nil
+	}
+	p.starts = append(p.starts, msg.starts...)
+	p.indexed = msg.end
+	p.done = msg.eof
+	if p.follow {
+		p.top = p.maxTop(pagerRows(m))
+	}
+	if !p.done {
+		return m, p.indexCmd(p.indexed)
+	}
+	if p.follow {
+		return m, followTick(p.path)
+	}
+	return m, nil
+}
+
+func followTick(path string) tea.Cmd {
+	return tea.Tick(pagerFollowEvery, func(time.Time) tea.Msg { return pagerFollowMsg{path: path} })
+}
+
+// applyPagerFollow indexes whatever was appended since the last check. A
+// file that shrank was truncated or replaced, so it is indexed again.
+func (m Model) applyPagerFollow(msg pagerFollowMsg) (Model, tea.Cmd) {
+	p := m.pager
+	if p == nil || p.path != msg.path || !p.follow || !p.done {
+		return m, nil
+	}
+	f, ok := p.src.(*os.File)
+	if !ok {
+		return m, nil
+	}
+	info, err := f.Stat()
+	switch {
+	case err != nil:
+		p.status = err.Error()
+		return m, nil
+	case info.Size() < p.indexed:
+		return m, p.reindex()
+	case info.Size() > p.indexed:
+		p.done = false
+		return m, p.indexCmd(p.indexed)
+	}
+	return m, followTick(p.path)
+}
+
+// reindex drops the line index and indexes the file again from the start,
+// after it was truncated, replaced or edited.
+func (p *pager) reindex() tea.Cmd {
+	p.gen++
+	p.starts, p.indexed, p.done, p.matchLine = []int64{0}, 0, false, -1
+	return p.indexCmd(0)
+}
+
+// lineCount returns the number of lines indexed so far. A line start at the
+// indexed end is only a line once something follows it.
+func (p *pager) lineCount() int {
+	n := len(p.starts)
+	if n > 1 && p.starts[n-1] >= p.indexed {
+		n--
+	}
+	return n
+}
+
+// line reads line i for display, without its newline.
+func (p *pager) line(i int) string {
+	start := p.starts[i]
+	end := p.indexed
+	if i+1 < len(p.starts) {
+		end = p.starts[i+1]
+	}
+	n := int(min(end-start, pagerMaxLineRead))
+	buf := make([]byte, n)
+	got, _ := p.src.ReadAt(buf, start)
+	s := strings.TrimRight(string(buf[:got]), "\r\n")
+	return sanitizeLine(s)
+}
+
+// lineAt returns the line containing byte offset off.
+func (p *pager) lineAt(off int64) int {
+	return sort.Search(len(p.starts), func(i int) bool { return p.starts[i] > off }) - 1
+}
+
+func (p *pager) maxTop(rows int) int {
+	return max(0, p.lineCount()-rows)
+}
+
+func (p *pager) scroll(delta, rows int) {
+	p.top = max(0, min(p.top+delta, p.maxTop(rows)))
+}
+
+// pagerRows returns how many text rows fit on screen.
+func pagerRows(m Model) int {
+	return max(1, m.height-4)
+}
+
+// updatePager handles keys while the pager is open.
+func (m Model) updatePager(msg tea.KeyMsg) (Model, tea.Cmd) {
+	p := m.pager
+	rows := pagerRows(m)
+
+	if p.prompt != pagerPromptNone {
+		switch msg.Type {
+		case tea.KeyEsc:
+			p.prompt = pagerPromptNone
+			p.input.Blur()
+			return m, nil
+		case tea.KeyEnter:
+			prompt, value := p.prompt, p.input.Value()
+			p.prompt = pagerPromptNone
+			p.input.Blur()
+			if value == "" {
+				return m, nil
+			}
+			if prompt == pagerPromptLine {
+				n, err := strconv.Atoi(strings.TrimSpace(value))
+				if err != nil || n < 1 {
+					p.status = fmt.Sprintf("invalid line %q", value)
+					return m, nil
+				}
+				p.follow = false
+				p.top = max(0, min(n-1, p.maxTop(rows)))
+				return m, nil
+			}
+			p.query = value
+			p.matchLine = -1
+			return m, p.searchCmd(false)
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return m, cmd
+	}
+
+	p.status = ""
+	if key.Matches(msg, m.keys.Pager, m.keys.Escape) {
+		p.close()
+		m.pager = nil
+		return m, nil
+	}
+	switch msg.String() {
+	case "ctrl+c":
+		p.close()
+		return m, tea.Quit
+	case "esc", "q":
+		p.close()
+		m.pager = nil
+		return m, nil
+	case "up", "k":
+		p.follow = false
+		p.scroll(-1, rows)
+	case "down", "j", "enter":
+		p.scroll(1, rows)
+	case "pgup", "ctrl+u", "b":
+		p.follow = false
+		p.scroll(-rows, rows)
+	case "pgdown", "ctrl+d", " ", "f":
+		p.scroll(rows, rows)
+	case "home", "g":
+		p.follow = false
+		p.top = 0
+	case "end", "G":
+		p.top = p.maxTop(rows)
+	case "left", "h":
+		p.left = max(0, p.left-8)
+	case "right", "l":
+		if !p.wrap {
+			p.left += 8
+		}
+	case "w":
+		p.wrap = !p.wrap
+		p.left = 0
+	case "#":
+		p.number = !p.number
+	case "e":
+		// Edit at the top line; the pager reloads when the editor exits
+		if _, ok := p.src.(*os.File); !ok {
+			return m, nil
+		}
+		rel, err := filepath.Rel(m.path, p.path)
+		if err != nil {
+			return m, nil
+		}
+		return m.editFile(rel, p.top+1)
+	case "F":
+		if _, ok := p.src.(*os.File); !ok {
+			return m, nil
+		}
+		p.follow = !p.follow
+		if p.follow {
+			p.top = p.maxTop(rows)
+			if p.done {
+				return m, followTick(p.path)
+			}
+		}
+	case ":":
+		return m, p.openPrompt(pagerPromptLine, "line number")
+	case "/":
+		return m, p.openPrompt(pagerPromptSearch, "text")
+	case "n", "N":
+		if p.query == "" {
+			p.status = "no search pattern"
+			return m, nil
+		}
+		return m, p.searchCmd(msg.String() == "N")
+	}
+	return m, nil
+}
+
+func (p *pager) openPrompt(kind pagerPrompt, placeholder string) tea.Cmd {
+	p.prompt = kind
+	p.input.Placeholder = placeholder
+	p.input.SetValue("")
+	p.input.Focus()
+	return textinput.Blink
+}
+
+// searchCmd looks for the query in the indexed part of the file, starting
+// after the current match (or at the top line), or before it when backward.
+func (p *pager) searchCmd(backward bool) tea.Cmd {
+	if p.searching != nil {
+		p.searching()
+	}
+	line := p.top
+	if p.matchLine >= 0 {
+		line = p.matchLine
+		if !backward {
+			line++
+		}
+	}
+	var from int64
+	switch {
+	case backward && line == 0:
+		p.status = "pattern not found"
+		return nil
+	case backward:
+		from = p.starts[line] - 1 // a match must start before this line
+	case line >= p.lineCount():
+		p.status = "pattern not found"
+		return nil
+	default:
+		from = p.starts[line]
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.searching = cancel
+	p.status = "searching…"
+	src, size, query, path := p.src, p.indexed, p.query, p.path
+	return func() tea.Msg {
+		at, err := findBytes(ctx, src, size, []byte(query), from, backward)
+		if ctx.Err() != nil {
+			return nil
+		}
+		return pagerSearchMsg{path: path, query: query, at: at, err: err}
+	}
+}
+
+// applyPagerSearch scrolls to a finished search's match.
+func (m Model) applyPagerSearch(msg pagerSearchMsg) Model {
+	p := m.pager
+	if p == nil || p.path != msg.path || p.query != msg.query {
+		return m
+	}
+	p.searching = nil
+	switch {
+	case msg.err != nil:
+		p.status = msg.err.Error()
+	case msg.at < 0:
+		p.status = "pattern not found"
+	default:
+		rows := pagerRows(m)
+		p.status = ""
+		p.follow = false
+		p.matchLine = p.lineAt(msg.at)
+		if p.matchLine < p.top || p.matchLine >= p.top+rows {
+			p.top = max(0, min(p.matchLine-rows/3, p.maxTop(rows)))
+		}
+	}
+	return m
+}
+
+// renderPager draws the pager full-screen.
+func renderPager(m Model) string {
+	p := m.pager
+	rows := pagerRows(m)
+	count := p.lineCount()
+
+	var b strings.Builder
+	pos := fmt.Sprintf("  line %s/%s", formatCount(min(p.top+1, count)), formatCount(count))
+	if !p.done {
+		pos += "+"
+	}
+	badges := ""
+	if p.wrap {
+		badges += " " + headerBadgeStyle.Render("WRAP")
+	}
+	if p.follow {
+		badges += " " + headerBadgeStyle.Render("FOLLOW")
+	}
+	name := truncatePath(shortenPath(p.path), m.width/2)
+	if p.title != "" {
+		name = truncateStr(p.title, m.width/2)
+	}
+	title := headerPathStyle.Render(name) +
+		headerStatStyle.Render(pos) + badges
+	b.WriteString(headerStyle.Width(m.width).Render(title))
+	b.WriteString("\n")
+	b.WriteString(m.cachedSep)
+	b.WriteString("\n")
+
+	numW := len(strconv.Itoa(max(count, 1)))
+	textW := m.width
+	if p.number {
+		textW -= numW + 1
+	}
+	textW = max(1, textW)
+
+	written := 0
+	for i := p.top; i < count && written < rows; i++ {
+		segs := []string{p.line(i)}
+		if p.wrap {
+			segs = wrapCells(segs[0], textW)
+		} else {
+			segs[0] = sliceCells(segs[0], p.left, textW)
+		}
+		for j, seg := range segs {
+			if written == rows {
+				break
+			}
+			if p.number {
+				num := ""
+				if j == 0 {
+					num = strconv.Itoa(i + 1)
+				}
+				b.WriteString(rowDimStyle.Render(padLeft(num, numW)) + " ")
+			}
+			b.WriteString(highlightMatches(seg, p.query))
+			b.WriteString("\n")
+			written++
+		}
+	}
+	for ; written < rows; written++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(m.cachedSep)
+	b.WriteString("\n")
+	switch {
+	case p.prompt == pagerPromptSearch:
+		b.WriteString(searchPromptStyle.Render(" / ") + p.input.View())
+	case p.prompt == pagerPromptLine:
+		b.WriteString(searchPromptStyle.Render(" line ") + p.input.View())
+	case p.status != "":
+		b.WriteString(footerDescStyle.Render(" " + p.status))
+	default:
+		b.WriteString(footerStyle.Width(m.width).Render(
+			footerKeyStyle.Render("↑↓") + " " + footerDescStyle.Render("scroll") + "  " +
+				footerKeyStyle.Render("/") + " " + footerDescStyle.Render("search") + "  " +
+				footerKeyStyle.Render("n/N") + " " + footerDescStyle.Render("next/prev") + "  " +
+				footerKeyStyle.Render(":") + " " + footerDescStyle.Render("line") + "  " +
+				footerKeyStyle.Render("w") + " " + footerDescStyle.Render("wrap") + "  " +
+				footerKeyStyle.Render("F") + " " + footerDescStyle.Render("follow") + "  " +
+				footerKeyStyle.Render("e") + " " + footerDescStyle.Render("edit") + "  " +
+				footerKeyStyle.Render(m.keys.closeLabel(m.keys.Pager)) + " " + footerDescStyle.Render("close")))
+	}
+	return b.String()
+}
+
+// wrapCells splits s into pieces at most w cells wide, a wide character
+// moving whole to the next piece; an empty s gives one empty piece.
+func wrapCells(s string, w int) []string {
+	if lipgloss.Width(s) <= w {
+		return []string{s}
+	}
+	var out []string
+	start, col := 0, 0
+	for i, r := range s {
+		rw := lipgloss.Width(string(r))
+		if col+rw > w && i > start {
+			out = append(out, s[start:i])
+			start, col = i, 0
+		}
+		col += rw
+	}
+	return append(out, s[start:])
+}
+
+// sliceCells returns at most w cells of s starting at cell from. A wide
+// character cut by either edge shows as a space on the left and is dropped
+// on the right.
+func sliceCells(s string, from, w int) string {
+	var b strings.Builder
+	col, used := 0, 0
+	for _, r := range s {
+		rw := lipgloss.Width(string(r))
+		switch {
+		case col+rw <= from:
+		case col < from:
+			b.WriteByte(' ')
+			used += col + rw - from
+		case used+rw > w:
+			return b.String()
+		default:
+			b.WriteRune(r)
+			used += rw
+		}
+		col += rw
+	}
+	return b.String()
+}
+
+// highlightMatches renders s with every occurrence of query highlighted.
+func highlightMatches(s, query string) string {
+	if query == "" || !strings.Contains(s, query) {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, query)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i])
+		b.WriteString(matchStyle.Render(query))
+		s = s[i+len(query):]
+	}
+}
+
+// viewText opens the selected text file in the pager.
+func (m Model) viewText() (Model, tea.Cmd) {
+	if len(m.filtered) == 0 || m.filtered[m.cursor].IsDir {
+		return m, nil
+	}
+	if m.vfs != nil {
+		m.err = fmt.Errorf("cannot view files inside %s", filepath.Base(m.vfs.path))
+		return m, nil
+	}
+	next, cmd, err := m.openPager()
+	switch {
+	case errors.Is(err, errBinaryFile):
+		m.err = errors.New("binary file — use x for the hex viewer")
+		return m, nil
+	case err != nil:
+		m.err = fmt.Errorf("cannot view %s: %w", m.filtered[m.cursor].Name, err)
+		return m, nil
+	}
+	return next, cmd
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pagerModel opens content in the pager and indexes it with chunk-sized steps.
+func pagerModel(t *testing.T, content string) (Model, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	os.WriteFile(path, []byte(content), 0o644)
+	m := makeTestModel(1)
+	m.cachedSep = strings.Repeat("─", m.width)
+	p, err := newPager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.close)
+	m.pager = p
+	return indexAll(t, m, p.indexCmd(0)), path
+}
+
+// indexAll runs index commands until the pager reaches the end of the file.
+func indexAll(t *testing.T, m Model, cmd tea.Cmd) Model {
+	t.Helper()
+	for i := 0; cmd != nil && !m.pager.done && i < 100; i++ {
+		msg, ok := cmd().(pagerIndexMsg)
+		if !ok {
+			break
+		}
+		m, cmd = m.applyPagerIndex(msg)
+	}
+	return m
+}
+
+func TestIndexLines(t *testing.T) {
+	r := strings.NewReader("ab\ncd\n\nef")
+	starts, end, eof, err := indexLines(r, 0, 4)
+	if err != nil || eof || end != 4 || fmt.Sprint(starts) != "[3]" {
+		t.Errorf("first chunk: %v %d %v %v", starts, end, eof, err)
+	}
+	starts, end, eof, err = indexLines(r, 4, 100)
+	if err != nil || !eof || end != 9 || fmt.Sprint(starts) != "[6 7]" {
+		t.Errorf("second chunk: %v %d %v %v", starts, end, eof, err)
+	}
+}
+
+func TestPagerLines(t *testing.T) {
+	m, _ := pagerModel(t, "first\n\tsecond\r\n\nlast\n")
+	p := m.pager
+	if !p.done || p.lineCount() != 4 {
+		t.Fatalf("indexed %d lines (done=%v), want 4", p.lineCount(), p.done)
+	}
+	want := []string{"first", "    second", "", "last"}
+	for i, w := range want {
+		if got := p.line(i); got != w {
+			t.Errorf("line %d = %q, want %q", i, got, w)
+		}
+	}
+
+	if _, err := newPager(writeTemp(t, "\x00\x01binary")); err != errBinaryFile {
+		t.Errorf("binary file opened in pager: %v", err)
+	}
+}
+
+func TestPagerSearchAndGoto(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 200; i++ {
+		if i%50 == 0 {
+			fmt.Fprintf(&b, "line %d ERROR here\n", i)
+		} else {
+			fmt.Fprintf(&b, "line %d ok\n", i)
+		}
+	}
+	m, _ := pagerModel(t, b.String())
+	p := m.pager
+
+	p.query = "ERROR"
+	m = m.applyPagerSearch(p.searchCmd(false)().(pagerSearchMsg))
+	if p.matchLine != 49 {
+		t.Fatalf("first match on line %d, want 49", p.matchLine)
+	}
+	m = m.applyPagerSearch(p.searchCmd(false)().(pagerSearchMsg))
+	if p.matchLine != 99 || p.top > 99 || p.top+pagerRows(m) <= 99 {
+		t.Fatalf("next match on line %d with top %d", p.matchLine, p.top)
+	}
+	m = m.applyPagerSearch(p.searchCmd(true)().(pagerSearchMsg))
+	if p.matchLine != 49 {
+		t.Errorf("previous match on line %d, want 49", p.matchLine)
+	}
+
+	// The match is highlighted
+	if !strings.Contains(renderPager(m), matchStyle.Render("ERROR")) {
+		t.Error("match not highlighted")
+	}
+
+	// :150 jumps to line 150
+	m, _ = m.updatePager(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	p.input.SetValue("150")
+	m, _ = m.updatePager(tea.KeyMsg{Type: tea.KeyEnter})
+	if p.top != 149 {
+		t.Errorf("goto line 150: top = %d", p.top)
+	}
+}
+
+func TestPagerFollow(t *testing.T) {
+	m, path := pagerModel(t, "one\ntwo\n")
+	p := m.pager
+	m, _ = m.updatePager(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F")})
+	if !p.follow {
+		t.Fatal("F did not enable follow")
+	}
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("three\nfour")
+	f.Close()
+	m, cmd := m.applyPagerFollow(pagerFollowMsg{path: path})
+	m = indexAll(t, m, cmd)
+	if p.lineCount() != 4 || p.line(3) != "four" {
+		t.Fatalf("after append: %d lines, last %q", p.lineCount(), p.line(p.lineCount()-1))
+	}
+
+	// Truncation restarts indexing from the beginning
+	os.WriteFile(path, []byte("new\n"), 0o644)
+	m, cmd = m.applyPagerFollow(pagerFollowMsg{path: path})
+	m = indexAll(t, m, cmd)
+	if p.lineCount() != 1 || p.line(0) != "new" {
+		t.Errorf("after truncation: %d lines, first %q", p.lineCount(), p.line(0))
+	}
+}
+
+func TestRenderPagerWidth(t *testing.T) {
+	m, _ := pagerModel(t, strings.Repeat("x", 500)+"\nshort\n")
+	for _, wrap := range []bool{false, true} {
+		m.pager.wrap = wrap
+		lines := strings.Split(renderPager(m), "\n")
+		if len(lines) != m.height {
+			t.Errorf("wrap=%v: %d lines, want %d", wrap, len(lines), m.height)
+		}
+		for i, line := range lines {
+			if w := lipgloss.Width(line); w > m.width {
+				t.Errorf("wrap=%v: line %d is %d wide", wrap, i, w)
+			}
+		}
+	}
+}
+
+func TestPagerWideCharacters(t *testing.T) {
+	m, _ := pagerModel(t, strings.Repeat("日本語", 100)+"\nshort\n")
+	for _, wrap := range []bool{false, true} {
+		m.pager.wrap = wrap
+		m.pager.left = 1 // starts halfway into a wide character
+		for i, line := range strings.Split(renderPager(m), "\n") {
+			if w := lipgloss.Width(line); w > m.width {
+				t.Errorf("wrap=%v: line %d is %d wide", wrap, i, w)
+			}
+		}
+	}
+
+	if got := wrapCells("ab日本c", 3); !reflect.DeepEqual(got, []string{"ab", "日", "本c"}) {
+		t.Errorf("wrapCells = %q", got)
+	}
+	for _, tt := range []struct {
+		from, w int
+		want    string
+	}{{0, 3, "a日"}, {1, 4, "日本"}, {2, 3, " 本"}, {0, 2, "a"}, {9, 3, ""}} {
+		if got := sliceCells("a日本c", tt.from, tt.w); got != tt.want {
+			t.Errorf("sliceCells(%d, %d) = %q, want %q", tt.from, tt.w, got, tt.want)
+		}
+	}
+}
+
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, []byte(content), 0o644)
+	return path
+}
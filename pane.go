@@ -0,0 +1,244 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// paneKind selects the content of the right-hand side pane.
+type paneKind int
+
+const (
+	paneNone    paneKind = iota
+	paneChart            // composition charts of the directory and the selection
+	panePreview          // contents or summary of the selected entry
+)
+
+// minPaneTermWidth is the narrowest terminal that still shows the side pane;
+// below it the list gets the full width.
+const minPaneTermWidth = 90
+
+// paneWidth returns the width of the side pane including its border, or 0
+// when it is off or the terminal is too narrow.
+func (m Model) paneWidth() int {
+	if m.pane == paneNone || m.width < minPaneTermWidth {
+		return 0
+	}
+	return minInt(50, maxInt(32, m.width/3))
+}
+
+// togglePane shows kind in the side pane, or hides it if already shown.
+func (m Model) togglePane(kind paneKind) Model {
+	if m.pane == kind {
+		m.pane = paneNone
+	} else {
+		m.pane = kind
+	}
+	if m.preview == nil {
+		m.preview = newPreviewState()
+	}
+	m.preview.reset() // reload when shown again; the entry may have changed
+	return m
+}
+
+// renderPane returns exactly h lines of the side pane, each w cells wide,
+// starting with a vertical border.
+func renderPane(m Model, w, h int) []string {
+	var body []string
+	switch m.pane {
+	case paneChart:
+		body = renderChartPane(m, w-2)
+	case panePreview:
+		body = renderPreviewPane(m, w-2, h)
+	}
+	border := rowSepStyle.Render("│") + " "
+	lines := make([]string, h)
+	for i := range lines {
+		s := ""
+		if i < len(body) {
+			s = body[i]
+		}
+		lines[i] = border + padRightVisual(s, w-2)
+	}
+	return lines
+}
+
+// chartTopN is how many slices a composition chart shows before "other".
+const chartTopN = 8
+
+// chartItem is one slice of a composition chart.
+type chartItem struct {
+	name  string
+	size  int64
+	pct   float64
+	isDir bool
+	other bool // aggregate of the remaining entries
+	count int  // entries aggregated into other
+}
+
+// composition returns the n largest of list as chart items plus one "other"
+// item for the rest. Percentages are relative to total.
+func composition(list []entry.FileEntry, total int64, n int) []chartItem {
+	// Partial selection of the n largest; listings may be sorted by any key
+	top := make([]int, 0, n+1)
+	for i := range list {
+		if list[i].Size <= 0 {
+			continue
+		}
+		pos := len(top)
+		for pos > 0 && list[top[pos-1]].Size < list[i].Size {
+			pos--
+		}
+		if pos >= n {
+			continue
+		}
+		top = append(top, 0)
+		copy(top[pos+1:], top[pos:])
+		top[pos] = i
+		if len(top) > n {
+			top = top[:n]
+		}
+	}
+
+	items := make([]chartItem, 0, len(top)+1)
+	var shown int64
+	for _, i := range top {
+		e := list[i]
+		items = append(items, chartItem{name: e.Name, size: e.Size, pct: pctOf(e.Size, total), isDir: e.IsDir})
+		shown += e.Size
+	}
+	if rest := total - shown; rest > 0 {
+		count := 0
+		for i := range list {
+			if list[i].Size > 0 {
+				count++
+			}
+		}
+		items = append(items, chartItem{name: "other", size: rest, pct: pctOf(rest, total), other: true, count: count - len(top)})
+	}
+	return items
+}
+
+func pctOf(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// chartGlyphs alternate between adjacent slices so neighbours sharing a
+// bar level stay distinguishable.
+var chartGlyphs = [2]string{"█", "▓"}
+
+// chartStyle returns the style of a slice: its bar level, dimmed for "other".
+func chartStyle(it chartItem, thresholds []float64) lipgloss.Style {
+	if it.other {
+		return barStyles[barDim]
+	}
+	return barStyles[barLevelOf(it.pct, thresholds)]
+}
+
+// chartGlyph returns the glyph slice i is drawn with. Without colors the
+// slices alternate between dark and light shades and "other" is dotted.
+func chartGlyph(items []chartItem, i int) string {
+	if !monochrome {
+		return chartGlyphs[i%2]
+	}
+	if items[i].other {
+		return "·"
+	}
+	if i%2 == 1 {
+		return barGlyphs[barYellow]
+	}
+	return barGlyphs[barRed]
+}
+
+// renderStackedBar draws items as one horizontal bar of width cells. Slice
+// widths use cumulative rounding so they always add up to the full width.
+// thresholds color the slices as in barLevelOf.
+func renderStackedBar(items []chartItem, width int, thresholds []float64) string {
+	var b strings.Builder
+	var cum float64
+	drawn := 0
+	for i, it := range items {
+		cum += it.pct
+		end := int(cum/100*float64(width) + 0.5)
+		if end > width {
+			end = width
+		}
+		if end > drawn {
+			b.WriteString(chartStyle(it, thresholds).Render(strings.Repeat(chartGlyph(items, i), end-drawn)))
+			drawn = end
+		}
+	}
+	if drawn < width {
+		b.WriteString(strings.Repeat(" ", width-drawn))
+	}
+	return b.String()
+}
+
+// renderChartLegend returns one line per slice: swatch, name, share and size.
+func renderChartLegend(items []chartItem, w int, thresholds []float64) []string {
+	lines := make([]string, 0, len(items))
+	for i, it := range items {
+		name := it.name
+		if it.isDir {
+			name += "/"
+		}
+		if it.other {
+			name = "other (" + strconv.Itoa(it.count) + ")"
+		}
+		pct := padLeft(strconv.FormatFloat(it.pct, 'f', 1, 64)+"%", 6)
+		size := padLeft(formatSize(it.size), 9)
+		nameW := maxInt(4, w-2-1-6-1-9)
+		lines = append(lines, chartStyle(it, thresholds).Render(chartGlyph(items, i))+" "+
+			rowNameStyle.Render(padRightVisual(truncateStrVisual(name, nameW), nameW))+" "+
+			rowPctStyle.Render(pct)+" "+rowDimStyle.Render(size))
+	}
+	return lines
+}
+
+// renderChartPane shows the composition of the current directory and, for a
+// selected directory, its own composition one level further down.
+func renderChartPane(m Model, w int) []string {
+	th := m.settings.BarThresholds
+	lines := []string{helpTitleStyle.Render(truncateStrVisual(shortenPath(m.path), w))}
+	items := composition(m.entries, m.totalSize, chartTopN)
+	bar := renderStackedBar(items, w, th)
+	lines = append(lines, bar, bar, "")
+	lines = append(lines, renderChartLegend(items, w, th)...)
+
+	if m.cursor >= len(m.filtered) {
+		return lines
+	}
+	sel := m.filtered[m.cursor]
+	lines = append(lines, "", helpTitleStyle.Render(truncateStrVisual("▸ "+sel.Name, w)))
+	if !sel.IsDir {
+		lines = append(lines, rowDimStyle.Render(formatSize(sel.Size)+" · "+
+			strconv.FormatFloat(pctOf(sel.Size, m.totalSize), 'f', 1, 64)+"% of directory"))
+		return lines
+	}
+	node := m.selectedNode()
+	if node == nil || node.Children == nil {
+		lines = append(lines, rowDimStyle.Render("(not scanned at this depth)"))
+		return lines
+	}
+	sub := composition(node.Entries(), node.Size, chartTopN/2+1)
+	lines = append(lines, renderStackedBar(sub, w, th), "")
+	lines = append(lines, renderChartLegend(sub, w, th)...)
+	return lines
+}
+
+// selectedNode returns the scanned tree node of the selected entry, if the
+// retained tree reaches it.
+func (m Model) selectedNode() *scan.Node {
+	if m.root == nil || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	return m.root.Find(filepath.ToSlash(m.filtered[m.cursor].Name))
+}
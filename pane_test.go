@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestComposition(t *testing.T) {
+	// Deliberately not in size order: the chart must not rely on the sort
+	list := []entry.FileEntry{
+		{Name: "c", Size: 30},
+		{Name: "a", Size: 100},
+		{Name: "empty", Size: 0},
+		{Name: "d", Size: 10},
+		{Name: "b", Size: 50},
+		{Name: "e", Size: 10},
+	}
+	items := composition(list, 200, 3)
+	want := []string{"a", "b", "c", "other"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i, name := range want {
+		if items[i].name != name {
+			t.Errorf("item %d = %q, want %q", i, items[i].name, name)
+		}
+	}
+	other := items[3]
+	if !other.other || other.size != 20 || other.count != 2 || other.pct != 10 {
+		t.Errorf("other = %+v, want 20 bytes over 2 entries at 10%%", other)
+	}
+
+	// Everything fits: no "other" slice
+	if items := composition(list, 200, 10); items[len(items)-1].other {
+		t.Errorf("unexpected other slice: %+v", items)
+	}
+}
+
+func TestRenderStackedBarWidth(t *testing.T) {
+	items := []chartItem{{pct: 33.3}, {pct: 33.3}, {pct: 33.4}}
+	for _, w := range []int{1, 7, 10, 31} {
+		if got := lipgloss.Width(renderStackedBar(items, w, defaultSettings().BarThresholds)); got != w {
+			t.Errorf("width %d: bar is %d cells", w, got)
+		}
+	}
+	// An empty directory still fills the width
+	if got := lipgloss.Width(renderStackedBar(nil, 12, defaultSettings().BarThresholds)); got != 12 {
+		t.Errorf("empty bar is %d cells, want 12", got)
+	}
+}
+
+func TestPaneHidesOnNarrowTerminal(t *testing.T) {
+	m := makeTestModel(10)
+	if m.paneWidth() != 0 {
+		t.Fatal("pane shown while off")
+	}
+	m = m.togglePane(paneChart)
+	if m.paneWidth() == 0 {
+		t.Fatal("pane hidden at 120 columns")
+	}
+	m.width = minPaneTermWidth - 1
+	if m.paneWidth() != 0 {
+		t.Error("pane shown below the minimum terminal width")
+	}
+	if m = m.togglePane(paneChart); m.pane != paneNone {
+		t.Error("toggling the shown pane should hide it")
+	}
+}
+
+func TestViewWithChartPane(t *testing.T) {
+	m := makeTestModel(12).togglePane(paneChart)
+	lines := strings.Split(m.View(), "\n")
+	if len(lines) < m.height-1 {
+		t.Fatalf("view has %d lines", len(lines))
+	}
+	for i, line := range lines {
+		if w := lipgloss.Width(line); w > m.width {
+			t.Errorf("line %d is %d cells wide, terminal is %d", i, w, m.width)
+		}
+	}
+	// The selected directory has no retained scan tree in the test model
+	if !strings.Contains(m.View(), "not scanned at this depth") {
+		t.Error("selection section missing from the chart pane")
+	}
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image metadata
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/cache"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+// previewKind is what the preview pane shows for the selected entry.
+type previewKind int
+
+const (
+	previewNone    previewKind = iota // nothing to show; note explains why
+	previewText                       // leading lines of a text file
+	previewHex                        // hex dump of a binary's leading bytes
+	previewImage                      // image format and dimensions
+	previewArchive                    // archive members
+	previewDir                        // largest children and type breakdown
+)
+
+const (
+	previewHeadBytes = 64 * 1024 // read from the start of a file for text and hex previews
+	previewMaxLines  = 200       // text lines kept; more than any pane is tall
+	previewHexBytes  = 1024      // bytes kept for the hex dump
+	previewTopN      = 6         // slices in the directory and archive charts
+	previewDirCache  = 64        // directory previews kept for revisits
+)
+
+// preview is the loaded content of the preview pane.
+type preview struct {
+	kind  previewKind
+	note  string      // summary line under the title, or why there is no preview
+	text  []string    // previewText: lines; previewImage: metadata lines
+	data  []byte      // previewHex: leading bytes
+	items []chartItem // previewDir: largest children; previewArchive: largest members
+	types []chartItem // previewDir: size by file extension
+}
+
+// previewState tracks the preview pane's content. It is shared between Model
+// copies so previewCmd can record the request it starts.
+type previewState struct {
+	path    string // full path shown or being loaded
+	loading bool
+	cancel  context.CancelFunc
+	preview
+
+	// dirs holds directory previews by full path, kept across resets since
+	// they walk the whole subtree.
+	dirs *cache.LRU[dirPreviewEntry]
+}
+
+// dirPreviewEntry is a directory preview and the listed size and
+// modification time it was made for; a rescan that changes either
+// invalidates it.
+type dirPreviewEntry struct {
+	size    int64
+	modTime time.Time
+	preview preview
+}
+
+func newPreviewState() *previewState {
+	return &previewState{dirs: cache.New[dirPreviewEntry](previewDirCache)}
+}
+
+// reset cancels a pending load and forgets the shown path.
+func (p *previewState) reset() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	*p = previewState{dirs: p.dirs}
+}
+
+// previewMsg carries a loaded preview.
+type previewMsg struct {
+	path    string
+	sel     entry.FileEntry
+	preview preview
+}
+
+// previewCmd starts loading the selected entry's preview when the preview
+// pane is visible and not already showing it. Update calls it after every
+// message, so any change of selection, directory or pane is picked up.
+func (m Model) previewCmd() tea.Cmd {
+	p := m.preview
+	if p == nil || m.pane != panePreview || m.paneWidth() == 0 || m.cursor >= len(m.filtered) {
+		return nil
+	}
+	sel := m.filtered[m.cursor]
+	full := filepath.Join(m.path, sel.Name)
+	if p.path == full {
+		return nil
+	}
+	p.reset()
+	if sel.IsDir {
+		if c, ok := p.dirs.Get(full); ok && c.size == sel.Size && c.modTime.Equal(sel.ModTime) {
+			p.path, p.preview = full, c.preview
+			return nil
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.path, p.loading, p.cancel = full, true, cancel
+	vfs := m.vfs
+	return func() tea.Msg {
+		pv := loadPreview(ctx, full, sel, vfs)
+		if ctx.Err() != nil {
+			return nil // superseded by a newer selection
+		}
+		return previewMsg{path: full, sel: sel, preview: pv}
+	}
+}
+
+// applyPreview stores a loaded preview if it is still the one wanted.
+func (m Model) applyPreview(msg previewMsg) Model {
+	if p := m.preview; p != nil && p.path == msg.path {
+		p.preview = msg.preview
+		p.loading = false
+		p.cancel = nil
+		if msg.preview.kind == previewDir {
+			p.dirs.Put(msg.path, dirPreviewEntry{size: msg.sel.Size, modTime: msg.sel.ModTime, preview: msg.preview})
+		}
+	}
+	return m
+}
+
+// loadPreview builds the preview of e, found at full. Directories inside a
+// virtual filesystem are summarised through it; file contents there are not
+// readable.
+func loadPreview(ctx context.Context, full string, e entry.FileEntry, vfs *vfsView) preview {
+	inVFS := vfs != nil && vfs.contains(full)
+	if e.IsDir {
+		if inVFS {
+			return dirPreview(ctx, vfs.fsys, vfs.name(full))
+		}
+		return dirPreview(ctx, scan.DirFS(full), ".")
+	}
+	if inVFS {
+		return preview{note: "no preview inside " + filepath.Base(vfs.path)}
+	}
+	if detectArchive(e.Name) != archiveNone {
+		return archivePreview(ctx, full)
+	}
+	if isImageExt(e.Name) {
+		if pv, ok := imagePreview(full); ok {
+			return pv
+		}
+	}
+
+	data, err := scan.Head(full, previewHeadBytes)
+	switch {
+	case err != nil:
+		return preview{note: err.Error()}
+	case len(data) == 0:
+		return preview{note: "empty file"}
+	case scan.IsBinaryContent(data):
+		return preview{kind: previewHex, data: data[:minInt(len(data), previewHexBytes)]}
+	default:
+		return preview{kind: previewText, text: textLines(data, len(data) == previewHeadBytes)}
+	}
+}
+
+// textLines splits data into at most previewMaxLines display lines. If the
+// data was cut off, its last partial line is dropped.
+func textLines(data []byte, truncated bool) []string {
+	s := string(data)
+	if truncated {
+		if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+			s = s[:i]
+		}
+	}
+	s = strings.TrimSuffix(s, "\n")
+	lines := strings.SplitN(s, "\n", previewMaxLines+1)
+	if len(lines) > previewMaxLines {
+		lines = lines[:previewMaxLines]
+	}
+	for i, l := range lines {
+		lines[i] = sanitizeLine(l)
+	}
+	return lines
+}
+
+// sanitizeLine makes a line safe to draw: tabs become spaces, invalid UTF-8
+// and control characters become placeholders so they cannot drive the terminal.
+func sanitizeLine(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	col := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == '\t':
+			n := 4 - col%4
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		case r == '\r':
+			continue
+		case r == utf8.RuneError && size == 1:
+			r = '�'
+		case r < 0x20 || r == 0x7f:
+			r = '·'
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// hexDumpLine formats one dump line: offset, perLine bytes in hex (missing
+// bytes padded) and their printable ASCII.
+func hexDumpLine(offset int64, data []byte, perLine int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08x ", offset)
+	for i := 0; i < perLine; i++ {
+		if i%8 == 0 {
+			b.WriteByte(' ')
+		}
+		if i < len(data) {
+			fmt.Fprintf(&b, "%02x ", data[i])
+		} else {
+			b.WriteString("   ")
+		}
+	}
+	b.WriteByte(' ')
+	for _, c := range data {
+		if c >= 0x20 && c < 0x7f {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// hexBytesPerLine returns how many bytes a dump line of width w can hold:
+// a multiple of 4 between 4 and 16.
+func hexBytesPerLine(w int) int {
+	// offset(9) + group gaps(~2) + 3 per byte hex + 1 per byte ASCII
+	n := (w - 12) / 4
+	n -= n % 4
+	return maxInt(4, minInt(16, n))
+}
+
+// isImageExt reports whether name has an extension whose metadata can be decoded.
+func isImageExt(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// imagePreview decodes only the image header for format and dimensions.
+func imagePreview(full string) (preview, bool) {
+	f, err := os.Open(full)
+	if err != nil {
+		return preview{}, false
+	}
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return preview{}, false
+	}
+	mp := float64(cfg.Width) * float64(cfg.Height) / 1e6
+	return preview{kind: previewImage, text: []string{
+		"format      " + strings.ToUpper(format),
+		"dimensions  " + strconv.Itoa(cfg.Width) + " × " + strconv.Itoa(cfg.Height),
+		"megapixels  " + strconv.FormatFloat(mp, 'f', 1, 64),
+	}}, true
+}
+
+// archivePreview lists an archive's largest members from its headers.
+func archivePreview(ctx context.Context, full string) preview {
+	view, err := readArchive(full, nil)
+	if err != nil {
+		return preview{note: err.Error()}
+	}
+	root, err := scan.Dir(ctx, view.fsys, ".", scan.Options{})
+	if err != nil {
+		return preview{note: err.Error()}
+	}
+	var files []entry.FileEntry
+	root.Walk(func(rel string, n *scan.Node) bool {
+		if !n.IsDir {
+			e := n.FileEntry
+			e.Name = rel
+			files = append(files, e)
+		}
+		return true
+	})
+	note := fmt.Sprintf("%s · %s members · %s unpacked", view.kind, formatCount(len(files)), formatSize(view.size))
+	if r := formatRatio(view.packed, view.size); r != "" {
+		note += " · " + r
+	}
+	return preview{kind: previewArchive, note: note, items: composition(files, root.Size, previewTopN)}
+}
+
+// dirPreview walks a directory's whole subtree for the size of each
+// immediate child and the share of each file extension. Only the totals are
+// kept, not a tree.
+func dirPreview(ctx context.Context, fsys scan.FS, name string) preview {
+	var files, dirs int
+	var total int64
+	children, types := newSizeTally(), newSizeTally()
+	err := fs.WalkDir(fsys, name, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if p == name {
+				return err
+			}
+			return nil // unreadable below the top: counts as empty
+		}
+		if p == name {
+			return nil
+		}
+		rel := p
+		if name != "." {
+			rel = p[len(name)+1:]
+		}
+		top, _, nested := strings.Cut(rel, "/")
+		if d.IsDir() {
+			dirs++
+			if !nested {
+				children.at(top).IsDir = true
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files++
+		total += info.Size()
+		children.at(top).Size += info.Size()
+		ext := strings.ToLower(path.Ext(d.Name()))
+		if ext == "" {
+			ext = "(none)"
+		}
+		types.at(ext).Size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return preview{note: err.Error()}
+	}
+	note := fmt.Sprintf("%s files · %s dirs · %s", formatCount(files), formatCount(dirs), formatSize(total))
+	return preview{
+		kind:  previewDir,
+		note:  note,
+		items: composition(children.list, total, previewTopN),
+		types: composition(types.list, total, previewTopN),
+	}
+}
+
+// sizeTally accumulates sizes under names, in first-seen order.
+type sizeTally struct {
+	index map[string]int
+	list  []entry.FileEntry
+}
+
+func newSizeTally() *sizeTally {
+	return &sizeTally{index: make(map[string]int)}
+}
+
+// at returns the entry for name, adding it if new.
+func (t *sizeTally) at(name string) *entry.FileEntry {
+	i, ok := t.index[name]
+	if !ok {
+		i = len(t.list)
+		t.index[name] = i
+		t.list = append(t.list, entry.FileEntry{Name: name})
+	}
+	return &t.list[i]
+}
+
+// renderPreviewPane draws the selected entry's preview in width w, at most h lines.
+func renderPreviewPane(m Model, w, h int) []string {
+	if m.cursor >= len(m.filtered) {
+		return nil
+	}
+	sel := m.filtered[m.cursor]
+	th := m.settings.BarThresholds
+	name := filepath.Base(sel.Name)
+	if sel.IsDir {
+		name += "/"
+	}
+	lines := []string{helpTitleStyle.Render(truncateStrVisual(name, w))}
+
+	p := m.preview
+	if p == nil || p.path != filepath.Join(m.path, sel.Name) || p.loading {
+		return append(lines, rowDimStyle.Render("loading…"))
+	}
+	if p.note != "" {
+		lines = append(lines, rowDimStyle.Render(truncateStrVisual(p.note, w)))
+	}
+	lines = append(lines, "")
+
+	switch p.kind {
+	case previewText:
+		numW := len(strconv.Itoa(len(p.text)))
+		for i, l := range p.text {
+			if len(lines) >= h {
+				break
+			}
+			lines = append(lines, rowDimStyle.Render(padLeft(strconv.Itoa(i+1), numW))+" "+
+				truncateStrVisual(truncateStr(l, w), w-numW-1))
+		}
+	case previewHex:
+		per := hexBytesPerLine(w)
+		for off := 0; off < len(p.data) && len(lines) < h; off += per {
+			chunk := p.data[off:minInt(off+per, len(p.data))]
+			lines = append(lines, truncateStr(hexDumpLine(int64(off), chunk, per), w))
+		}
+	case previewImage:
+		for _, l := range p.text {
+			lines = append(lines, truncateStr(l, w))
+		}
+	case previewArchive:
+		lines = append(lines, renderStackedBar(p.items, w, th), "")
+		lines = append(lines, renderChartLegend(p.items, w, th)...)
+	case previewDir:
+		lines = append(lines, rowDimStyle.Render("largest"), renderStackedBar(p.items, w, th))
+		lines = append(lines, renderChartLegend(p.items, w, th)...)
+		lines = append(lines, "", rowDimStyle.Render("by type"), renderStackedBar(p.types, w, th))
+		lines = append(lines, renderChartLegend(p.types, w, th)...)
+	}
+	return lines
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestTextLines(t *testing.T) {
+	lines := textLines([]byte("one\n\ttwo\r\nthree\x1b[31m\npart"), true)
+	want := []string{"one", "    two", "three·[31m"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+
+	// Complete files keep their last line and are capped at previewMaxLines
+	long := strings.Repeat("x\n", previewMaxLines+50) + "end"
+	if got := textLines([]byte(long), false); len(got) != previewMaxLines {
+		t.Errorf("got %d lines, want %d", len(got), previewMaxLines)
+	}
+	if got := textLines([]byte("a\nb"), false); len(got) != 2 || got[1] != "b" {
+		t.Errorf("untruncated data lost its last line: %q", got)
+	}
+}
+
+func TestHexDumpLine(t *testing.T) {
+	got := hexDumpLine(16, []byte("AB\x00\xff"), 8)
+	want := "00000010  41 42 00 ff " + strings.Repeat("   ", 4) + " AB.."
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+	for _, w := range []int{30, 48, 80} {
+		per := hexBytesPerLine(w)
+		if per%4 != 0 || per < 4 || per > 16 {
+			t.Errorf("width %d: %d bytes per line", w, per)
+		}
+	}
+}
+
+func TestLoadPreview(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello\nworld\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "blob.bin"), []byte{0x7f, 'E', 'L', 'F', 0, 1, 2}, 0o644)
+	os.WriteFile(filepath.Join(dir, "empty"), nil, 0o644)
+	f, _ := os.Create(filepath.Join(dir, "pic.png"))
+	png.Encode(f, image.NewRGBA(image.Rect(0, 0, 3, 2)))
+	f.Close()
+	os.MkdirAll(filepath.Join(dir, "sub", "deep"), 0o755)
+	os.WriteFile(filepath.Join(dir, "sub", "a.go"), make([]byte, 300), 0o644)
+	os.WriteFile(filepath.Join(dir, "sub", "deep", "b.go"), make([]byte, 100), 0o644)
+	os.WriteFile(filepath.Join(dir, "sub", "deep", "c.md"), make([]byte, 100), 0o644)
+
+	load := func(name string, isDir bool) preview {
+		e := entry.New(name)
+		e.IsDir = isDir
+		return loadPreview(context.Background(), filepath.Join(dir, name), e, nil)
+	}
+
+	if p := load("notes.txt", false); p.kind != previewText || len(p.text) != 2 || p.text[1] != "world" {
+		t.Errorf("text preview: %+v", p)
+	}
+	if p := load("blob.bin", false); p.kind != previewHex || len(p.data) != 7 {
+		t.Errorf("hex preview: %+v", p)
+	}
+	if p := load("empty", false); p.kind != previewNone || p.note != "empty file" {
+		t.Errorf("empty preview: %+v", p)
+	}
+	if p := load("pic.png", false); p.kind != previewImage || !strings.Contains(strings.Join(p.text, "\n"), "3 × 2") {
+		t.Errorf("image preview: %+v", p)
+	}
+
+	p := load("sub", true)
+	if p.kind != previewDir {
+		t.Fatalf("dir preview: %+v", p)
+	}
+	if len(p.items) == 0 || p.items[0].name != "a.go" {
+		t.Errorf("largest children: %+v", p.items)
+	}
+	// .go is 400 of 500 bytes, found below the first level too
+	if len(p.types) < 2 || p.types[0].name != ".go" || p.types[0].size != 400 {
+		t.Errorf("type breakdown: %+v", p.types)
+	}
+}
+
+func TestPreviewPaneFollowsSelection(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("line\n", 100)), 0o644)
+	os.WriteFile(filepath.Join(dir, "small.txt"), []byte("tiny\n"), 0o644)
+
+	m := NewModel(dir)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model)
+
+	// Showing the pane requests the selection's preview
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("showing the preview pane did not load a preview")
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if m.preview.loading || m.preview.kind != previewText || len(m.preview.text) != 100 {
+		t.Fatalf("preview not applied: %+v", m.preview.preview)
+	}
+	if strings.Count(m.View(), " line") < 10 {
+		t.Error("preview lines missing from view")
+	}
+
+	// Moving the cursor requests the next entry
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = next.(Model)
+	if cmd == nil || m.preview.path != filepath.Join(dir, "small.txt") || !m.preview.loading {
+		t.Fatalf("moving did not request the new selection: %+v", m.preview)
+	}
+}
+
+func TestDirPreviewCached(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "big", "deep"), 0o755)
+	os.WriteFile(filepath.Join(dir, "big", "deep", "a.log"), make([]byte, 500), 0o644)
+	os.MkdirAll(filepath.Join(dir, "small"), 0o755)
+	os.WriteFile(filepath.Join(dir, "small", "b.txt"), make([]byte, 10), 0o644)
+
+	m := NewModel(dir)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model)
+	press := func(msg tea.KeyMsg) tea.Cmd {
+		next, cmd := m.Update(msg)
+		m = next.(Model)
+		return cmd
+	}
+
+	cmd := press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if m.preview.kind != previewDir || m.preview.note != "1 files · 1 dirs · 500 B" {
+		t.Fatalf("big/ preview: %+v", m.preview.preview)
+	}
+	if cmd := press(tea.KeyMsg{Type: tea.KeyDown}); cmd == nil {
+		t.Fatal("small/ was not loaded")
+	}
+
+	// Coming back shows the earlier walk without another
+	if cmd := press(tea.KeyMsg{Type: tea.KeyUp}); cmd != nil {
+		t.Error("big/ was walked again")
+	}
+	if m.preview.loading || m.preview.kind != previewDir || m.preview.path != filepath.Join(dir, "big") {
+		t.Errorf("cached preview not shown: %+v", m.preview)
+	}
+
+	// A rescan that changes the size walks it again
+	os.WriteFile(filepath.Join(dir, "big", "c.log"), make([]byte, 100), 0o644)
+	next, cmd = m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model)
+	if cmd == nil || !m.preview.loading {
+		t.Error("changed big/ came from the cache")
+	}
+}
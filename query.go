@@ -0,0 +1,506 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+// A query filters entries with space-separated terms that must all match:
+//
+//	size>100M  size<=1G   apparent size, with >, >=, <, <= or =
+//	age>90d    age<2h     time since last modified (s, m, h, d, w, y)
+//	lines>1000            line count, where known
+//	ext:log,tmp           extension, any of a comma-separated list
+//	name:keep*            shell glob on the name
+//	type:dir              dir, file or link
+//	@big                  a saved filter from the [filters] config table
+//	report                anything else fuzzy-matches the name
+//
+// Any term can be negated with a leading !, and values with spaces can be
+// quoted: name:"old *".
+//
+// A prefix on the whole query instead matches names with one pattern,
+// spaces included:
+//
+//	re:^IMG_\d+\.jpe?g$   Go regular expression
+//	g:*.tar.*              shell glob on the base name
+//	=v1.2                  literal, case-sensitive substring
+type query struct {
+	mode  searchMode
+	terms []queryTerm
+}
+
+// searchMode is how a query matches names.
+type searchMode int
+
+const (
+	searchFuzzy  searchMode = iota // query terms, words fuzzy-match
+	searchRegexp                   // re: Go regular expression
+	searchGlob                     // g: shell glob
+	searchExact                    // = whole name, as typed
+)
+
+// searchModes are the query prefixes, with the names the prompt shows.
+var searchModes = []struct {
+	mode   searchMode
+	prefix string
+	label  string
+}{
+	{searchRegexp, "re:", "regexp"},
+	{searchGlob, "g:", "glob"},
+	{searchExact, "=", "exact"},
+}
+
+// splitSearchMode returns the mode s selects with its prefix, and the rest.
+func splitSearchMode(s string) (searchMode, string) {
+	for _, sm := range searchModes {
+		if rest, ok := strings.CutPrefix(s, sm.prefix); ok {
+			return sm.mode, rest
+		}
+	}
+	return searchFuzzy, s
+}
+
+// modePrompt returns prompt preceded by the mode text selects, if any.
+func modePrompt(text, prompt string) string {
+	if mode, _ := splitSearchMode(text); mode != searchFuzzy {
+		return mode.String() + " " + prompt
+	}
+	return prompt
+}
+
+// String returns the name the prompt shows for mode, "" for fuzzy.
+func (mode searchMode) String() string {
+	for _, sm := range searchModes {
+		if sm.mode == mode {
+			return sm.label
+		}
+	}
+	return ""
+}
+
+// queryTerm is one condition. name is what fuzzy terms match: the entry's
+// name in a listing, its relative path in the recursive search.
+type queryTerm struct {
+	neg   bool
+	match func(name string, e *entry.FileEntry) bool
+	fuzzy string // the pattern of a bare word, for ranking and highlighting
+
+	spans func(name string) []int // rune indexes matched by a pattern, for highlighting
+}
+
+// empty reports whether q matches everything.
+func (q query) empty() bool {
+	return len(q.terms) == 0
+}
+
+// match reports whether e, shown as name, satisfies every term.
+func (q query) match(name string, e *entry.FileEntry) bool {
+	for _, t := range q.terms {
+		if t.match(name, e) == t.neg {
+			return false
+		}
+	}
+	return true
+}
+
+// ranked reports whether q has words to rank matches by.
+func (q query) ranked() bool {
+	for _, t := range q.terms {
+		if t.fuzzy != "" && !t.neg {
+			return true
+		}
+	}
+	return false
+}
+
+// score returns how well name matches the words of q, higher being better.
+func (q query) score(name string) int {
+	total := 0
+	for _, t := range q.terms {
+		if t.fuzzy != "" && !t.neg {
+			s, _ := entry.FuzzyScore(name, t.fuzzy)
+			total += s
+		}
+	}
+	return total
+}
+
+// highlights returns the rune indexes of name matched by the words of q,
+// ascending, possibly with repeats.
+func (q query) highlights(name string) []int {
+	var pos []int
+	for _, t := range q.terms {
+		switch {
+		case t.neg:
+		case t.fuzzy != "":
+			pos = append(pos, entry.FuzzyPositions(name, t.fuzzy)...)
+		case t.spans != nil:
+			pos = append(pos, t.spans(name)...)
+		}
+	}
+	sort.Ints(pos)
+	return pos
+}
+
+// rank sorts entries by how well their names match, best first, keeping
+// the current order among equals.
+func (q query) rank(entries []entry.FileEntry) {
+	scores := make([]int, len(entries))
+	for i := range entries {
+		scores[i] = q.score(entries[i].Name)
+	}
+	sort.Stable(byScore{entries, scores})
+}
+
+// byScore sorts entries and their scores together.
+type byScore struct {
+	entries []entry.FileEntry
+	scores  []int
+}
+
+func (s byScore) Len() int           { return len(s.entries) }
+func (s byScore) Less(i, j int) bool { return s.scores[i] > s.scores[j] }
+func (s byScore) Swap(i, j int) {
+	s.entries[i], s.entries[j] = s.entries[j], s.entries[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
+// matcher returns q as a predicate for entry.FilterFuncInto, or nil if it
+// matches everything.
+func (q query) matcher() func(*entry.FileEntry) bool {
+	if q.empty() {
+		return nil
+	}
+	return func(e *entry.FileEntry) bool { return q.match(e.Name, e) }
+}
+
+// parseQuery parses s. filters holds the saved filters @name refers to, and
+// ages are measured from now.
+func parseQuery(s string, filters map[string]string, now time.Time) (query, error) {
+	return parseQueryDepth(s, filters, now, 0)
+}
+
+func parseQueryDepth(s string, filters map[string]string, now time.Time, depth int) (query, error) {
+	if mode, pattern := splitSearchMode(s); mode != searchFuzzy {
+		return parseModeQuery(mode, pattern)
+	}
+	words, err := splitQuery(s)
+	if err != nil {
+		return query{}, err
+	}
+	var q query
+	for _, w := range words {
+		neg := false
+		if len(w) > 1 && w[0] == '!' {
+			neg, w = true, w[1:]
+		}
+		if name, ok := strings.CutPrefix(w, "@"); ok {
+			saved, ok := filters[name]
+			if !ok {
+				return query{}, fmt.Errorf("no saved filter @%s (see [filters] in the config)", name)
+			}
+			if depth > 8 {
+				return query{}, fmt.Errorf("@%s: saved filters refer to each other in a loop", name)
+			}
+			sub, err := parseQueryDepth(saved, filters, now, depth+1)
+			if err != nil {
+				return query{}, fmt.Errorf("@%s: %w", name, err)
+			}
+			if neg {
+				q.terms = append(q.terms, queryTerm{neg: true, match: sub.match})
+			} else {
+				q.terms = append(q.terms, sub.terms...)
+			}
+			continue
+		}
+		match, err := parseTerm(w, now)
+		if err != nil {
+			return query{}, err
+		}
+		if match == nil {
+			pattern := w
+			q.terms = append(q.terms, queryTerm{neg: neg, fuzzy: pattern, match: func(name string, _ *entry.FileEntry) bool {
+				return entry.FuzzyMatch(name, pattern)
+			}})
+			continue
+		}
+		q.terms = append(q.terms, queryTerm{neg: neg, match: match})
+	}
+	return q, nil
+}
+
+// parseModeQuery parses the pattern after a mode prefix.
+func parseModeQuery(mode searchMode, pattern string) (query, error) {
+	q := query{mode: mode}
+	if pattern == "" {
+		return q, nil
+	}
+	var t queryTerm
+	switch mode {
+	case searchRegexp:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			// Drop the "error parsing regexp: " the footer has no room for
+			var se *syntax.Error
+			if errors.As(err, &se) {
+				return query{}, fmt.Errorf("re: %s in `%s`", se.Code, se.Expr)
+			}
+			return query{}, fmt.Errorf("re: %w", err)
+		}
+		t.match = func(name string, _ *entry.FileEntry) bool { return re.MatchString(name) }
+		t.spans = func(name string) []int { return runeSpans(name, re.FindAllStringIndex(name, -1)) }
+	case searchGlob:
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return query{}, fmt.Errorf("g: bad pattern %q", pattern)
+		}
+		t.match = func(name string, _ *entry.FileEntry) bool {
+			ok, _ := filepath.Match(pattern, filepath.Base(name))
+			return ok
+		}
+	case searchExact:
+		// The base name, so the recursive search finds the file in any directory
+		t.match = func(name string, _ *entry.FileEntry) bool { return filepath.Base(name) == pattern }
+		t.spans = func(name string) []int {
+			if filepath.Base(name) != pattern {
+				return nil
+			}
+			return runeSpans(name, [][]int{{len(name) - len(pattern), len(name)}})
+		}
+	}
+	q.terms = []queryTerm{t}
+	return q, nil
+}
+
+// runeSpans returns the rune indexes of s covered by spans, byte offset
+// pairs in order as regexp returns them.
+func runeSpans(s string, spans [][]int) []int {
+	var pos []int
+	ri := 0
+	for bi := range s {
+		for len(spans) > 0 && bi >= spans[0][1] {
+			spans = spans[1:]
+		}
+		if len(spans) == 0 {
+			break
+		}
+		if bi >= spans[0][0] {
+			pos = append(pos, ri)
+		}
+		ri++
+	}
+	return pos
+}
+
+// queryOps are the comparison operators, longest first so ">=" is not read
+// as ">".
+var queryOps = []string{">=", "<=", ">", "<", "="}
+
+// parseTerm parses one field term without its negation. It returns nil
+// for a bare word.
+func parseTerm(w string, now time.Time) (func(string, *entry.FileEntry) bool, error) {
+	// field<op>value
+	if i := strings.IndexAny(w, "<>="); i > 0 && isFieldName(w[:i]) {
+		field, rest := w[:i], w[i:]
+		var op string
+		for _, o := range queryOps {
+			if strings.HasPrefix(rest, o) {
+				op = o
+				break
+			}
+		}
+		value := rest[len(op):]
+		if value == "" {
+			return nil, fmt.Errorf("%s%s: missing value", field, op)
+		}
+		switch field {
+		case "size":
+			n, err := parseSize(value)
+			if err != nil {
+				return nil, fmt.Errorf("size: %w", err)
+			}
+			return func(_ string, e *entry.FileEntry) bool { return compare(e.Size, op, n) }, nil
+		case "age":
+			d, err := parseAge(value)
+			if err != nil {
+				return nil, fmt.Errorf("age: %w", err)
+			}
+			// An older entry has a larger age, so compare modification
+			// times the other way round
+			cut := now.Add(-d)
+			return func(_ string, e *entry.FileEntry) bool {
+				return !e.ModTime.IsZero() && compare(cut.UnixNano(), op, e.ModTime.UnixNano())
+			}, nil
+		case "lines":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("lines: invalid count %q", value)
+			}
+			return func(_ string, e *entry.FileEntry) bool {
+				return e.LineCount > 0 && compare(int64(e.LineCount), op, int64(n))
+			}, nil
+		}
+		return nil, fmt.Errorf("unknown field %q in %q (size, age, lines)", field, w)
+	}
+
+	// field:value
+	if field, value, ok := strings.Cut(w, ":"); ok && isFieldName(field) {
+		if value == "" {
+			return nil, fmt.Errorf("%s: missing value", field)
+		}
+		switch field {
+		case "ext":
+			exts := make(map[string]bool)
+			for _, x := range strings.Split(value, ",") {
+				exts["."+strings.ToLower(strings.TrimPrefix(x, "."))] = true
+			}
+			return func(_ string, e *entry.FileEntry) bool {
+				return !e.IsDir && exts[strings.ToLower(filepath.Ext(e.Name))]
+			}, nil
+		case "name":
+			if _, err := filepath.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("name: bad pattern %q", value)
+			}
+			return func(_ string, e *entry.FileEntry) bool {
+				ok, _ := filepath.Match(value, filepath.Base(e.Name))
+				return ok
+			}, nil
+		case "type":
+			switch value {
+			case "dir", "d":
+				return func(_ string, e *entry.FileEntry) bool { return e.IsDir }, nil
+			case "file", "f":
+				return func(_ string, e *entry.FileEntry) bool { return !e.IsDir }, nil
+			case "link", "l":
+				return func(_ string, e *entry.FileEntry) bool { return e.IsSymlink }, nil
+			}
+			return nil, fmt.Errorf("type: %q is not dir, file or link", value)
+		}
+		return nil, fmt.Errorf("unknown field %q in %q (ext, name, type)", field, w)
+	}
+
+	return nil, nil
+}
+
+// isFieldName reports whether s can be a field name: lowercase ASCII
+// letters. Other words, such as "IMG:1" or "a_b=c", fuzzy-match names.
+func isFieldName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLower(r) || r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+// compare applies op to a and b.
+func compare(a int64, op string, b int64) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	default:
+		return a == b
+	}
+}
+
+// ageUnits are the units of parseAge.
+var ageUnits = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+// parseAge parses an age such as "90d", "2w", "1.5y" or "30m".
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	unit, ok := ageUnits[s[len(s)-1]]
+	if !ok {
+		return 0, fmt.Errorf("invalid age %q (use a number with s, m, h, d, w or y)", s)
+	}
+	f, err := strconv.ParseFloat(s[:len(s)-1], 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return time.Duration(f * float64(unit)), nil
+}
+
+// splitQuery splits s at spaces, keeping double-quoted runs together and
+// dropping the quotes.
+func splitQuery(s string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord, quoted := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			inWord = true
+		case unicode.IsSpace(r) && !quoted:
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// filterNames returns the names of the saved filters in order.
+func filterNames(filters map[string]string) []string {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseSearch parses the text typed into the search prompt if it changed.
+// A query that does not parse leaves the last one that did in effect, so
+// the listing does not flicker while a term is half typed.
+func (m *Model) parseSearch() {
+	text := m.searchInput.Value()
+	if text == m.queryText {
+		return
+	}
+	m.queryText = text
+	q, err := parseQuery(text, m.config.Filters, time.Now())
+	m.queryErr = err
+	if err == nil {
+		m.query = q
+	}
+}
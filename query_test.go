@@ -0,0 +1,210 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	entries := []entry.FileEntry{
+		{Name: "app.log", Size: 200 << 20, ModTime: now.Add(-100 * day)},
+		{Name: "keep.log", Size: 300 << 20, ModTime: now.Add(-200 * day)},
+		{Name: "scratch.TMP", Size: 5 << 20, ModTime: now.Add(-1 * day)},
+		{Name: "report.txt", Size: 1 << 10, ModTime: now.Add(-400 * day), LineCount: 40},
+		{Name: "old photos", Size: 2 << 30, ModTime: now.Add(-30 * day), IsDir: true},
+		{Name: "latest", ModTime: now, IsSymlink: true},
+	}
+	filters := map[string]string{"junk": "ext:log,tmp", "huge": "size>1G"}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "app.log keep.log scratch.TMP report.txt old photos latest"},
+		{"size>100M age>90d ext:log,tmp !name:keep*", "app.log"},
+		{"size>=300M", "keep.log old photos"},
+		{"size<1K", "latest"},
+		{"size=1K", "report.txt"},
+		{"age<2d", "scratch.TMP latest"},
+		{"age>1y", "report.txt"},
+		{"ext:.TMP", "scratch.TMP"},
+		{"type:dir", "old photos"},
+		{"!type:dir type:f", "app.log keep.log scratch.TMP report.txt latest"},
+		{"type:link", "latest"},
+		{"lines>10", "report.txt"},
+		{`name:"old *"`, "old photos"},
+		{"rpt", "report.txt"},
+		{"@junk !@huge size>100M", "app.log keep.log"},
+		{"!@junk !type:d", "report.txt latest"},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query, filters, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, e := range entry.FilterFuncInto(nil, entries, true, entry.FilterAll, q.matcher()) {
+			got = append(got, e.Name)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.query, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	filters := map[string]string{"loop": "@loop"}
+	tests := []struct{ query, want string }{
+		{"size>lots", `size: invalid size "lots"`},
+		{"size>", "size>: missing value"},
+		{"age>3q", `age: invalid age "3q"`},
+		{"colour:red", `unknown field "colour"`},
+		{"type:pipe", `type: "pipe" is not dir, file or link`},
+		{"name:[a", "name: bad pattern"},
+		{`name:"a b`, "unterminated quote"},
+		{"@nope", "no saved filter @nope"},
+		{"@loop", "in a loop"},
+	}
+	for _, tt := range tests {
+		_, err := parseQuery(tt.query, filters, time.Now())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestSearchKeepsLastValidQuery(t *testing.T) {
+	m := NewModel("/x")
+	m.entries = []entry.FileEntry{{Name: "a", Size: 10}, {Name: "b", Size: 1000}}
+	m.searchInput.SetValue("size>100")
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.queryErr != nil {
+		t.Fatalf("size>100: %v %v", m.filtered, m.queryErr)
+	}
+	// Half typed: the error is shown and the previous filter stays
+	m.searchInput.SetValue("size>100 age>")
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.queryErr == nil {
+		t.Errorf("size>100 age>: %v %v", m.filtered, m.queryErr)
+	}
+	m.searchInput.SetValue("")
+	m.applyFilter()
+	if len(m.filtered) != 2 || m.queryErr != nil {
+		t.Errorf("cleared: %v %v", m.filtered, m.queryErr)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	m := NewModel("/x")
+	m.entries = []entry.FileEntry{
+		{Name: "domain.go", Size: 300},
+		{Name: "m_a_i_n.txt", Size: 200},
+		{Name: "main.go", Size: 100},
+	}
+	m.searchInput.SetValue("main")
+	m.applyFilter()
+	if m.filtered[0].Name != "domain.go" {
+		t.Fatalf("size order: got %s first", m.filtered[0].Name)
+	}
+	// Tab in the prompt ranks by match quality
+	m.searchMode = true
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = next.(Model)
+	if !m.rankSearch || m.filtered[0].Name != "main.go" {
+		t.Errorf("ranked: got %s first", m.filtered[0].Name)
+	}
+	// Negated and field terms do not rank
+	m.searchInput.SetValue("!main size>0")
+	m.applyFilter()
+	if m.query.ranked() {
+		t.Error("query without words is ranked")
+	}
+}
+
+func TestHighlightSeg(t *testing.T) {
+	label := "fuzzy_test.go"
+	hits := entry.FuzzyPositions(label, "test")
+	got := highlightSeg(padRightVisual(label, 15), label, hits, rowNameStyle, false)
+	want := rowNameStyle.Render("fuzzy_") + matchStyle.Render("test") + rowNameStyle.Render(".go  ")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Hits past the truncation are dropped rather than marking the ellipsis
+	got = highlightSeg(truncateStrVisual(label, 8), label, hits, rowNameStyle, false)
+	want = rowNameStyle.Render("fuzzy_") + matchStyle.Render("t") + rowNameStyle.Render("…")
+	if got != want {
+		t.Errorf("truncated: got %q, want %q", got, want)
+	}
+}
+
+func TestSearchModes(t *testing.T) {
+	names := []string{"IMG_0042.jpg", "img_7.jpeg", "backup.tar.gz", "v1.2 notes.txt", "v142.txt"}
+	tests := []struct {
+		query string
+		mode  searchMode
+		want  string
+	}{
+		{`re:^IMG_\d+\.jpe?g$`, searchRegexp, "IMG_0042.jpg"},
+		{`re:(?i)^img`, searchRegexp, "IMG_0042.jpg img_7.jpeg"},
+		{"g:*.tar.*", searchGlob, "backup.tar.gz"},
+		{"g:v1?2*", searchGlob, "v1.2 notes.txt v142.txt"},
+		{"=v1.2 notes.txt", searchExact, "v1.2 notes.txt"},
+		{"=IMG", searchExact, ""},
+		{"=img_7.JPEG", searchExact, ""},
+		{"v12", searchFuzzy, "v1.2 notes.txt v142.txt"},
+		{"re:", searchRegexp, strings.Join(names, " ")},
+	}
+	for _, tt := range tests {
+		q, err := parseQuery(tt.query, nil, time.Now())
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, name := range names {
+			if q.match(name, &entry.FileEntry{Name: name}) {
+				got = append(got, name)
+			}
+		}
+		if q.mode != tt.mode || strings.Join(got, " ") != tt.want {
+			t.Errorf("%q: mode %v, got %q, want %v %q", tt.query, q.mode, got, tt.mode, tt.want)
+		}
+	}
+
+	for query, want := range map[string]string{
+		"re:(abc": "re: missing closing ) in `(abc`",
+		"g:[a":    `g: bad pattern "[a"`,
+	} {
+		if _, err := parseQuery(query, nil, time.Now()); err == nil || err.Error() != want {
+			t.Errorf("%q: got %v, want %q", query, err, want)
+		}
+	}
+
+	// Matched characters, as rune indexes
+	for query, want := range map[string][]int{
+		`re:\d+`:  {1, 2, 5},
+		"=é12 é3": {0, 1, 2, 3, 4, 5},
+		"=é1":     nil,
+		"g:*":     nil,
+	} {
+		q, _ := parseQuery(query, nil, time.Now())
+		if got := q.highlights("é12 é3"); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q highlights %v, want %v", query, got, want)
+		}
+	}
+
+	if got := modePrompt("re:x", "/ "); got != "regexp / " {
+		t.Errorf("prompt %q", got)
+	}
+	if got := modePrompt("x", "/ "); got != "/ " {
+		t.Errorf("fuzzy prompt %q", got)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build darwin
+
+package main
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile creates dst sharing src's data blocks with clonefile(2), which
+// APFS supports.
+func cloneFile(src, dst string) error {
+	err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EXDEV) {
+		return errNoReflink
+	}
+	return err
+}
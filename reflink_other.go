@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package main
+
+// cloneFile is not available on this platform.
+func cloneFile(src, dst string) error {
+	return errNoReflink
+}
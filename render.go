@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mohsinkaleem/dirgo/entry"
 )
 
 // buildStatsLine assembles the stats/badges portion of the header (right-hand side).
@@ -30,15 +32,40 @@ func buildStatsLine(m Model) string {
 	div := headerDivider.String()
 
 	statsLine := div + total + div + files + div + dirs
+	if m.vfs != nil {
+		statsLine += div + headerBadgeStyle.Render(strings.ToUpper(m.vfs.kind))
+		if m.vfs.packed > 0 {
+			packed := formatSize(m.vfs.packed) + " packed"
+			if r := formatRatio(m.vfs.packed, m.vfs.size); r != "" {
+				packed += " (" + r + ")"
+			}
+			statsLine += " " + headerStatStyle.Render(packed)
+		}
+	}
+	if m.sortMode != entry.DefaultSort {
+		statsLine += div + headerBadgeStyle.Render("SORT "+strings.ToUpper(m.sortMode.String()))
+	}
+	if m.tree != nil {
+		statsLine += div + headerBadgeStyle.Render("TREE")
+	}
+	if m.treemap {
+		statsLine += div + headerBadgeStyle.Render("MAP")
+	}
 	if m.topMode {
-		statsLine += div + headerBadgeStyle.Render("TOP 10")
+		statsLine += div + headerBadgeStyle.Render("TOP "+strconv.Itoa(m.settings.TopN))
 	}
 	switch m.viewFilter {
-	case FilterDirsOnly:
+	case entry.FilterDirsOnly:
 		statsLine += div + headerBadgeStyle.Render("DIRS")
-	case FilterFilesOnly:
+	case entry.FilterFilesOnly:
 		statsLine += div + headerBadgeStyle.Render("FILES")
 	}
+	if len(m.marked) > 0 {
+		statsLine += div + headerBadgeStyle.Render(strconv.Itoa(len(m.marked))+" MARKED")
+	}
+	if m.running > 0 {
+		statsLine += div + headerBadgeStyle.Render(strconv.Itoa(m.running)+" RUNNING")
+	}
 	if m.showHidden {
 		statsLine += div + headerBadgeStyle.Render("HIDDEN")
 	}
@@ -85,14 +112,45 @@ func renderHeader(m Model) string {
 	return headerStyle.Width(m.width).Render(path + "\n" + statsLine)
 }
 
-// styledSeg renders text with a style, optionally with a selection background applied too.
+// styledSeg renders text with a style, optionally with the selection
+// background (reverse video in monochrome themes) applied too.
 func styledSeg(text string, base lipgloss.Style, selected bool) string {
 	if selected {
-		return base.Background(colorSelBg).Render(text)
+		return base.Inherit(rowSelBgStyle).Render(text)
 	}
 	return base.Render(text)
 }
 
+// highlightSeg is styledSeg for a name with the runes at hits, indexes into
+// label, in matchStyle. name is label truncated and padded, so hits past
+// the truncation are not shown.
+func highlightSeg(name, label string, hits []int, base lipgloss.Style, selected bool) string {
+	if len(hits) == 0 {
+		return styledSeg(name, base, selected)
+	}
+	nr, lr := []rune(name), []rune(label)
+	hit := make([]bool, len(nr))
+	for _, i := range hits {
+		if i < len(nr) && i < len(lr) && nr[i] == lr[i] {
+			hit[i] = true
+		}
+	}
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(nr); i++ {
+		if i < len(nr) && hit[i] == hit[start] {
+			continue
+		}
+		if hit[start] {
+			b.WriteString(matchStyle.Render(string(nr[start:i])))
+		} else {
+			b.WriteString(styledSeg(string(nr[start:i]), base, selected))
+		}
+		start = i
+	}
+	return b.String()
+}
+
 // formatCount returns a compact number string: 999 → "999", 1234 → "1.2k", 1200000 → "1.2M".
 func formatCount(n int) string {
 	switch {
@@ -106,24 +164,16 @@ func formatCount(n int) string {
 }
 
 // renderRow renders a single file entry row.
-func renderRow(m Model, index int, entry FileEntry, selected bool) string {
+func renderRow(m Model, index int, e entry.FileEntry, selected bool) string {
 	w := m.width
 	if w < 40 {
 		w = 40
 	}
 
-	// Fixed-width meta column (always 6 chars wide), only for text files: "1.2k l"
-	const metaWidth = 6
-	rawMeta := ""
-	if !entry.IsDir && entry.LineCount > 0 {
-		rawMeta = formatCount(entry.LineCount) + " l"
-	}
-	rawMeta = padLeft(truncateStr(rawMeta, metaWidth), metaWidth)
-
-	// Fixed cols outside bar+name:
-	// pointer(2) + num(4) + sp(1) + bar(var) + sp(1) + pct(6) + sp(1) + sep(1) + sp(1) + icon(2) + name(var) + sp(1) + sz(9) + sp(1) + meta(6)
-	// = 2+4+1+1+6+1+1+1+2+1+9+1+6 = 36 fixed, plus bar and name.
-	const fixedNonBar = 36
+	// Optional columns right of the name, narrowed to what fits.
+	var colBuf [numColumns]columnID
+	cols, colsWidth := fitColumns(colBuf[:0], m, w)
+	fixedNonBar := rowFixedWidth + colsWidth
 	barMaxWidth := maxInt(6, minInt(28, (w-fixedNonBar-16)/3))
 	nameMaxWidth := maxInt(8, w-fixedNonBar-barMaxWidth)
 
@@ -132,6 +182,9 @@ func renderRow(m Model, index int, entry FileEntry, selected bool) string {
 	if selected {
 		pointer = "▶ "
 	}
+	if len(m.marked) > 0 && m.marked[filepath.Join(m.path, e.Name)] {
+		pointer = pointer[:len(pointer)-1] + "•"
+	}
 	var pointerSt lipgloss.Style
 	if selected {
 		pointerSt = rowPointerActiveStyle
@@ -143,27 +196,50 @@ func renderRow(m Model, index int, entry FileEntry, selected bool) string {
 	numStr := padLeft(strconv.Itoa(index+1)+".", 4)
 
 	// Bar
-	bc := barColor(entry.Percentage)
-	barStr := barString(entry.Percentage, barMaxWidth)
+	level := barLevelOf(e.Percentage, m.settings.BarThresholds)
+	barStr := barString(e.Percentage, barMaxWidth, level)
 
 	// Percentage — use strconv to avoid fmt.Sprintf allocation
-	pctStr := padLeft(strconv.FormatFloat(entry.Percentage, 'f', 1, 64)+"%", 6)
+	pctStr := padLeft(strconv.FormatFloat(e.Percentage, 'f', 1, 64)+"%", 6)
 
 	// Icon: directory ▸, symlink →, file space
 	iconChar := "  "
-	if entry.IsDir && entry.IsSymlink {
+	if e.IsDir && e.IsSymlink {
 		iconChar = "⇢ "
-	} else if entry.IsSymlink {
+	} else if e.IsSymlink {
 		iconChar = "→ "
-	} else if entry.IsDir {
+	} else if e.IsDir {
 		iconChar = "▸ "
 	}
 
+	// Tree view: guides before the icon, base name only, ▾ on expanded dirs
+	label := e.Name
+	guides := ""
+	nameWidth := nameMaxWidth
+	if m.tree != nil && index < len(m.tree.rows) {
+		guides = m.tree.rows[index].prefix
+		label = filepath.Base(e.Name)
+		if e.IsDir && m.tree.expanded[e.Name] {
+			iconChar = "▾ "
+		}
+		// Guides are single-width runes; very deep rows keep only the innermost levels
+		g := []rune(guides)
+		if keep := nameMaxWidth - 4; len(g) > keep {
+			g = g[len(g)-keep:]
+			guides = string(g)
+		}
+		nameWidth -= len(g)
+	}
+
 	// Name (truncated + padded to fixed width, visual-width aware for emoji/wide chars)
-	name := truncateStrVisual(entry.Name, nameMaxWidth)
-	name = padRightVisual(name, nameMaxWidth)
+	name := truncateStrVisual(label, nameWidth)
+	name = padRightVisual(name, nameWidth)
 
-	szStr := padLeft(formatSize(entry.Size), 9)
+	// Characters matched by the search words
+	var hits []int
+	if m.searchInput.Value() != "" && (m.tree == nil || index >= len(m.tree.rows) || m.tree.rows[index].depth == 0) {
+		hits = m.query.highlights(label)
+	}
 
 	// Select name style based on selection state
 	var nameSt lipgloss.Style
@@ -173,8 +249,8 @@ func renderRow(m Model, index int, entry FileEntry, selected bool) string {
 		nameSt = rowNameStyle
 	}
 
-	// Use pre-defined bar color style to avoid per-row allocation
-	barSt := barStyles[bc]
+	// Use pre-defined bar level style to avoid per-row allocation
+	barSt := barStyles[level]
 
 	// Assemble — apply selection background to each segment individually
 	// so inner ANSI resets don't clobber the row background.
@@ -185,10 +261,18 @@ func renderRow(m Model, index int, entry FileEntry, selected bool) string {
 		styledSeg(barStr, barSt, selected) + " " +
 		styledSeg(pctStr, rowPctStyle, selected) + " " +
 		styledSeg("│", rowSepStyle, selected) + " " +
+		styledSeg(guides, rowSepStyle, selected) +
 		styledSeg(iconChar, rowIconStyle, selected) +
-		styledSeg(name, nameSt, selected) + " " +
-		styledSeg(szStr, rowDimStyle, selected) + " " +
-		styledSeg(rawMeta, rowMetaStyle, selected)
+		highlightSeg(name, label, hits, nameSt, selected)
+	for _, id := range cols {
+		cw := id.widthIn(m)
+		text := padLeft(truncateStr(columns[id].render(m, e), cw), cw)
+		st := rowDimStyle
+		if id != colSize {
+			st = rowMetaStyle
+		}
+		parts += " " + styledSeg(text, st, selected)
+	}
 
 	// Pad row to full width and apply selection background to fill.
 	visualW := lipgloss.Width(parts)
@@ -206,45 +290,77 @@ func renderRow(m Model, index int, entry FileEntry, selected bool) string {
 // renderFooter renders the bottom keybinding bar.
 func renderFooter(m Model) string {
 	if m.searchMode {
-		return searchPromptStyle.Render(" / ") + m.searchInput.View() +
-			footerDescStyle.Render("  ↑↓ nav  ⏎ apply  esc cancel")
+		s := searchPromptStyle.Render(" "+modePrompt(m.searchInput.Value(), "/ ")) + m.searchInput.View()
+		if m.queryErr != nil {
+			return s + errorStyle.Render("  "+m.queryErr.Error())
+		}
+		order := "tab best match"
+		if m.rankSearch {
+			order = "tab size order"
+		}
+		return s + footerDescStyle.Render("  ↑↓ nav  "+order+"  ⏎ apply  esc cancel")
 	}
 
 	if m.gotoMode {
-		return searchPromptStyle.Render(" cd ") + m.gotoInput.View()
+		return renderGotoFooter(m)
 	}
 
-	keys := []struct {
-		key  string
-		desc string
-	}{
-		{"↑↓", "nav"},
-		{"←", "back"},
-		{"→⏎", "open"},
-		{"␣", "qlook"},
-		{"r", "refresh"},
-		{"t", "top10"},
-		{"o", "open"},
-		{"/", "search"},
-		{"c", "cd"},
-		{"h", "hidden"},
-		{"f", "filter"},
-		{"d", "trash"},
-		{"s", "lines"},
-		{"x", "hex"},
-		{"?", "help"},
-		{"q", "quit"},
-	}
-
-	// Use strings.Builder instead of slice+Join to reduce allocations
+	switch m.bookmarkPrompt {
+	case bookmarkSet:
+		return searchPromptStyle.Render(" bookmark as ") + footerDescStyle.Render("letter or digit  esc cancel")
+	case bookmarkJump:
+		keys := bookmarkKeys(m.bookmarks)
+		if len(keys) == 0 {
+			return searchPromptStyle.Render(" go to bookmark ") + footerDescStyle.Render("none yet — m then a letter sets one  esc cancel")
+		}
+		return searchPromptStyle.Render(" go to bookmark ") + footerKeyStyle.Render(strings.Join(keys, " ")) + footerDescStyle.Render("  esc cancel")
+	}
+
+	if m.compress != nil {
+		return renderCompressFooter(m)
+	}
+
+	if m.treemap {
+		notice := actionNotice(m)
+		if notice != "" {
+			notice += "  "
+		}
+		return footerStyle.Width(m.width).Render(
+			notice + footerKeyStyle.Render(shortLabel(m.keys.Left)+shortLabel(m.keys.Up)+shortLabel(m.keys.Down)+shortLabel(m.keys.Right)) + " " + footerDescStyle.Render("move") + "  " +
+				footerKeyStyle.Render("⏎") + " " + footerDescStyle.Render("zoom in") + "  " +
+				footerKeyStyle.Render("BS") + " " + footerDescStyle.Render("zoom out") + "  " +
+				footerKeyStyle.Render(m.keys.closeLabel(m.keys.Treemap)) + " " + footerDescStyle.Render("list") + "  " +
+				footerKeyStyle.Render(shortLabel(m.keys.Quit)) + " " + footerDescStyle.Render("quit"))
+	}
+
+	// Footer entries come from the live key map; adjacent bindings with the
+	// same label share an entry
 	var b strings.Builder
-	for i, k := range keys {
-		if i > 0 {
+	b.WriteString(actionNotice(m))
+	if m.pendingKeys != "" {
+		if b.Len() > 0 {
 			b.WriteString("  ")
 		}
-		b.WriteString(footerKeyStyle.Render(k.key))
+		b.WriteString(footerKeyStyle.Render(keyLabel(m.pendingKeys) + "…"))
+	}
+	km := m.keys
+	named := km.named()
+	for i := 0; i < len(named); i++ {
+		nb := named[i]
+		if nb.footer == "" || len(nb.b.Keys()) == 0 {
+			continue
+		}
+		label := shortLabel(*nb.b)
+		for i+1 < len(named) && named[i+1].footer == nb.footer {
+			i++
+			label += shortLabel(*named[i].b)
+		}
+		if b.Len() > 0 {
+			b.WriteString("  ")
+		}
+		b.WriteString(footerKeyStyle.Render(label))
 		b.WriteString(" ")
-		b.WriteString(footerDescStyle.Render(k.desc))
+		b.WriteString(footerDescStyle.Render(nb.footer))
 	}
 
 	return footerStyle.Width(m.width).Render(b.String())
@@ -252,34 +368,22 @@ func renderFooter(m Model) string {
 
 // renderHelp renders the help overlay.
 func renderHelp(m Model) string {
-	bindings := []struct {
+	type helpLine struct {
 		key  string
 		desc string
-	}{
-		{"↑ / k", "Move cursor up"},
-		{"↓ / j", "Move cursor down"},
-		{"Scroll", "Mouse wheel up/down"},
-		{"PgUp / ^U", "Page up"},
-		{"PgDn / ^D", "Page down"},
-		{"← / BS", "Go to parent (remembers position)"},
-		{"→ / l / Enter", "Open dir / open file with default app"},
-		{"Space", "Quick Look / preview"},
-		{"g", "Jump to top of list"},
-		{"G", "Jump to bottom of list"},
-		{"r", "Refresh (smart — skips if unchanged)"},
-		{"t", "Toggle top 10 view"},
-		{"o", "Open in file manager"},
-		{"/", "Search / filter files"},
-		{"↑↓ in /", "Navigate filtered results"},
-		{"Esc", "Cancel search / close help"},
-		{"c", "Go to directory (cd)"},
-		{"h", "Toggle hidden files (on by default)"},
-		{"f", "Cycle filter: all → dirs → files"},
-		{"d", "Move selected entry to Trash"},
-		{"s", "Count lines for all files"},
-		{"x", "Hex dump file (xxd/hexdump + pager)"},
-		{"?", "Show this help"},
-		{"q / Ctrl+C", "Quit"},
+	}
+	var bindings []helpLine
+	km := m.keys
+	for _, nb := range km.named() {
+		if len(nb.b.Keys()) > 0 {
+			bindings = append(bindings, helpLine{nb.b.Help().Key, nb.b.Help().Desc})
+		}
+	}
+	bindings = append(bindings,
+		helpLine{"Scroll", "Mouse wheel up/down"},
+		helpLine{shortLabel(km.Up) + shortLabel(km.Down) + " in /", "Navigate filtered results"})
+	for _, a := range m.actions {
+		bindings = append(bindings, helpLine{keyLabel(a.Key), a.Label})
 	}
 
 	var b strings.Builder
@@ -295,7 +399,7 @@ func renderHelp(m Model) string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(footerStyle.Render("  Press Esc or ? to close"))
+	b.WriteString(footerStyle.Render("  Press " + shortLabel(m.keys.Escape) + " or " + shortLabel(m.keys.Help) + " to close"))
 	b.WriteString("\n")
 
 	boxStyle := lipgloss.NewStyle().
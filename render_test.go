@@ -5,14 +5,16 @@ import (
 	"testing"
 
 	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/mohsinkaleem/dirgo/entry"
 )
 
 func makeTestModel(entryCount int) Model {
-	entries := make([]FileEntry, entryCount)
+	entries := make([]entry.FileEntry, entryCount)
 	var totalSize int64
 	for i := range entries {
 		sz := int64((entryCount - i) * 1024)
-		entries[i] = FileEntry{
+		entries[i] = entry.FileEntry{
 			Name:       "file_" + padLeft(string(rune('a'+i%26)), 2) + ".go",
 			Size:       sz,
 			Percentage: float64(sz) / float64(entryCount*1024) * 100,
@@ -39,6 +41,8 @@ func makeTestModel(entryCount int) Model {
 		path:        "/home/user/project",
 		viewBuf:     &strings.Builder{},
 		searchInput: textinput.New(),
+		columns:     defaultColumns,
+		settings:    defaultSettings(),
 	}
 }
 
@@ -86,16 +90,16 @@ func BenchmarkModelView(b *testing.B) {
 }
 
 func BenchmarkApplyFilter(b *testing.B) {
-	entries := make([]FileEntry, 10000)
+	entries := make([]entry.FileEntry, 10000)
 	for i := range entries {
-		entries[i] = FileEntry{
+		entries[i] = entry.FileEntry{
 			Name: "file_" + string(rune('a'+i%26)) + "_test.go",
 			Size: int64(i * 100),
 		}
 	}
 	m := Model{
 		entries:     entries,
-		filtered:    make([]FileEntry, 0, len(entries)),
+		filtered:    make([]entry.FileEntry, 0, len(entries)),
 		searchInput: textinput.New(),
 	}
 	b.ResetTimer()
@@ -105,16 +109,16 @@ func BenchmarkApplyFilter(b *testing.B) {
 }
 
 func BenchmarkApplyFilterWithSearch(b *testing.B) {
-	entries := make([]FileEntry, 10000)
+	entries := make([]entry.FileEntry, 10000)
 	for i := range entries {
-		entries[i] = FileEntry{
+		entries[i] = entry.FileEntry{
 			Name: "file_" + string(rune('a'+i%26)) + "_test.go",
 			Size: int64(i * 100),
 		}
 	}
 	m := Model{
 		entries:     entries,
-		filtered:    make([]FileEntry, 0, len(entries)),
+		filtered:    make([]entry.FileEntry, 0, len(entries)),
 		searchInput: textinput.New(),
 	}
 	m.searchInput.SetValue("test")
@@ -0,0 +1,27 @@
+package scan_test
+
+import (
+	"context"
+	"fmt"
+	"testing/fstest"
+
+	"github.com/mohsinkaleem/dirgo/scan"
+)
+
+func ExampleDir() {
+	fsys := fstest.MapFS{
+		"logs/app.log": {Data: make([]byte, 300)},
+		"logs/old.log": {Data: make([]byte, 100)},
+		"README.md":    {Data: make([]byte, 100)},
+	}
+	root, err := scan.Dir(context.Background(), fsys, ".", scan.Options{})
+	if err != nil {
+		panic(err)
+	}
+	for _, c := range root.Children {
+		fmt.Printf("%s %d %.0f%%\n", c.Name, c.Size, c.Percentage)
+	}
+	// Output:
+	// logs 400 80%
+	// README.md 100 20%
+}
@@ -0,0 +1,88 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS is the filesystem abstraction the scanner runs on. Names are
+// slash-separated io/fs paths relative to the filesystem root ("." is the
+// root itself), so the same scanner can walk the OS filesystem, an archive,
+// an imported dump or an in-memory fixture such as fstest.MapFS.
+type FS interface {
+	fs.ReadDirFS
+	fs.StatFS
+}
+
+// ExtStat holds attributes that fs.FileInfo does not expose portably.
+type ExtStat struct {
+	DiskSize int64  // allocated bytes (compressed bytes for archive members); 0 if unknown
+	Dev      uint64 // device holding Inode; 0 if unknown
+	Inode    uint64 // unique within Dev; 0 if unknown
+	Nlink    uint64 // 0 if unknown
+	UID      int    // owning user id; -1 if unknown
+	GID      int    // owning group id; -1 if unknown
+}
+
+// noExtStat is the ExtStat of a file nothing extra is known about.
+var noExtStat = ExtStat{UID: -1, GID: -1}
+
+// ExtStatFS is implemented by filesystems that can report ExtStat. info is the
+// FileInfo already obtained for name, so implementations can avoid a second stat.
+type ExtStatFS interface {
+	ExtStat(name string, info fs.FileInfo) ExtStat
+}
+
+// StatExt returns the extended stat for name if fsys implements ExtStatFS,
+// and an ExtStat with unknown fields otherwise.
+func StatExt(fsys fs.FS, name string, info fs.FileInfo) ExtStat {
+	if x, ok := fsys.(ExtStatFS); ok {
+		return x.ExtStat(name, info)
+	}
+	return noExtStat
+}
+
+// DirFS returns an FS rooted at the OS directory dir. Unlike os.DirFS it
+// also implements ExtStatFS using the platform stat structure.
+func DirFS(dir string) FS {
+	return osFS(dir)
+}
+
+// osFS is the OS implementation of FS returned by DirFS.
+type osFS string
+
+func (root osFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(string(root), filepath.FromSlash(name)), nil
+}
+
+func (root osFS) Open(name string) (fs.File, error) {
+	p, err := root.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (root osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := root.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(p)
+}
+
+func (root osFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := root.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (root osFS) ExtStat(_ string, info fs.FileInfo) ExtStat {
+	return sysExtStat(info)
+}
@@ -0,0 +1,89 @@
+package scan
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// bufPool reuses read buffers for line counting to avoid per-call allocations.
+var bufPool = sync.Pool{
+	New: func() interface{} { return make([]byte, 64*1024) },
+}
+
+// IsBinaryContent checks whether a byte slice looks like binary data.
+// Only checks the first 512 bytes (sufficient for detection, matches HTTP sniffing).
+func IsBinaryContent(data []byte) bool {
+	check := data
+	if len(check) > 512 {
+		check = check[:512]
+	}
+	return bytes.IndexByte(check, 0) >= 0
+}
+
+// CountLines counts newlines in the file at path using chunked reads + bytes.Count.
+// Files larger than maxSize are skipped and report 0 lines.
+// Returns (lineCount, isBinary, error).
+// The first read chunk doubles as the binary check — no seek needed.
+func CountLines(path string, maxSize int64) (int, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() == 0 {
+		return 0, false, err
+	}
+	if info.Size() > maxSize {
+		return 0, false, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	buf := bufPool.Get().([]byte)
+	defer bufPool.Put(buf)
+
+	// First chunk: binary check + start counting
+	n, err := f.Read(buf)
+	if n > 0 && IsBinaryContent(buf[:n]) {
+		return 0, true, nil
+	}
+
+	count := 0
+	if n > 0 {
+		count = bytes.Count(buf[:n], []byte{'\n'})
+	}
+	totalRead := int64(n)
+
+	for totalRead < maxSize && err == nil {
+		n, err = f.Read(buf)
+		if n > 0 {
+			count += bytes.Count(buf[:n], []byte{'\n'})
+			totalRead += int64(n)
+		}
+	}
+	return count, false, nil
+}
+
+// Head returns a copy of up to max bytes from the start of the file at path,
+// read through the same pooled buffer as CountLines. max is capped at the
+// buffer size (64 KiB); a short file returns what it has.
+func Head(path string, max int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := bufPool.Get().([]byte)
+	defer bufPool.Put(buf)
+	if max > len(buf) {
+		max = len(buf)
+	}
+	n, err := io.ReadFull(f, buf[:max])
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return append([]byte(nil), buf[:n]...), err
+}
@@ -0,0 +1,121 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCountLines(t *testing.T) {
+	dir := t.TempDir()
+
+	// Text file with 5 lines
+	textFile := filepath.Join(dir, "text.txt")
+	os.WriteFile(textFile, []byte("a\nb\nc\nd\ne\n"), 0o644)
+	lines, isBin, err := CountLines(textFile, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isBin {
+		t.Error("text file detected as binary")
+	}
+	if lines != 5 {
+		t.Errorf("expected 5 lines, got %d", lines)
+	}
+
+	// Binary file
+	binFile := filepath.Join(dir, "binary.bin")
+	data := make([]byte, 100)
+	data[50] = 0 // null byte
+	os.WriteFile(binFile, data, 0o644)
+	lines, isBin, _ = CountLines(binFile, 1024*1024)
+	if !isBin {
+		t.Error("binary file not detected")
+	}
+	if lines != 0 {
+		t.Errorf("expected 0 lines for binary, got %d", lines)
+	}
+
+	// Empty file
+	emptyFile := filepath.Join(dir, "empty.txt")
+	os.WriteFile(emptyFile, []byte{}, 0o644)
+	lines, isBin, _ = CountLines(emptyFile, 1024*1024)
+	if isBin {
+		t.Error("empty file detected as binary")
+	}
+	if lines != 0 {
+		t.Errorf("expected 0 lines for empty file, got %d", lines)
+	}
+
+	// File over maxSize should return 0
+	bigFile := filepath.Join(dir, "big.txt")
+	os.WriteFile(bigFile, []byte(strings.Repeat("line\n", 100)), 0o644)
+	lines, _, _ = CountLines(bigFile, 10) // maxSize = 10 bytes
+	if lines != 0 {
+		t.Errorf("expected 0 for oversized file, got %d", lines)
+	}
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	if IsBinaryContent([]byte("hello world")) {
+		t.Error("text should not be binary")
+	}
+	if !IsBinaryContent([]byte{0x48, 0x65, 0x00, 0x6c}) {
+		t.Error("data with null byte should be binary")
+	}
+}
+
+// --- Benchmarks ---
+
+func BenchmarkCountLines(b *testing.B) {
+	dir := b.TempDir()
+	// Create a ~1MB text file
+	data := strings.Repeat("this is a line of text for benchmarking\n", 25000)
+	path := filepath.Join(dir, "bench.txt")
+	os.WriteFile(path, []byte(data), 0o644)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CountLines(path, 10*1024*1024)
+	}
+}
+
+func BenchmarkCountLinesBinary(b *testing.B) {
+	dir := b.TempDir()
+	data := make([]byte, 1024)
+	data[100] = 0 // null byte triggers early exit
+	path := filepath.Join(dir, "binary.bin")
+	os.WriteFile(path, data, 0o644)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CountLines(path, 10*1024*1024)
+	}
+}
+
+func TestHead(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "big.txt")
+	content := strings.Repeat("0123456789", 10000) // 100 KB, larger than the buffer
+	os.WriteFile(file, []byte(content), 0o644)
+
+	got, err := Head(file, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content[:16] {
+		t.Errorf("Head(16) = %q", got)
+	}
+	// Capped at the pooled buffer size
+	if got, _ := Head(file, 1<<20); len(got) != 64*1024 {
+		t.Errorf("Head(1 MiB) returned %d bytes, want 64 KiB", len(got))
+	}
+
+	short := filepath.Join(dir, "short.txt")
+	os.WriteFile(short, []byte("hi\n"), 0o644)
+	if got, err := Head(short, 100); err != nil || string(got) != "hi\n" {
+		t.Errorf("Head(short) = %q, %v", got, err)
+	}
+	if _, err := Head(filepath.Join(dir, "missing"), 10); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
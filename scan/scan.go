@@ -0,0 +1,357 @@
+// Package scan computes disk usage trees over any FS: the OS filesystem via
+// DirFS, or any fs.FS that also implements fs.ReadDirFS and fs.StatFS.
+//
+// A scan lists a directory, computes the recursive size and file/dir counts
+// of every entry, and returns the result as a tree of Nodes sorted by size:
+//
+//	root, err := scan.Dir(ctx, scan.DirFS("/var/lib"), ".", scan.Options{})
+//	for _, child := range root.Children {
+//		fmt.Println(child.Name, child.Size, child.Percentage)
+//	}
+package scan
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+// Progress holds live counters updated while a scan runs.
+// Safe to read via atomic loads from another goroutine.
+type Progress struct {
+	Files atomic.Int64
+	Dirs  atomic.Int64
+	Size  atomic.Int64
+}
+
+// Options configures Dir.
+type Options struct {
+	// Progress, if non-nil, is updated as files and directories are visited.
+	Progress *Progress
+
+	// Depth limits how many levels of descendants are kept in the returned
+	// tree. Sizes and counts are always computed over the whole subtree.
+	// 0 keeps everything; 1 keeps only the root's immediate entries.
+	Depth int
+
+	// Concurrency bounds how many subdirectories of the root are scanned in
+	// parallel. 0 uses runtime.NumCPU(), capped at 16.
+	Concurrency int
+}
+
+// Node is one file or directory in a scanned tree. The embedded FileEntry
+// carries sizes and counts aggregated over the node's whole subtree, and
+// Percentage is the node's share of its parent's size.
+type Node struct {
+	entry.FileEntry
+
+	// Children are the directory's entries sorted by size descending.
+	// Nil for files and for directories below Options.Depth.
+	Children []*Node
+}
+
+// ErrNotDir is returned by Dir when name is not a directory.
+var ErrNotDir = errors.New("not a directory")
+
+// Dir scans the directory name inside fsys and returns it as a tree.
+// Immediate subdirectories are scanned in parallel with bounded concurrency;
+// unreadable subdirectories contribute zero size rather than failing the scan.
+// If ctx is cancelled, Dir stops early and returns ctx.Err().
+func Dir(ctx context.Context, fsys FS, name string, opts Options) (*Node, error) {
+	// Stat the directory itself for modtime
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "scan", Path: name, Err: ErrNotDir}
+	}
+	dirEntries, err := fsys.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &scanner{ctx: ctx, fsys: fsys, prog: opts.Progress}
+	keep := -1 // unlimited
+	if opts.Depth > 0 {
+		keep = opts.Depth
+	}
+
+	root := &Node{FileEntry: entry.New(path.Base(name))}
+	root.IsDir = true
+	s.stat(&root.FileEntry, name, info)
+	root.Children = make([]*Node, 0, len(dirEntries))
+
+	// Separate dirs (including symlinks to dirs) from files
+	var dirs []*Node
+	var files []fs.DirEntry
+	for _, de := range dirEntries {
+		if !de.IsDir() && de.Type()&fs.ModeSymlink == 0 {
+			files = append(files, de)
+			continue
+		}
+		childName := de.Name()
+		isSymlink := de.Type()&fs.ModeSymlink != 0
+		isDir := de.IsDir()
+		if isSymlink && !isDir {
+			if target, err := fsys.Stat(path.Join(name, childName)); err == nil && target.IsDir() {
+				isDir = true
+			}
+		}
+		if !isDir {
+			files = append(files, de)
+			continue
+		}
+		n := &Node{FileEntry: entry.New(childName)}
+		n.IsDir = true
+		n.IsSymlink = isSymlink
+		if fi, err := de.Info(); err == nil {
+			s.stat(&n.FileEntry, path.Join(name, childName), fi)
+		}
+		dirs = append(dirs, n)
+		root.Children = append(root.Children, n)
+	}
+
+	// Compute directory sizes in parallel
+	if len(dirs) > 0 {
+		limit := opts.Concurrency
+		if limit <= 0 {
+			limit = min(runtime.NumCPU(), 16)
+		}
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, limit)
+		for _, n := range dirs {
+			wg.Add(1)
+			go func(n *Node) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				// Use ReadDir + manual recursion instead of fs.WalkDir
+				// to reduce syscall overhead (one getdirentries per dir vs Lstat per entry)
+				s.walk(path.Join(name, n.Name), n, keep-1)
+			}(n)
+		}
+		wg.Wait()
+	}
+
+	// Stat files — parallel if large directory
+	fileNodes := make([]*Node, len(files))
+	if len(files) > 20 {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, runtime.NumCPU())
+		for i, de := range files {
+			wg.Add(1)
+			go func(i int, d fs.DirEntry) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				fileNodes[i] = &Node{FileEntry: s.fileEntry(name, d)}
+			}(i, de)
+		}
+		wg.Wait()
+	} else {
+		for i, de := range files {
+			fileNodes[i] = &Node{FileEntry: s.fileEntry(name, de)}
+		}
+	}
+	root.Children = append(root.Children, fileNodes...)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, c := range root.Children {
+		root.add(c)
+	}
+	finish(root)
+	return root, nil
+}
+
+// File returns the entry for the single non-directory name inside fsys,
+// e.g. to refresh one row after the file was written.
+func File(fsys FS, name string) (entry.FileEntry, error) {
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return entry.FileEntry{}, err
+	}
+	if info.IsDir() {
+		return entry.FileEntry{}, &fs.PathError{Op: "scan", Path: name, Err: errors.New("is a directory")}
+	}
+	s := &scanner{ctx: context.Background(), fsys: fsys}
+	return s.fileEntry(path.Dir(name), fs.FileInfoToDirEntry(info)), nil
+}
+
+// scanner holds the state shared by one Dir call's goroutines.
+type scanner struct {
+	ctx  context.Context
+	fsys FS
+	prog *Progress
+}
+
+// walk fills in n (a directory named name) by recursing through its subtree.
+// keep is the number of descendant levels to attach as Children; negative
+// means unlimited. Only files are stat'ed, since only file sizes are needed.
+func (s *scanner) walk(name string, n *Node, keep int) {
+	entries, err := s.fsys.ReadDir(name)
+	if err != nil {
+		return
+	}
+	if keep != 0 {
+		n.Children = make([]*Node, 0, len(entries))
+	}
+	for _, e := range entries {
+		if s.ctx.Err() != nil {
+			return
+		}
+		childName := e.Name()
+		var child *Node
+		if e.IsDir() {
+			if s.prog != nil {
+				s.prog.Dirs.Add(1)
+			}
+			child = &Node{FileEntry: entry.New(childName)}
+			child.IsDir = true
+			if keep != 0 {
+				if fi, err := e.Info(); err == nil {
+					s.stat(&child.FileEntry, path.Join(name, childName), fi)
+				}
+			}
+			s.walk(path.Join(name, childName), child, keep-1)
+		} else {
+			child = &Node{FileEntry: s.fileEntry(name, e)}
+		}
+		n.add(child)
+		if keep != 0 {
+			n.Children = append(n.Children, child)
+		}
+	}
+	if keep != 0 {
+		finish(n)
+	}
+}
+
+// fileEntry builds the FileEntry for a non-directory entry of dir and
+// reports it to the progress counters.
+func (s *scanner) fileEntry(dir string, d fs.DirEntry) entry.FileEntry {
+	name := d.Name()
+	e := entry.New(name)
+	e.IsBinary = entry.IsBinaryExt(name)
+	e.IsSymlink = d.Type()&fs.ModeSymlink != 0
+	info, err := d.Info()
+	if err == nil {
+		e.Size = info.Size()
+		e.DiskSize = s.stat(&e, path.Join(dir, name), info).DiskSize
+		if s.prog != nil {
+			s.prog.Files.Add(1)
+			s.prog.Size.Add(e.Size)
+		}
+	}
+	return e
+}
+
+// stat fills e's modification time, mode and ownership from info, the
+// FileInfo of name, and returns the extended stat it read.
+func (s *scanner) stat(e *entry.FileEntry, name string, info fs.FileInfo) ExtStat {
+	x := StatExt(s.fsys, name, info)
+	e.ModTime = info.ModTime()
+	e.Mode = info.Mode()
+	e.UID, e.GID = x.UID, x.GID
+	return x
+}
+
+// add folds child's totals into n.
+func (n *Node) add(child *Node) {
+	n.Size += child.Size
+	n.DiskSize += child.DiskSize
+	if child.IsDir {
+		n.ChildDirs += 1 + child.ChildDirs
+		n.ChildFiles += child.ChildFiles
+	} else {
+		n.ChildFiles++
+	}
+}
+
+// finish computes children's percentages and sorts them by size descending.
+func finish(n *Node) {
+	for _, c := range n.Children {
+		if n.Size > 0 {
+			c.Percentage = float64(c.Size) / float64(n.Size) * 100
+		}
+	}
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		return n.Children[i].Size > n.Children[j].Size
+	})
+}
+
+// Entries returns copies of n's children as FileEntry values, in order.
+func (n *Node) Entries() []entry.FileEntry {
+	out := make([]entry.FileEntry, len(n.Children))
+	for i, c := range n.Children {
+		out[i] = c.FileEntry
+	}
+	return out
+}
+
+// Counts returns the number of immediate file and directory children.
+func (n *Node) Counts() (files, dirs int) {
+	for _, c := range n.Children {
+		if c.IsDir {
+			dirs++
+		} else {
+			files++
+		}
+	}
+	return files, dirs
+}
+
+// Child returns the immediate child with the given name, or nil.
+func (n *Node) Child(name string) *Node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find returns the descendant at the slash-separated path rel, or nil if it
+// is not in the tree. "" and "." return n itself.
+func (n *Node) Find(rel string) *Node {
+	if rel == "" || rel == "." {
+		return n
+	}
+	cur := n
+	for _, part := range strings.Split(rel, "/") {
+		if cur = cur.Child(part); cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// Walk calls fn for n and each retained descendant in depth-first, size
+// order. rel is the slash-separated path relative to n ("" for n itself).
+// If fn returns false for a directory, its children are skipped.
+func (n *Node) Walk(fn func(rel string, node *Node) bool) {
+	n.walk("", fn)
+}
+
+func (n *Node) walk(rel string, fn func(string, *Node) bool) {
+	if !fn(rel, n) {
+		return
+	}
+	for _, c := range n.Children {
+		childRel := c.Name
+		if rel != "" {
+			childRel = rel + "/" + c.Name
+		}
+		c.walk(childRel, fn)
+	}
+}
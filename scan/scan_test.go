@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a.txt":           {Data: []byte("12345")},
+		".hidden":         {Data: []byte("1")},
+		"src/main.go":     {Data: []byte("package main\n")},
+		"src/lib/util.go": {Data: []byte("package lib\n")},
+		"empty":           {Mode: fs.ModeDir},
+	}
+}
+
+func TestDirMapFS(t *testing.T) {
+	prog := &Progress{}
+	root, err := Dir(context.Background(), testFS(), ".", Options{Progress: prog})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Size != 31 || root.ChildFiles != 4 || root.ChildDirs != 3 {
+		t.Errorf("unexpected root totals: %+v", root.FileEntry)
+	}
+	if files, dirs := root.Counts(); files != 2 || dirs != 2 {
+		t.Errorf("expected 2 files / 2 dirs, got %d / %d", files, dirs)
+	}
+	src := root.Children[0]
+	if src.Name != "src" || src.Size != 25 || src.ChildFiles != 2 || src.ChildDirs != 1 {
+		t.Errorf("unexpected first child: %+v", src.FileEntry)
+	}
+	if got := prog.Files.Load(); got != 4 {
+		t.Errorf("expected progress to count 4 files, got %d", got)
+	}
+
+	util := root.Find("src/lib/util.go")
+	if util == nil || util.Size != 12 || util.Percentage != 100 {
+		t.Errorf("unexpected src/lib/util.go node: %+v", util)
+	}
+	if root.Find("src/missing") != nil {
+		t.Error("expected nil for missing path")
+	}
+
+	var visited []string
+	root.Walk(func(rel string, n *Node) bool {
+		visited = append(visited, rel)
+		return n.Name != "lib"
+	})
+	want := []string{"", "src", "src/main.go", "src/lib", "a.txt", ".hidden", "empty"}
+	if len(visited) != len(want) {
+		t.Fatalf("walk visited %v, want %v", visited, want)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("walk visited %v, want %v", visited, want)
+			break
+		}
+	}
+}
+
+func TestDirDepth(t *testing.T) {
+	root, err := Dir(context.Background(), testFS(), ".", Options{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := root.Child("src")
+	if src == nil || src.Children != nil {
+		t.Fatalf("expected src without retained children, got %+v", src)
+	}
+	// Totals still cover the whole subtree
+	if src.Size != 25 || src.ChildFiles != 2 {
+		t.Errorf("unexpected src totals: %+v", src.FileEntry)
+	}
+}
+
+func TestDirErrors(t *testing.T) {
+	fsys := testFS()
+	if _, err := Dir(context.Background(), fsys, "missing", Options{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := Dir(context.Background(), fsys, "a.txt", Options{}); !errors.Is(err, ErrNotDir) {
+		t.Errorf("expected ErrNotDir, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Dir(ctx, fsys, ".", Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDirFSRejectsInvalidPaths(t *testing.T) {
+	fsys := DirFS(t.TempDir())
+	if _, err := fsys.ReadDir("../etc"); err == nil {
+		t.Error("expected error for path escaping the root")
+	}
+	if _, err := fsys.Stat("."); err != nil {
+		t.Errorf("root stat failed: %v", err)
+	}
+}
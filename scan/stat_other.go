@@ -0,0 +1,10 @@
+//go:build !unix
+
+package scan
+
+import "io/fs"
+
+// sysExtStat is a no-op on platforms without a Unix stat structure.
+func sysExtStat(info fs.FileInfo) ExtStat {
+	return noExtStat
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package scan
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// sysExtStat extracts block usage, device and inode, link count and
+// ownership from a Unix stat.
+func sysExtStat(info fs.FileInfo) ExtStat {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return noExtStat
+	}
+	return ExtStat{
+		DiskSize: int64(st.Blocks) * 512,
+		Dev:      uint64(st.Dev),
+		Inode:    uint64(st.Ino),
+		Nlink:    uint64(st.Nlink),
+		UID:      int(st.Uid),
+		GID:      int(st.Gid),
+	}
+}
@@ -1,15 +1,16 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+	"github.com/mohsinkaleem/dirgo/scan"
 )
 
 // --- Message types ---
@@ -17,7 +18,8 @@ import (
 // scanResultMsg is sent when directory scanning completes.
 type scanResultMsg struct {
 	path       string
-	entries    []FileEntry
+	root       *scan.Node // scanned directory; entries are copies of its children
+	entries    []entry.FileEntry
 	totalSize  int64
 	totalFiles int
 	totalDirs  int
@@ -45,223 +47,81 @@ type scanUpToDateMsg struct {
 	path string
 }
 
-// ScanProgress holds live progress counters updated by the scanner goroutine.
-// Read via atomic loads from the UI goroutine (spinner tick).
-type ScanProgress struct {
-	Files atomic.Int64
-	Dirs  atomic.Int64
-	Size  atomic.Int64
-}
-
 // --- Commands ---
 
-// scanDirectory performs a full directory listing with sizes computed upfront.
-// Directory sizes are computed in parallel using bounded concurrency.
+// scanDirectory performs a full listing of an OS directory with sizes computed upfront.
 // If prog is non-nil, progress counters are updated as the scan proceeds.
-func scanDirectory(path string, prog *ScanProgress) tea.Cmd {
+func scanDirectory(ctx context.Context, path string, prog *scan.Progress) tea.Cmd {
 	return func() tea.Msg {
 		absPath, err := filepath.Abs(path)
 		if err != nil {
 			return scanErrorMsg{err: err}
 		}
+		return scanFS(ctx, scan.DirFS(absPath), ".", absPath, prog)
+	}
+}
 
-		// Stat the directory itself for modtime
-		dirInfo, err := os.Stat(absPath)
-		if err != nil {
-			return scanErrorMsg{err: err}
-		}
-		dirModTime := dirInfo.ModTime()
-
-		dirEntries, err := os.ReadDir(absPath)
-		if err != nil {
-			return scanErrorMsg{err: err}
-		}
-
-		entries := make([]FileEntry, 0, len(dirEntries))
-		var totalSize int64
-		var totalFiles, totalDirs int
-
-		// Separate dirs and files
-		type dirInfo2 struct {
-			index int
-			name  string
-		}
-		fileEntries := make([]os.DirEntry, 0, len(dirEntries))
-		dirEntryIndices := make([]dirInfo2, 0, len(dirEntries)/4+1)
-
-		for _, de := range dirEntries {
-			if de.IsDir() || de.Type()&os.ModeSymlink != 0 {
-				name := de.Name()
-				isHidden := strings.HasPrefix(name, ".")
-				isSymlink := de.Type()&os.ModeSymlink != 0
-				isDir := de.IsDir()
-
-				if isSymlink && !isDir {
-					target, err := os.Stat(filepath.Join(absPath, name))
-					if err == nil && target.IsDir() {
-						isDir = true
-					}
-				}
-
-				if isDir {
-					totalDirs++
-					var modTime time.Time
-					info, err := de.Info()
-					if err == nil {
-						modTime = info.ModTime()
-					}
-					idx := len(entries)
-					entries = append(entries, FileEntry{
-						Name:      name,
-						IsDir:     true,
-						IsHidden:  isHidden,
-						IsSymlink: isSymlink,
-						ModTime:   modTime,
-					})
-					dirEntryIndices = append(dirEntryIndices, dirInfo2{index: idx, name: name})
-				} else {
-					fileEntries = append(fileEntries, de)
-				}
-			} else {
-				fileEntries = append(fileEntries, de)
-			}
-		}
-
-		// Compute directory sizes in parallel
-		if len(dirEntryIndices) > 0 {
-			type dirResult struct {
-				index      int
-				size       int64
-				childFiles int
-				childDirs  int
-			}
-			results := make([]dirResult, len(dirEntryIndices))
-			var wg sync.WaitGroup
-			sem := make(chan struct{}, minInt(runtime.NumCPU(), 16))
-
-			for ri, di := range dirEntryIndices {
-				wg.Add(1)
-				go func(resultIdx int, info dirInfo2) {
-					defer wg.Done()
-					sem <- struct{}{}
-					defer func() { <-sem }()
-
-					dirPath := filepath.Join(absPath, info.name)
-					// Use os.ReadDir + manual recursion instead of filepath.WalkDir
-					// to reduce syscall overhead (one getdirentries per dir vs Lstat per entry)
-					size, files, dirs := dirSizeRecursive(dirPath, prog)
-					results[resultIdx] = dirResult{index: info.index, size: size, childFiles: files, childDirs: dirs}
-				}(ri, di)
-			}
-			wg.Wait()
-
-			// Apply results back to entries
-			for _, r := range results {
-				entries[r.index].Size = r.size
-				entries[r.index].ChildFiles = r.childFiles
-				entries[r.index].ChildDirs = r.childDirs
-				totalSize += r.size
-			}
-		}
-
-		// Stat files — parallel if large directory
-		if len(fileEntries) > 20 {
-			results := make([]FileEntry, len(fileEntries))
-			var wg sync.WaitGroup
-			sem := make(chan struct{}, runtime.NumCPU())
-			for idx, de := range fileEntries {
-				wg.Add(1)
-				go func(i int, d os.DirEntry) {
-					defer wg.Done()
-					sem <- struct{}{}
-					defer func() { <-sem }()
-					name := d.Name()
-					e := FileEntry{
-						Name:      name,
-						IsHidden:  strings.HasPrefix(name, "."),
-						IsBinary:  isBinaryExt(name),
-						IsSymlink: d.Type()&os.ModeSymlink != 0,
-					}
-					info, err := d.Info()
-					if err == nil {
-						e.Size = info.Size()
-						e.ModTime = info.ModTime()
-					}
-					results[i] = e
-				}(idx, de)
-			}
-			wg.Wait()
-			for _, e := range results {
-				totalFiles++
-				totalSize += e.Size
-				entries = append(entries, e)
-			}
-		} else {
-			for _, de := range fileEntries {
-				totalFiles++
-				name := de.Name()
-				e := FileEntry{
-					Name:      name,
-					IsHidden:  strings.HasPrefix(name, "."),
-					IsBinary:  isBinaryExt(name),
-					IsSymlink: de.Type()&os.ModeSymlink != 0,
-				}
-				info, err := de.Info()
-				if err == nil {
-					e.Size = info.Size()
-					e.ModTime = info.ModTime()
-				}
-				totalSize += e.Size
-				entries = append(entries, e)
-			}
-		}
-
-		// Compute final percentages
-		if totalSize > 0 {
-			for i := range entries {
-				entries[i].Percentage = float64(entries[i].Size) / float64(totalSize) * 100
-			}
-		}
+// scanFSCmd lists dir inside fsys, reporting the result under displayPath.
+func scanFSCmd(ctx context.Context, fsys scan.FS, dir, displayPath string, prog *scan.Progress) tea.Cmd {
+	return func() tea.Msg {
+		return scanFS(ctx, fsys, dir, displayPath, prog)
+	}
+}
 
-		SortBySize(entries)
+// scanFS scans dir inside fsys and returns a scanResultMsg whose path is
+// displayPath, or a scanErrorMsg. Cancelling ctx stops the scan.
+func scanFS(ctx context.Context, fsys scan.FS, dir, displayPath string, prog *scan.Progress) tea.Msg {
+	// Keep grandchildren for the chart pane and instant tree expansion;
+	// deeper levels are scanned on demand.
+	root, err := scan.Dir(ctx, fsys, dir, scan.Options{Progress: prog, Depth: 2})
+	if err != nil {
+		return scanErrorMsg{err: err}
+	}
+	return newScanResult(displayPath, root)
+}
 
-		return scanResultMsg{
-			path:       absPath,
-			entries:    entries,
-			totalSize:  totalSize,
-			totalFiles: totalFiles,
-			totalDirs:  totalDirs,
-			dirModTime: dirModTime,
-		}
+// newScanResult builds the listing message for the directory node root,
+// displayed at path.
+func newScanResult(path string, root *scan.Node) scanResultMsg {
+	files, dirs := root.Counts()
+	return scanResultMsg{
+		path:       path,
+		root:       root,
+		entries:    root.Entries(),
+		totalSize:  root.Size,
+		totalFiles: files,
+		totalDirs:  dirs,
+		dirModTime: root.ModTime,
 	}
 }
 
 // smartRefreshCmd checks if a directory has changed before triggering a full rescan.
-func smartRefreshCmd(path string, cached scanResultMsg, prog *ScanProgress) tea.Cmd {
+func smartRefreshCmd(ctx context.Context, path string, cached scanResultMsg, prog *scan.Progress) tea.Cmd {
 	return func() tea.Msg {
 		info, err := os.Stat(path)
 		if err != nil {
-			return scanDirectory(path, prog)() // fallback to full scan
+			return scanDirectory(ctx, path, prog)() // fallback to full scan
 		}
 		if info.ModTime().Equal(cached.dirModTime) {
 			return scanUpToDateMsg{path: path}
 		}
-		return scanDirectory(path, prog)() // directory modified, full rescan
+		return scanDirectory(ctx, path, prog)() // directory modified, full rescan
 	}
 }
 
-// countLinesCmd returns a tea.Cmd to count lines for a specific file.
-func countLinesCmd(dir, name string) tea.Cmd {
+// countLinesCmd returns a tea.Cmd to count lines for a specific file. Files
+// larger than max, the line_count_max setting, are not counted.
+func countLinesCmd(dir, name string, max int64) tea.Cmd {
 	return func() tea.Msg {
 		path := filepath.Join(dir, name)
-		lines, _, _ := countLines(path, 10*1024*1024) // 10MB max
+		lines, _, _ := scan.CountLines(path, max)
 		return lineCountMsg{name: name, lines: lines}
 	}
 }
 
 // countAllLinesCmd returns a tea.Cmd that counts lines for all non-binary,
-// non-directory entries. Uses bounded concurrency.
-func countAllLinesCmd(entries []FileEntry, dir string) tea.Cmd {
+// non-directory entries no larger than max. Uses bounded concurrency.
+func countAllLinesCmd(entries []entry.FileEntry, dir string, max int64) tea.Cmd {
 	return func() tea.Msg {
 		counts := make(map[string]int)
 		var mu sync.Mutex
@@ -278,7 +138,7 @@ func countAllLinesCmd(entries []FileEntry, dir string) tea.Cmd {
 				sem <- struct{}{}
 				defer func() { <-sem }()
 				path := filepath.Join(dir, name)
-				lines, isBin, _ := countLines(path, 10*1024*1024)
+				lines, isBin, _ := scan.CountLines(path, max)
 				if !isBin && lines > 0 {
 					mu.Lock()
 					counts[name] = lines
@@ -291,36 +151,3 @@ func countAllLinesCmd(entries []FileEntry, dir string) tea.Cmd {
 		return batchLineCountMsg{Counts: counts}
 	}
 }
-
-// dirSizeRecursive computes the total size, file count, and subdirectory count
-// of a directory using os.ReadDir + manual recursion. This is more efficient than
-// filepath.WalkDir because os.ReadDir uses a single getdirentries syscall per
-// directory, and we only call Info() on files (not dirs) since we only need file sizes.
-func dirSizeRecursive(path string, prog *ScanProgress) (size int64, files int, dirs int) {
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return 0, 0, 0
-	}
-	for _, e := range entries {
-		if e.IsDir() {
-			dirs++
-			if prog != nil {
-				prog.Dirs.Add(1)
-			}
-			s, f, d := dirSizeRecursive(filepath.Join(path, e.Name()), prog)
-			size += s
-			files += f
-			dirs += d
-		} else {
-			files++
-			if info, err := e.Info(); err == nil {
-				size += info.Size()
-				if prog != nil {
-					prog.Files.Add(1)
-					prog.Size.Add(info.Size())
-				}
-			}
-		}
-	}
-	return size, files, dirs
-}
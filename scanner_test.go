@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
 )
 
 func makeTempDir(t testing.TB, files, subdirs, subFiles int) string {
@@ -42,7 +49,7 @@ func makeDeepDir(t testing.TB, depth, filesPerLevel int) string {
 
 func TestScanDirectory(t *testing.T) {
 	dir := makeTempDir(t, 5, 3, 2)
-	cmd := scanDirectory(dir, nil)
+	cmd := scanDirectory(context.Background(), dir, nil)
 	msg := cmd()
 
 	result, ok := msg.(scanResultMsg)
@@ -68,7 +75,7 @@ func TestScanDirectory(t *testing.T) {
 
 func TestDirSizeComputed(t *testing.T) {
 	dir := makeTempDir(t, 0, 1, 10)
-	cmd := scanDirectory(dir, nil)
+	cmd := scanDirectory(context.Background(), dir, nil)
 	msg := cmd()
 
 	result, ok := msg.(scanResultMsg)
@@ -97,9 +104,9 @@ func TestDirSizeComputed(t *testing.T) {
 
 func TestSmartRefreshUnchanged(t *testing.T) {
 	dir := makeTempDir(t, 3, 0, 0)
-	scanMsg := scanDirectory(dir, nil)().(scanResultMsg)
+	scanMsg := scanDirectory(context.Background(), dir, nil)().(scanResultMsg)
 
-	cmd := smartRefreshCmd(dir, scanMsg, nil)
+	cmd := smartRefreshCmd(context.Background(), dir, scanMsg, nil)
 	msg := cmd()
 
 	if _, ok := msg.(scanUpToDateMsg); !ok {
@@ -109,11 +116,11 @@ func TestSmartRefreshUnchanged(t *testing.T) {
 
 func TestSmartRefreshChanged(t *testing.T) {
 	dir := makeTempDir(t, 3, 0, 0)
-	scanMsg := scanDirectory(dir, nil)().(scanResultMsg)
+	scanMsg := scanDirectory(context.Background(), dir, nil)().(scanResultMsg)
 
 	os.WriteFile(filepath.Join(dir, "new_file.txt"), []byte("new"), 0o644)
 
-	cmd := smartRefreshCmd(dir, scanMsg, nil)
+	cmd := smartRefreshCmd(context.Background(), dir, scanMsg, nil)
 	msg := cmd()
 
 	if _, ok := msg.(scanResultMsg); !ok {
@@ -123,14 +130,14 @@ func TestSmartRefreshChanged(t *testing.T) {
 
 func TestCountAllLines(t *testing.T) {
 	dir := makeTempDir(t, 5, 0, 0)
-	entries := make([]FileEntry, 5)
+	entries := make([]entry.FileEntry, 5)
 	for i := 0; i < 5; i++ {
-		entries[i] = FileEntry{
+		entries[i] = entry.FileEntry{
 			Name: fmt.Sprintf("file_%04d.txt", i),
 		}
 	}
 
-	cmd := countAllLinesCmd(entries, dir)
+	cmd := countAllLinesCmd(entries, dir, 10<<20)
 	msg := cmd()
 
 	result, ok := msg.(batchLineCountMsg)
@@ -151,7 +158,7 @@ func BenchmarkScanDirectory(b *testing.B) {
 	dir := makeTempDir(b, 100, 10, 5)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		scanDirectory(dir, nil)()
+		scanDirectory(context.Background(), dir, nil)()
 	}
 }
 
@@ -159,7 +166,7 @@ func BenchmarkScanLargeDir(b *testing.B) {
 	dir := makeTempDir(b, 1000, 0, 0)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		scanDirectory(dir, nil)()
+		scanDirectory(context.Background(), dir, nil)()
 	}
 }
 
@@ -167,6 +174,68 @@ func BenchmarkScanDeepDir(b *testing.B) {
 	dir := makeDeepDir(b, 5, 10)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		scanDirectory(dir, nil)()
+		scanDirectory(context.Background(), dir, nil)()
+	}
+}
+
+func TestScanFSMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":           {Data: []byte("12345")},
+		".hidden":         {Data: []byte("1")},
+		"src/main.go":     {Data: []byte("package main\n")},
+		"src/lib/util.go": {Data: []byte("package lib\n")},
+		"empty":           {Mode: fs.ModeDir},
+	}
+	msg := scanFS(context.Background(), fsys, ".", "/virtual", nil)
+	result, ok := msg.(scanResultMsg)
+	if !ok {
+		t.Fatalf("expected scanResultMsg, got %T", msg)
+	}
+	if result.path != "/virtual" {
+		t.Errorf("expected display path /virtual, got %q", result.path)
+	}
+	if result.totalFiles != 2 || result.totalDirs != 2 {
+		t.Errorf("expected 2 files / 2 dirs, got %d / %d", result.totalFiles, result.totalDirs)
+	}
+	if result.totalSize != 31 {
+		t.Errorf("expected total size 31, got %d", result.totalSize)
+	}
+	src := result.entries[0]
+	if src.Name != "src" || src.Size != 25 || src.ChildFiles != 2 || src.ChildDirs != 1 {
+		t.Errorf("unexpected first entry: %+v", src)
+	}
+
+	if _, ok := scanFS(context.Background(), fsys, "missing", "/virtual/missing", nil).(scanErrorMsg); !ok {
+		t.Error("expected scanErrorMsg for missing directory")
+	}
+}
+
+func TestNavigationCancelsScan(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		os.Mkdir(filepath.Join(dir, name), 0o755)
+	}
+	// firstCmd returns the scan from a batch of scan and spinner tick
+	firstCmd := func(cmd tea.Cmd) tea.Cmd {
+		return cmd().(tea.BatchMsg)[0]
+	}
+
+	m := NewModel(dir)
+	m.width, m.height = 80, 24
+	initScan := firstCmd(m.Init())
+	m, cmd := m.navigateTo(filepath.Join(dir, "a"))
+	scanA := firstCmd(cmd)
+	m, _ = m.navigateTo(filepath.Join(dir, "b"))
+
+	for _, c := range []tea.Cmd{initScan, scanA} {
+		msg, ok := c().(scanErrorMsg)
+		if !ok || !errors.Is(msg.err, context.Canceled) {
+			t.Fatalf("expected the replaced scan to be cancelled, got %#v", msg)
+		}
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	if m.err != nil || !m.loading {
+		t.Errorf("cancelled scans should be ignored, got err %v, loading %v", m.err, m.loading)
 	}
 }
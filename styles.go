@@ -4,147 +4,251 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// colors used throughout the app
+// colors used throughout the app, set from the theme by applyTheme
 var (
-	colorRed     = lipgloss.Color("196")
-	colorOrange  = lipgloss.Color("208")
-	colorYellow  = lipgloss.Color("220")
-	colorGreen   = lipgloss.Color("70")
-	colorCyan    = lipgloss.Color("81")
-	colorBlue    = lipgloss.Color("63")
-	colorDim     = lipgloss.Color("240")
-	colorDimmer  = lipgloss.Color("236")
-	colorWhite   = lipgloss.Color("255")
-	colorFg      = lipgloss.Color("252")
-	colorAccent  = lipgloss.Color("81")
-	colorSelBg   = lipgloss.Color("237")
-	colorSelFg   = lipgloss.Color("255")
-	colorDirIcon = lipgloss.Color("81")
+	colorRed     lipgloss.TerminalColor
+	colorOrange  lipgloss.TerminalColor
+	colorYellow  lipgloss.TerminalColor
+	colorGreen   lipgloss.TerminalColor
+	colorCyan    lipgloss.TerminalColor
+	colorBlue    lipgloss.TerminalColor
+	colorDim     lipgloss.TerminalColor
+	colorDimmer  lipgloss.TerminalColor
+	colorWhite   lipgloss.TerminalColor
+	colorFg      lipgloss.TerminalColor
+	colorAccent  lipgloss.TerminalColor
+	colorSelBg   lipgloss.TerminalColor
+	colorSelFg   lipgloss.TerminalColor
+	colorDirIcon lipgloss.TerminalColor
+	colorMeta    lipgloss.TerminalColor
+	colorInk     lipgloss.TerminalColor // text on colored backgrounds
 )
 
-// Style definitions
+// Style definitions, built by buildStyles
 var (
+	headerStyle       lipgloss.Style
+	headerPathStyle   lipgloss.Style
+	headerStatStyle   lipgloss.Style
+	headerDivider     lipgloss.Style
+	headerBadgeStyle  lipgloss.Style
+	headerCachedStyle lipgloss.Style
+
+	// Row styles (pre-defined to avoid per-row allocation)
+	rowDimStyle             lipgloss.Style
+	rowPctStyle             lipgloss.Style
+	rowSepStyle             lipgloss.Style
+	rowIconStyle            lipgloss.Style
+	rowNameStyle            lipgloss.Style
+	rowNameSelStyle         lipgloss.Style
+	rowMetaStyle            lipgloss.Style
+	rowPointerActiveStyle   lipgloss.Style
+	rowPointerInactiveStyle lipgloss.Style
+	rowSelBgStyle           lipgloss.Style
+	selectedStyle           lipgloss.Style
+
+	footerStyle     lipgloss.Style
+	footerKeyStyle  lipgloss.Style
+	footerDescStyle lipgloss.Style
+
+	errorStyle   lipgloss.Style
+	spinnerStyle lipgloss.Style
+
+	helpTitleStyle lipgloss.Style
+	helpKeyStyle   lipgloss.Style
+	helpDescStyle  lipgloss.Style
+
+	searchPromptStyle lipgloss.Style
+
+	// matchStyle highlights search matches in viewers.
+	matchStyle lipgloss.Style
+)
+
+// barLevel is the size bucket of a percentage, set by bar_thresholds.
+type barLevel int
+
+const (
+	barRed barLevel = iota
+	barOrange
+	barYellow
+	barGreen
+	barDim
+	barLevels
+)
+
+// Pre-defined bar styles per level to avoid per-row allocation in
+// renderRow, and the glyph bars are drawn with. Monochrome themes use
+// glyphs of decreasing density instead of colors.
+var (
+	barStyles [barLevels]lipgloss.Style
+	barGlyphs [barLevels]string
+)
+
+// Treemap rectangle styles per bar level, plus the selection.
+var (
+	treemapStyles   [barLevels]lipgloss.Style
+	treemapSelStyle lipgloss.Style
+)
+
+// monochrome is set while a theme without colors is applied.
+var monochrome bool
+
+func init() {
+	applyTheme(darkTheme)
+}
+
+// buildStyles builds every style from the colors of t.
+func buildStyles(t theme) {
+	monochrome = t.mono
+
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorCyan).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(colorCyan).
+		Padding(0, 1)
 
 	headerPathStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorWhite)
+		Bold(true).
+		Foreground(colorWhite)
 
 	headerStatStyle = lipgloss.NewStyle().
-			Foreground(colorDim)
+		Foreground(colorDim)
 
 	headerDivider = lipgloss.NewStyle().
-			Foreground(colorDim).
-			SetString(" │ ")
+		Foreground(colorDim).
+		SetString(" │ ")
 
 	headerBadgeStyle = lipgloss.NewStyle().
-				Foreground(colorYellow).
-				Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	headerCachedStyle = lipgloss.NewStyle().
-				Foreground(colorCyan)
+		Foreground(colorCyan)
 
-	// Row styles (pre-defined to avoid per-row allocation)
 	rowDimStyle = lipgloss.NewStyle().
-			Foreground(colorDim)
+		Foreground(colorDim)
 
 	rowPctStyle = lipgloss.NewStyle().
-			Foreground(colorDim).
-			Width(6).
-			Align(lipgloss.Right)
+		Foreground(colorDim).
+		Width(6).
+		Align(lipgloss.Right)
 
 	rowSepStyle = lipgloss.NewStyle().
-			Foreground(colorDimmer)
+		Foreground(colorDimmer)
 
 	rowIconStyle = lipgloss.NewStyle().
-			Foreground(colorDirIcon)
+		Foreground(colorDirIcon)
 
 	rowNameStyle = lipgloss.NewStyle().
-			Foreground(colorFg)
+		Foreground(colorFg)
 
 	rowNameSelStyle = lipgloss.NewStyle().
-			Foreground(colorSelFg).
-			Bold(true)
+		Foreground(colorSelFg).
+		Bold(true)
 
 	rowMetaStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("243")).
-			Italic(true)
+		Foreground(colorMeta).
+		Italic(true)
 
 	rowPointerActiveStyle = lipgloss.NewStyle().
-				Foreground(colorCyan).
-				Bold(true)
+		Foreground(colorCyan).
+		Bold(true)
 
 	rowPointerInactiveStyle = lipgloss.NewStyle().
-				Foreground(colorDim)
+		Foreground(colorDim)
 
 	rowSelBgStyle = lipgloss.NewStyle().
-			Background(colorSelBg)
+		Background(colorSelBg)
 
 	selectedStyle = lipgloss.NewStyle().
-			Bold(true).
-			Background(colorSelBg).
-			Foreground(colorSelFg)
+		Bold(true).
+		Background(colorSelBg).
+		Foreground(colorSelFg)
 
 	footerStyle = lipgloss.NewStyle().
-			Foreground(colorDim).
-			Padding(0, 1)
+		Foreground(colorDim).
+		Padding(0, 1)
 
 	footerKeyStyle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	footerDescStyle = lipgloss.NewStyle().
-			Foreground(colorDim)
+		Foreground(colorDim)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(colorRed).
-			Bold(true).
-			Padding(0, 1)
+		Foreground(colorRed).
+		Bold(true).
+		Padding(0, 1)
 
 	spinnerStyle = lipgloss.NewStyle().
-			Foreground(colorCyan)
+		Foreground(colorCyan)
 
 	helpTitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorCyan).
-			Padding(0, 1)
+		Bold(true).
+		Foreground(colorCyan).
+		Padding(0, 1)
 
 	helpKeyStyle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Width(14)
+		Foreground(colorAccent).
+		Width(14)
 
 	helpDescStyle = lipgloss.NewStyle().
-			Foreground(colorFg)
+		Foreground(colorFg)
 
 	searchPromptStyle = lipgloss.NewStyle().
-				Foreground(colorCyan).
-				Bold(true)
-)
+		Foreground(colorCyan).
+		Bold(true)
+
+	matchStyle = lipgloss.NewStyle().Background(colorYellow).Foreground(colorInk)
 
-// Pre-defined bar color styles to avoid per-row allocation in renderRow.
-var barStyles = map[lipgloss.Color]lipgloss.Style{
-	colorRed:    lipgloss.NewStyle().Foreground(colorRed),
-	colorOrange: lipgloss.NewStyle().Foreground(colorOrange),
-	colorYellow: lipgloss.NewStyle().Foreground(colorYellow),
-	colorGreen:  lipgloss.NewStyle().Foreground(colorGreen),
-	colorDim:    lipgloss.NewStyle().Foreground(colorDim),
+	levels := [barLevels]lipgloss.TerminalColor{colorRed, colorOrange, colorYellow, colorGreen, colorDim}
+	for i, c := range levels {
+		barStyles[i] = lipgloss.NewStyle().Foreground(c)
+		treemapStyles[i] = lipgloss.NewStyle().Background(c).Foreground(colorInk)
+		barGlyphs[i] = "█"
+	}
+	treemapStyles[barDim] = treemapStyles[barDim].Foreground(colorWhite)
+	treemapSelStyle = lipgloss.NewStyle().Background(colorWhite).Foreground(colorInk).Bold(true)
+
+	if t.mono {
+		// No colors: selection and matches are reversed, and levels are
+		// told apart by glyph density and emphasis
+		rowSelBgStyle = rowSelBgStyle.Reverse(true)
+		selectedStyle = selectedStyle.Reverse(true)
+		matchStyle = matchStyle.Reverse(true)
+		rowDimStyle = rowDimStyle.Faint(true)
+		barGlyphs = [barLevels]string{"█", "▓", "▒", "░", "·"}
+		treemapStyles = [barLevels]lipgloss.Style{
+			lipgloss.NewStyle().Reverse(true).Bold(true),
+			lipgloss.NewStyle().Reverse(true),
+			lipgloss.NewStyle().Underline(true),
+			lipgloss.NewStyle(),
+			lipgloss.NewStyle().Faint(true),
+		}
+		treemapSelStyle = lipgloss.NewStyle().Reverse(true).Bold(true).Underline(true)
+	}
 }
 
-// barColor returns a color based on the percentage.
-func barColor(pct float64) lipgloss.Color {
+// barLevelOf returns the bar level of a percentage. thresholds are the
+// bar_thresholds setting: the percentages at which bars turn red, orange,
+// yellow and green.
+func barLevelOf(pct float64, thresholds []float64) barLevel {
 	switch {
-	case pct >= 40:
-		return colorRed
-	case pct >= 20:
-		return colorOrange
-	case pct >= 10:
-		return colorYellow
-	case pct >= 2:
-		return colorGreen
+	case pct >= thresholds[0]:
+		return barRed
+	case pct >= thresholds[1]:
+		return barOrange
+	case pct >= thresholds[2]:
+		return barYellow
+	case pct >= thresholds[3]:
+		return barGreen
 	default:
-		return colorDim
+		return barDim
 	}
 }
+
+// bar returns a styled bar of width cells for pct, colored by m's
+// thresholds.
+func (m Model) bar(pct float64, width int) string {
+	level := barLevelOf(pct, m.settings.BarThresholds)
+	return barStyles[level].Render(barString(pct, width, level))
+}
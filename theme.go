@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme is a palette plus overrides of individual styles. The built-in
+// themes are dark (the original look), light, high-contrast and monochrome;
+// theme files start from one of them:
+//
+//	base = "light"
+//	[colors]
+//	accent = "#005f87"
+//	[styles.header_path]
+//	fg = "#000000"
+//	underline = true
+//	[styles.bar_red]
+//	fg = "124"
+type theme struct {
+	name   string
+	mono   bool                      // no colors; see buildStyles
+	colors map[string]string         // palette, by themeColors name
+	styles map[string]themeStyleSpec // overrides applied after buildStyles
+}
+
+// themeStyleSpec overrides parts of one style. Colors are ANSI numbers or
+// #rrggbb; an empty color leaves the style's own.
+type themeStyleSpec struct {
+	Fg        string `toml:"fg"`
+	Bg        string `toml:"bg"`
+	Bold      *bool  `toml:"bold"`
+	Italic    *bool  `toml:"italic"`
+	Underline *bool  `toml:"underline"`
+	Faint     *bool  `toml:"faint"`
+	Reverse   *bool  `toml:"reverse"`
+}
+
+// themeColors maps palette names to the color variables of styles.go.
+var themeColors = map[string]*lipgloss.TerminalColor{
+	"red": &colorRed, "orange": &colorOrange, "yellow": &colorYellow, "green": &colorGreen,
+	"cyan": &colorCyan, "blue": &colorBlue, "dim": &colorDim, "dimmer": &colorDimmer,
+	"white": &colorWhite, "fg": &colorFg, "accent": &colorAccent, "sel_bg": &colorSelBg,
+	"sel_fg": &colorSelFg, "dir_icon": &colorDirIcon, "meta": &colorMeta, "ink": &colorInk,
+}
+
+// themeStyles maps style names in theme files to the styles of styles.go.
+func themeStyles() map[string]*lipgloss.Style {
+	s := map[string]*lipgloss.Style{
+		"header": &headerStyle, "header_path": &headerPathStyle, "header_stat": &headerStatStyle,
+		"header_divider": &headerDivider, "header_badge": &headerBadgeStyle, "header_cached": &headerCachedStyle,
+		"row_dim": &rowDimStyle, "row_pct": &rowPctStyle, "row_sep": &rowSepStyle, "row_icon": &rowIconStyle,
+		"row_name": &rowNameStyle, "row_name_sel": &rowNameSelStyle, "row_meta": &rowMetaStyle,
+		"row_pointer_active": &rowPointerActiveStyle, "row_pointer_inactive": &rowPointerInactiveStyle,
+		"row_sel_bg": &rowSelBgStyle, "selected": &selectedStyle,
+		"footer": &footerStyle, "footer_key": &footerKeyStyle, "footer_desc": &footerDescStyle,
+		"error": &errorStyle, "spinner": &spinnerStyle,
+		"help_title": &helpTitleStyle, "help_key": &helpKeyStyle, "help_desc": &helpDescStyle,
+		"search_prompt": &searchPromptStyle, "match": &matchStyle, "treemap_sel": &treemapSelStyle,
+	}
+	for i, name := range []string{"red", "orange", "yellow", "green", "dim"} {
+		s["bar_"+name] = &barStyles[i]
+		s["treemap_"+name] = &treemapStyles[i]
+	}
+	return s
+}
+
+var (
+	darkTheme = theme{name: "dark", colors: map[string]string{
+		"red": "196", "orange": "208", "yellow": "220", "green": "70", "cyan": "81", "blue": "63",
+		"dim": "240", "dimmer": "236", "white": "255", "fg": "252", "accent": "81",
+		"sel_bg": "237", "sel_fg": "255", "dir_icon": "81", "meta": "243", "ink": "16",
+	}}
+	lightTheme = theme{name: "light", colors: map[string]string{
+		"red": "160", "orange": "166", "yellow": "136", "green": "28", "cyan": "31", "blue": "25",
+		"dim": "244", "dimmer": "250", "white": "232", "fg": "235", "accent": "31",
+		"sel_bg": "254", "sel_fg": "232", "dir_icon": "31", "meta": "242", "ink": "231",
+	}}
+	highContrastTheme = theme{name: "high-contrast", colors: map[string]string{
+		"red": "9", "orange": "214", "yellow": "11", "green": "10", "cyan": "14", "blue": "12",
+		"dim": "250", "dimmer": "245", "white": "15", "fg": "15", "accent": "14",
+		"sel_bg": "21", "sel_fg": "15", "dir_icon": "14", "meta": "252", "ink": "0",
+	}}
+	monoTheme = theme{name: "monochrome", mono: true, colors: map[string]string{}}
+)
+
+// builtinThemes are the themes selectable by name.
+var builtinThemes = map[string]theme{
+	"dark": darkTheme, "light": lightTheme, "high-contrast": highContrastTheme, "monochrome": monoTheme,
+}
+
+// applyTheme sets the palette and rebuilds every style.
+func applyTheme(t theme) {
+	for name, c := range themeColors {
+		if v, ok := t.colors[name]; ok && v != "" {
+			*c = lipgloss.Color(v)
+		} else {
+			*c = lipgloss.NoColor{}
+		}
+	}
+	buildStyles(t)
+	styles := themeStyles()
+	for name, spec := range t.styles {
+		*styles[name] = spec.apply(*styles[name])
+	}
+}
+
+func (s themeStyleSpec) apply(st lipgloss.Style) lipgloss.Style {
+	if s.Fg != "" {
+		st = st.Foreground(lipgloss.Color(s.Fg))
+	}
+	if s.Bg != "" {
+		st = st.Background(lipgloss.Color(s.Bg))
+	}
+	if s.Bold != nil {
+		st = st.Bold(*s.Bold)
+	}
+	if s.Italic != nil {
+		st = st.Italic(*s.Italic)
+	}
+	if s.Underline != nil {
+		st = st.Underline(*s.Underline)
+	}
+	if s.Faint != nil {
+		st = st.Faint(*s.Faint)
+	}
+	if s.Reverse != nil {
+		st = st.Reverse(*s.Reverse)
+	}
+	return st
+}
+
+// themeFile is the TOML layout of a theme file.
+type themeFile struct {
+	Base   string                    `toml:"base"`
+	Mono   *bool                     `toml:"mono"`
+	Colors map[string]string         `toml:"colors"`
+	Styles map[string]themeStyleSpec `toml:"styles"`
+}
+
+// loadTheme returns the theme called name: "auto", a built-in theme, a file
+// themes/<name>.toml in the config directory, or a path to a theme file.
+func loadTheme(name string) (theme, error) {
+	if name == "" || name == "auto" {
+		return autoTheme(), nil
+	}
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+	path := expandHome(name)
+	if !strings.ContainsRune(name, filepath.Separator) && !strings.HasSuffix(name, ".toml") {
+		path = filepath.Join(configDir(), "themes", name+".toml")
+	}
+	var f themeFile
+	md, err := toml.DecodeFile(path, &f)
+	if errors.Is(err, os.ErrNotExist) {
+		return theme{}, fmt.Errorf("unknown theme %q (built in: %s; or a file at %s)", name, builtinThemeNames(), path)
+	}
+	if err != nil {
+		return theme{}, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return theme{}, fmt.Errorf("%s: unknown setting %s", path, undecoded[0])
+	}
+
+	base := darkTheme
+	if f.Base != "" {
+		var ok bool
+		if base, ok = builtinThemes[f.Base]; !ok {
+			return theme{}, fmt.Errorf("%s: base %q is not a built-in theme (%s)", path, f.Base, builtinThemeNames())
+		}
+	}
+	t := theme{name: name, mono: base.mono, colors: make(map[string]string), styles: f.Styles}
+	for k, v := range base.colors {
+		t.colors[k] = v
+	}
+	if f.Mono != nil {
+		t.mono = *f.Mono
+	}
+	var errs []error
+	for k, v := range f.Colors {
+		if _, ok := themeColors[k]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown color %q", path, k))
+		}
+		t.colors[k] = v
+	}
+	styles := themeStyles()
+	for k := range f.Styles {
+		if _, ok := styles[k]; !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown style %q", path, k))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return t, errors.Join(errs...)
+}
+
+// autoTheme picks monochrome when NO_COLOR is set, else light or dark to
+// match the terminal background.
+func autoTheme() theme {
+	if os.Getenv("NO_COLOR") != "" {
+		return monoTheme
+	}
+	if !lipgloss.HasDarkBackground() {
+		return lightTheme
+	}
+	return darkTheme
+}
+
+func builtinThemeNames() string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestLoadThemeFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := filepath.Join(configDir(), "themes")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "paper.toml"), []byte(`
+base = "light"
+glyphs = "x"
+[colors]
+accent = "#005f87"
+[styles.bar_red]
+fg = "124"
+underline = true
+`), 0o644)
+	if _, err := loadTheme("paper"); err == nil || !strings.Contains(err.Error(), "unknown setting glyphs") {
+		t.Fatalf("got %v, want unknown setting", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "paper.toml"), []byte(`
+base = "light"
+[colors]
+accent = "#005f87"
+[styles.bar_red]
+fg = "124"
+underline = true
+`), 0o644)
+	th, err := loadTheme("paper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.colors["accent"] != "#005f87" || th.colors["red"] != lightTheme.colors["red"] {
+		t.Errorf("colors not merged over the base: %v", th.colors)
+	}
+
+	applyTheme(th)
+	defer applyTheme(darkTheme)
+	if colorAccent != lipgloss.Color("#005f87") {
+		t.Errorf("accent = %v", colorAccent)
+	}
+	if barStyles[barRed].GetForeground() != lipgloss.Color("124") || !barStyles[barRed].GetUnderline() {
+		t.Errorf("bar_red override not applied")
+	}
+
+	// Unknown names are all reported
+	os.WriteFile(filepath.Join(dir, "bad.toml"), []byte("base = \"dark\"\n[colors]\nteal = \"6\"\n[styles.sidebar]\nbold = true\n"), 0o644)
+	_, err = loadTheme("bad")
+	if err == nil || !strings.Contains(err.Error(), `unknown color "teal"`) || !strings.Contains(err.Error(), `unknown style "sidebar"`) {
+		t.Errorf("got %v", err)
+	}
+	if _, err := loadTheme("missing"); err == nil || !strings.Contains(err.Error(), "unknown theme") {
+		t.Errorf("missing theme: %v", err)
+	}
+}
+
+func TestAutoThemeNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if th, _ := loadTheme("auto"); !th.mono {
+		t.Errorf("NO_COLOR: got theme %s", th.name)
+	}
+}
+
+func TestMonochromeBars(t *testing.T) {
+	applyTheme(monoTheme)
+	defer applyTheme(darkTheme)
+	if !monochrome {
+		t.Fatal("monochrome not set")
+	}
+	if got := barString(25, 8, barOrange); got != "▓▓      " {
+		t.Errorf("orange bar = %q", got)
+	}
+	if got := barString(5, 40, barGreen); !strings.HasPrefix(got, "░░") {
+		t.Errorf("green bar = %q", got)
+	}
+	seen := make(map[string]bool)
+	for _, g := range barGlyphs {
+		if seen[g] {
+			t.Errorf("glyph %q used for two bar levels: %q", g, barGlyphs)
+		}
+		seen[g] = true
+	}
+	if _, ok := barStyles[barRed].GetForeground().(lipgloss.NoColor); !ok {
+		t.Errorf("bar has a color: %v", barStyles[barRed].GetForeground())
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mohsinkaleem/dirgo/entry"
+)
+
+// treeState holds the inline tree view. Directories are expanded in place;
+// m.filtered then holds the flattened visible tree with each entry's Name set
+// to its path relative to m.path, so cursor, offset and every action that
+// joins m.path with the selected name keep working on nested rows.
+type treeState struct {
+	path     string                       // directory the expansions belong to
+	expanded map[string]bool              // relative path → expanded
+	children map[string][]entry.FileEntry // relative path → loaded listing
+	rows     []treeRow                    // parallel to m.filtered
+	top      []entry.FileEntry            // reusable buffer for the filtered top level
+}
+
+// treeRow is the layout of one flattened row.
+type treeRow struct {
+	depth  int
+	prefix string // tree guides drawn before the name, e.g. "│  ├─ "
+}
+
+// treeChildrenMsg carries the listing of a directory expanded in tree mode.
+type treeChildrenMsg struct {
+	path string // m.path the expansion was requested under
+	rel  string
+	scan scanResultMsg
+	err  error
+}
+
+func newTreeState(path string) *treeState {
+	return &treeState{
+		path:     path,
+		expanded: make(map[string]bool),
+		children: make(map[string][]entry.FileEntry),
+	}
+}
+
+// toggleTree switches between the flat list and the tree view.
+func (m Model) toggleTree() Model {
+	selected := m.selectedRel()
+	if m.tree == nil {
+		m.tree = newTreeState(m.path)
+	} else {
+		m.tree = nil
+		// Nested rows disappear; fall back to their top-level ancestor
+		selected = strings.SplitN(selected, string(filepath.Separator), 2)[0]
+	}
+	m.applyFilter()
+	m.selectRel(selected)
+	return m
+}
+
+// selectedRel returns the selected entry's name (its relative path in tree mode).
+func (m Model) selectedRel() string {
+	if m.cursor < len(m.filtered) {
+		return m.filtered[m.cursor].Name
+	}
+	return ""
+}
+
+// selectRel moves the cursor to the row named rel, if visible.
+func (m *Model) selectRel(rel string) {
+	for i, e := range m.filtered {
+		if e.Name == rel {
+			m.cursor = i
+			break
+		}
+	}
+	m.ensureVisible()
+}
+
+// flattenTree rebuilds m.filtered and the row layout from the filtered top
+// level, descending into every expanded directory whose listing is loaded.
+// Nested levels only honour the hidden-files toggle; the view filter and
+// search apply to the top level.
+func (m *Model) flattenTree(top []entry.FileEntry) {
+	t := m.tree
+	t.rows = t.rows[:0]
+	m.filtered = m.filtered[:0]
+
+	var walk func(parent string, list []entry.FileEntry, depth int, guide string)
+	walk = func(parent string, list []entry.FileEntry, depth int, guide string) {
+		// Index of the last visible entry, so its guide can close the branch
+		last := -1
+		for i := range list {
+			if m.showHidden || !list[i].IsHidden {
+				last = i
+			}
+		}
+		for i, e := range list {
+			if !m.showHidden && e.IsHidden {
+				continue
+			}
+			rel := e.Name
+			if parent != "" {
+				rel = filepath.Join(parent, e.Name)
+			}
+			row := treeRow{depth: depth}
+			childGuide := ""
+			if depth > 0 {
+				if i == last {
+					row.prefix = guide + "└─ "
+					childGuide = guide + "   "
+				} else {
+					row.prefix = guide + "├─ "
+					childGuide = guide + "│  "
+				}
+			}
+			e.Name = rel
+			m.filtered = append(m.filtered, e)
+			t.rows = append(t.rows, row)
+			if e.IsDir && t.expanded[rel] {
+				if kids, ok := t.children[rel]; ok {
+					entry.Sort(kids, m.sortMode)
+					walk(rel, kids, depth+1, childGuide)
+				}
+			}
+		}
+	}
+	walk("", top, 0, "")
+}
+
+// treeRight expands the selected directory, or steps into its first child
+// if it is already expanded. Files are opened as in the flat list.
+func (m Model) treeRight() (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	sel := m.filtered[m.cursor]
+	if !sel.IsDir {
+		return m.navigateIn()
+	}
+	t := m.tree
+	if t.expanded[sel.Name] {
+		if m.cursor+1 < len(m.filtered) && m.tree.rows[m.cursor+1].depth > m.tree.rows[m.cursor].depth {
+			m = m.moveCursor(1)
+		}
+		return m, nil
+	}
+	t.expanded[sel.Name] = true
+	if _, ok := t.children[sel.Name]; ok {
+		m.applyFilter()
+		return m, nil
+	}
+	if n := m.selectedNode(); n != nil && n.Children != nil {
+		// Already scanned as part of the listing
+		t.children[sel.Name] = n.Entries()
+		m.applyFilter()
+		return m, nil
+	}
+	full := filepath.Join(m.path, sel.Name)
+	if cached, ok := m.cache.Get(full); ok {
+		t.children[sel.Name] = append([]entry.FileEntry(nil), cached.entries...)
+		m.applyFilter()
+		return m, nil
+	}
+	return m, m.treeChildrenCmd(sel.Name)
+}
+
+// treeLeft collapses the selected directory, moves to the parent row of a
+// nested entry, or leaves the directory from the top level.
+func (m Model) treeLeft() (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m.navigateUp()
+	}
+	t := m.tree
+	sel := m.filtered[m.cursor]
+	depth := t.rows[m.cursor].depth
+	switch {
+	case sel.IsDir && t.expanded[sel.Name]:
+		delete(t.expanded, sel.Name)
+		m.applyFilter()
+		m.ensureVisible()
+		return m, nil
+	case depth > 0:
+		for i := m.cursor - 1; i >= 0; i-- {
+			if t.rows[i].depth == depth-1 {
+				m.cursor = i
+				break
+			}
+		}
+		m.ensureVisible()
+		return m, m.lineCountForSelected()
+	default:
+		return m.navigateUp()
+	}
+}
+
+// treeChildrenCmd lists the directory rel (relative to m.path) for expansion.
+func (m Model) treeChildrenCmd(rel string) tea.Cmd {
+	full := filepath.Join(m.path, rel)
+	var scanCmd tea.Cmd
+	if m.vfs != nil && m.vfs.contains(full) {
+		scanCmd = scanFSCmd(context.Background(), m.vfs.fsys, m.vfs.name(full), full, nil)
+	} else {
+		scanCmd = scanDirectory(context.Background(), full, nil)
+	}
+	path := m.path
+	return func() tea.Msg {
+		switch msg := scanCmd().(type) {
+		case scanResultMsg:
+			return treeChildrenMsg{path: path, rel: rel, scan: msg}
+		case scanErrorMsg:
+			return treeChildrenMsg{path: path, rel: rel, err: msg.err}
+		default:
+			return nil
+		}
+	}
+}
+
+// applyTreeChildren stores a loaded listing and re-flattens the tree.
+func (m Model) applyTreeChildren(msg treeChildrenMsg) Model {
+	if m.tree == nil || m.tree.path != msg.path || msg.path != m.path {
+		return m // stale: tree mode left or directory changed meanwhile
+	}
+	if msg.err != nil {
+		delete(m.tree.expanded, msg.rel)
+		m.err = fmt.Errorf("cannot expand %s: %w", msg.rel, msg.err)
+		return m
+	}
+	m.cache.Put(msg.scan.path, msg.scan)
+	m.tree.children[msg.rel] = append([]entry.FileEntry(nil), msg.scan.entries...)
+	selected := m.selectedRel()
+	m.applyFilter()
+	m.selectRel(selected)
+	return m
+}
+
+// treeEntry returns the loaded nested entry at rel, or nil.
+func (t *treeState) treeEntry(rel string) *entry.FileEntry {
+	parent, name := filepath.Split(rel)
+	parent = strings.TrimSuffix(parent, string(filepath.Separator))
+	kids := t.children[parent]
+	for i := range kids {
+		if kids[i].Name == name {
+			return &kids[i]
+		}
+	}
+	return nil
+}
+
+// removeTreeEntry drops the nested entry rel after it was trashed and
+// subtracts it from every ancestor, up to and including the top-level entry.
+func (m *Model) removeTreeEntry(rel string) {
+	t := m.tree
+	gone := t.treeEntry(rel)
+	if gone == nil {
+		return
+	}
+	removed := *gone
+	files := removed.ChildFiles
+	dirs := removed.ChildDirs
+	if removed.IsDir {
+		dirs++
+	} else {
+		files++
+	}
+
+	parent := filepath.Dir(rel)
+	kids := t.children[parent]
+	for i := range kids {
+		if kids[i].Name == removed.Name {
+			t.children[parent] = append(kids[:i], kids[i+1:]...)
+			break
+		}
+	}
+	delete(t.children, rel)
+	delete(t.expanded, rel)
+	m.adjustTreeAncestors(parent, -removed.Size, -removed.DiskSize, -files, -dirs)
+}
+
+// adjustTreeAncestors adds size and counts to the nested directory parent and
+// every ancestor up to and including the top-level entry, then refreshes the
+// listing totals.
+func (m *Model) adjustTreeAncestors(parent string, size, disk int64, files, dirs int) {
+	t := m.tree
+	for dir := parent; ; dir = filepath.Dir(dir) {
+		var e *entry.FileEntry
+		if strings.ContainsRune(dir, filepath.Separator) {
+			e = t.treeEntry(dir)
+		} else {
+			for i := range m.entries {
+				if m.entries[i].Name == dir {
+					e = &m.entries[i]
+					break
+				}
+			}
+		}
+		if e != nil {
+			e.Size += size
+			e.DiskSize += disk
+			e.ChildFiles += files
+			e.ChildDirs += dirs
+			setPercentages(t.children[dir], e.Size)
+		}
+		m.cache.Delete(filepath.Join(m.path, dir))
+		if !strings.ContainsRune(dir, filepath.Separator) {
+			break
+		}
+	}
+	m.totalSize += size
+	m.recomputePercentages()
+	m.computeDeepTotals()
+}
+
+// setPercentages refreshes each entry's share of total.
+func setPercentages(list []entry.FileEntry, total int64) {
+	for i := range list {
+		if total > 0 {
+			list[i].Percentage = float64(list[i].Size) / float64(total) * 100
+		} else {
+			list[i].Percentage = 0
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// treeModel returns a model in tree mode listing a deep test directory:
+// level_0/level_1/level_2 with two files per level.
+func treeModel(t *testing.T) Model {
+	t.Helper()
+	dir := makeDeepDir(t, 3, 2)
+	m := NewModel(dir)
+	m.width, m.height = 100, 30
+	next, _ := m.Update(scanDirectory(context.Background(), dir, nil)())
+	m = next.(Model).toggleTree()
+	return m
+}
+
+// expandSelected expands the selected directory, running the listing command.
+func expandSelected(t *testing.T, m Model) Model {
+	t.Helper()
+	m, cmd := m.treeRight()
+	if cmd != nil {
+		next, _ := m.Update(cmd())
+		m = next.(Model)
+	}
+	return m
+}
+
+func TestTreeExpandCollapse(t *testing.T) {
+	m := treeModel(t)
+	if m.filtered[0].Name != "level_0" || len(m.filtered) != 3 {
+		t.Fatalf("unexpected top level: %v", m.filtered)
+	}
+
+	m = expandSelected(t, m)
+	if len(m.filtered) != 6 {
+		t.Fatalf("expected 6 rows after expanding, got %d", len(m.filtered))
+	}
+	nested := filepath.Join("level_0", "level_1")
+	if m.filtered[1].Name != nested || m.tree.rows[1].depth != 1 || m.tree.rows[1].prefix != "├─ " {
+		t.Errorf("unexpected first nested row: %q %+v", m.filtered[1].Name, m.tree.rows[1])
+	}
+	if m.tree.rows[3].prefix != "└─ " {
+		t.Errorf("expected last child to close the branch, got %q", m.tree.rows[3].prefix)
+	}
+	// Percentages of nested rows are relative to their parent
+	if p := m.filtered[1].Percentage + m.filtered[2].Percentage + m.filtered[3].Percentage; p < 99.9 || p > 100.1 {
+		t.Errorf("nested percentages sum to %.1f, want 100", p)
+	}
+
+	// → on an expanded dir steps to its first child; ← goes back to the parent
+	m = expandSelected(t, m)
+	if m.cursor != 1 {
+		t.Fatalf("expected cursor on first child, got %d", m.cursor)
+	}
+	m = expandSelected(t, m)
+	if m.tree.rows[2].prefix != "│  ├─ " {
+		t.Errorf("expected nested guide, got %q", m.tree.rows[2].prefix)
+	}
+	m, _ = m.treeLeft() // collapse level_1
+	m, _ = m.treeLeft() // up to level_0
+	if m.cursor != 0 {
+		t.Errorf("expected cursor back on level_0, got %d", m.cursor)
+	}
+	m, _ = m.treeLeft() // collapse level_0
+	if len(m.filtered) != 3 {
+		t.Errorf("expected 3 rows after collapsing, got %d", len(m.filtered))
+	}
+
+	// Leaving tree mode keeps the selection on the top-level ancestor
+	m = expandSelected(t, m)
+	m = m.moveCursor(2)
+	m = m.toggleTree()
+	if m.tree != nil || m.filtered[m.cursor].Name != "level_0" {
+		t.Errorf("expected flat list with level_0 selected, got %q", m.filtered[m.cursor].Name)
+	}
+}
+
+func TestTreeTrashNested(t *testing.T) {
+	m := treeModel(t)
+	m = expandSelected(t, m)
+	top := m.filtered[0]
+
+	rel := filepath.Join("level_0", "f0.txt")
+	var size int64
+	for _, e := range m.filtered {
+		if e.Name == rel {
+			size = e.Size
+		}
+	}
+	next, _ := m.Update(trashResultMsg{name: rel, size: size})
+	m = next.(Model)
+
+	for _, e := range m.filtered {
+		if e.Name == rel {
+			t.Fatal("trashed entry still listed")
+		}
+	}
+	if m.filtered[0].Size != top.Size-size || m.filtered[0].ChildFiles != top.ChildFiles-1 {
+		t.Errorf("ancestor not adjusted: size %d→%d, files %d→%d",
+			top.Size, m.filtered[0].Size, top.ChildFiles, m.filtered[0].ChildFiles)
+	}
+}
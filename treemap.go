@@ -0,0 +1,342 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The treemap view draws m.filtered as a squarified treemap in the list area.
+// It is another rendering of the same listing: the cursor is m.cursor,
+// zooming into a directory navigates into it and zooming out goes to the
+// parent, so scanning, caching and cursor history are shared with the list.
+
+// maxTreemapEntries bounds how many entries are laid out; smaller ones would
+// not get a cell anyway.
+const maxTreemapEntries = 200
+
+// tmRect is a layout rectangle in float units.
+type tmRect struct {
+	x, y, w, h float64
+}
+
+// tmCell is a laid-out rectangle in terminal cells, tied to its m.filtered index.
+type tmCell struct {
+	index          int
+	x0, y0, x1, y1 int // half-open
+}
+
+func (c tmCell) cx() float64 { return float64(c.x0+c.x1) / 2 }
+func (c tmCell) cy() float64 { return float64(c.y0+c.y1) / 2 }
+
+// squarify lays out sizes (sorted descending, all > 0) in the rectangle
+// bounds using the squarified treemap algorithm (Bruls, Huizing, van Wijk):
+// items are added to the current row while that improves the worst aspect
+// ratio, then the row is fixed along the shorter side.
+func squarify(sizes []float64, bounds tmRect) []tmRect {
+	out := make([]tmRect, len(sizes))
+	var total float64
+	for _, s := range sizes {
+		total += s
+	}
+	if total <= 0 || bounds.w <= 0 || bounds.h <= 0 {
+		return out
+	}
+	scale := bounds.w * bounds.h / total
+
+	free := bounds
+	start := 0
+	for start < len(sizes) {
+		short := math.Min(free.w, free.h)
+		end := start + 1
+		rowSum := sizes[start] * scale
+		best := worstRatio(sizes[start:end], rowSum, scale, short)
+		for end < len(sizes) {
+			nextSum := rowSum + sizes[end]*scale
+			r := worstRatio(sizes[start:end+1], nextSum, scale, short)
+			if r > best {
+				break
+			}
+			best, rowSum = r, nextSum
+			end++
+		}
+
+		// Lay the row along the shorter side of the free rectangle
+		thick := rowSum / short
+		pos := 0.0
+		for i := start; i < end; i++ {
+			length := sizes[i] * scale / thick
+			if free.w >= free.h {
+				out[i] = tmRect{free.x, free.y + pos, thick, length}
+			} else {
+				out[i] = tmRect{free.x + pos, free.y, length, thick}
+			}
+			pos += length
+		}
+		if free.w >= free.h {
+			free.x += thick
+			free.w -= thick
+		} else {
+			free.y += thick
+			free.h -= thick
+		}
+		start = end
+	}
+	return out
+}
+
+// worstRatio returns the worst aspect ratio of a row of items with the given
+// total area laid along a side of length short.
+func worstRatio(row []float64, rowSum, scale, short float64) float64 {
+	if rowSum <= 0 {
+		return math.Inf(1)
+	}
+	maxA := row[0] * scale
+	minA := row[len(row)-1] * scale
+	s2 := short * short
+	r2 := rowSum * rowSum
+	return math.Max(s2*maxA/r2, r2/(s2*minA))
+}
+
+// treemapLayout lays out m.filtered in a w×h cell area. Layout happens in
+// units where a cell is twice as tall as it is wide, so rectangles look square
+// on screen. Entries too small to cover a cell are left out.
+func treemapLayout(m Model, w, h int) []tmCell {
+	idx := make([]int, 0, len(m.filtered))
+	for i, e := range m.filtered {
+		if e.Size > 0 {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return m.filtered[idx[a]].Size > m.filtered[idx[b]].Size
+	})
+	if len(idx) > maxTreemapEntries {
+		idx = idx[:maxTreemapEntries]
+	}
+	sizes := make([]float64, len(idx))
+	for i, fi := range idx {
+		sizes[i] = float64(m.filtered[fi].Size)
+	}
+
+	rects := squarify(sizes, tmRect{0, 0, float64(w), float64(h) * 2})
+	cells := make([]tmCell, 0, len(rects))
+	for i, r := range rects {
+		c := tmCell{
+			index: idx[i],
+			x0:    int(math.Round(r.x)),
+			x1:    int(math.Round(r.x + r.w)),
+			y0:    int(math.Round(r.y / 2)),
+			y1:    int(math.Round((r.y + r.h) / 2)),
+		}
+		if c.x1 > c.x0 && c.y1 > c.y0 {
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+// renderTreemap draws the treemap into exactly h lines of width w.
+func renderTreemap(m Model, w, h int) string {
+	cells := treemapLayout(m, w, h)
+
+	// owner[y][x] is the index into cells covering that cell, or -1 for gaps
+	owner := make([][]int, h)
+	text := make([][]rune, h)
+	for y := range owner {
+		owner[y] = make([]int, w)
+		text[y] = []rune(strings.Repeat(" ", w))
+		for x := range owner[y] {
+			owner[y][x] = -1
+		}
+	}
+	for ci, c := range cells {
+		// Leave a one-cell gap on the right and bottom edges so neighbours
+		// stay distinguishable, unless the rectangle is too thin for it.
+		x1, y1 := c.x1, c.y1
+		if x1-c.x0 > 2 {
+			x1--
+		}
+		if y1-c.y0 > 1 {
+			y1--
+		}
+		for y := c.y0; y < y1 && y < h; y++ {
+			for x := c.x0; x < x1 && x < w; x++ {
+				owner[y][x] = ci
+			}
+		}
+		// Label: name on the first line, size on the second where space allows
+		e := m.filtered[c.index]
+		labelW := x1 - c.x0 - 1
+		if labelW < 1 {
+			continue
+		}
+		name := e.Name
+		if e.IsDir {
+			name = "▸" + name
+		}
+		writeLabel(text[c.y0], c.x0+1, truncateStrVisual(name, labelW))
+		if y1-c.y0 >= 2 {
+			writeLabel(text[c.y0+1], c.x0+1, truncateStrVisual(formatSize(e.Size), labelW))
+		}
+	}
+
+	var b strings.Builder
+	for y := 0; y < h; y++ {
+		// Emit runs of cells sharing an owner with a single style
+		for x := 0; x < w; {
+			o := owner[y][x]
+			end := x + 1
+			for end < w && owner[y][end] == o {
+				end++
+			}
+			run := cellString(text[y][x:end])
+			if o < 0 {
+				b.WriteString(run)
+			} else {
+				b.WriteString(treemapCellStyle(m, cells[o]).Render(run))
+			}
+			x = end
+		}
+		if y < h-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// wideCell marks the cell covered by the right half of a wide rune.
+const wideCell rune = 0
+
+// writeLabel copies s into line at column x, clipped to the line. A wide
+// rune takes two cells, the second marked wideCell; one that does not fit
+// is dropped.
+func writeLabel(line []rune, x int, s string) {
+	for _, r := range s {
+		w := lipgloss.Width(string(r))
+		if x+w > len(line) {
+			return
+		}
+		line[x] = r
+		if w == 2 {
+			line[x+1] = wideCell
+		}
+		x += w
+	}
+}
+
+// cellString joins a run of cells, skipping wide runes' second halves.
+func cellString(cells []rune) string {
+	var b strings.Builder
+	for _, r := range cells {
+		if r != wideCell {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// treemapCellStyle styles a rectangle by its bar level; the selection is
+// drawn inverted.
+func treemapCellStyle(m Model, c tmCell) lipgloss.Style {
+	if c.index == m.cursor {
+		return treemapSelStyle
+	}
+	e := m.filtered[c.index]
+	st := treemapStyles[barLevelOf(e.Percentage, m.settings.BarThresholds)]
+	if e.IsDir {
+		st = st.Bold(true)
+	}
+	return st
+}
+
+// treemapArea returns the size of the list area the treemap fills.
+func treemapArea(m Model) (int, int) {
+	return m.width - m.paneWidth(), maxInt(1, m.height-4-headerLineCount(m))
+}
+
+// updateTreemap handles keys in treemap mode: the movement keys move
+// between rectangles, enter zooms into a directory, backspace/- zooms out
+// and escape or the treemap key returns to the list. Other keys fall through
+// to the list bindings.
+func (m Model) updateTreemap(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	var dx, dy int
+	// enter and backspace zoom though the default left and right keys
+	// include them
+	switch k := msg.String(); {
+	case key.Matches(msg, m.keys.Treemap, m.keys.Escape):
+		m.treemap = false
+		m.ensureVisible()
+		return m, nil, true
+	case k == "enter":
+		if len(m.filtered) > 0 && m.filtered[m.cursor].IsDir {
+			next, cmd := m.navigateIn()
+			return next, cmd, true
+		}
+		return m, nil, true
+	case k == "backspace" || k == "-":
+		next, cmd := m.navigateUp()
+		return next, cmd, true
+	case key.Matches(msg, m.keys.Left):
+		dx = -1
+	case key.Matches(msg, m.keys.Right):
+		dx = 1
+	case key.Matches(msg, m.keys.Up):
+		dy = -1
+	case key.Matches(msg, m.keys.Down):
+		dy = 1
+	default:
+		return m, nil, false
+	}
+	w, h := treemapArea(m)
+	m.cursor = treemapNeighbor(treemapLayout(m, w, h), m.cursor, dx, dy)
+	return m, m.lineCountForSelected(), true
+}
+
+// treemapNeighbor returns the index of the rectangle nearest to the one at
+// cur in direction (dx, dy), or cur if there is none. Distance along the
+// direction counts once, sideways offset twice, favouring aligned neighbours.
+func treemapNeighbor(cells []tmCell, cur, dx, dy int) int {
+	from := -1
+	for i, c := range cells {
+		if c.index == cur {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		if len(cells) > 0 {
+			return cells[0].index
+		}
+		return cur
+	}
+	src := cells[from]
+	best, bestScore := cur, math.Inf(1)
+	for i, c := range cells {
+		if i == from {
+			continue
+		}
+		// The candidate must lie beyond the current rectangle's edge
+		var along, side float64
+		switch {
+		case dx > 0 && c.x0 >= src.x1:
+			along, side = float64(c.x0-src.x1), math.Abs(c.cy()-src.cy())
+		case dx < 0 && c.x1 <= src.x0:
+			along, side = float64(src.x0-c.x1), math.Abs(c.cy()-src.cy())
+		case dy > 0 && c.y0 >= src.y1:
+			along, side = float64(c.y0-src.y1), math.Abs(c.cx()-src.cx())
+		case dy < 0 && c.y1 <= src.y0:
+			along, side = float64(src.y0-c.y1), math.Abs(c.cx()-src.cx())
+		default:
+			continue
+		}
+		if score := along + 2*side; score < bestScore {
+			best, bestScore = c.index, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSquarify(t *testing.T) {
+	sizes := []float64{6, 6, 4, 3, 2, 2, 1}
+	bounds := tmRect{0, 0, 6, 4}
+	rects := squarify(sizes, bounds)
+
+	var area float64
+	for i, r := range rects {
+		// Each rectangle's area is proportional to its size (total 24 = 6×4)
+		if got := r.w * r.h; math.Abs(got-sizes[i]) > 1e-9 {
+			t.Errorf("rect %d area %.3f, want %.0f", i, got, sizes[i])
+		}
+		if r.x < -1e-9 || r.y < -1e-9 || r.x+r.w > bounds.w+1e-9 || r.y+r.h > bounds.h+1e-9 {
+			t.Errorf("rect %d %+v outside bounds", i, r)
+		}
+		area += r.w * r.h
+	}
+	if math.Abs(area-24) > 1e-9 {
+		t.Errorf("total area %.3f, want 24", area)
+	}
+	// The classic example lays the two largest items side by side first
+	if rects[0].h != rects[1].h || rects[0].x != 0 || rects[1].x != 0 {
+		t.Errorf("unexpected first row: %+v %+v", rects[0], rects[1])
+	}
+}
+
+func TestRenderTreemap(t *testing.T) {
+	m := makeTestModel(8)
+	out := renderTreemap(m, 60, 12)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 12 {
+		t.Fatalf("expected 12 lines, got %d", len(lines))
+	}
+	for i, l := range lines {
+		if w := lipgloss.Width(l); w != 60 {
+			t.Errorf("line %d is %d cells wide, want 60", i, w)
+		}
+	}
+	if !strings.Contains(out, "▸"+m.filtered[0].Name) {
+		t.Error("largest entry should be labelled")
+	}
+
+	// Wide names are cut by display width, so rows keep their width
+	for i := range m.filtered {
+		m.filtered[i].Name = strings.Repeat("日本語", i+1) + "🎉.txt"
+	}
+	for i, l := range strings.Split(renderTreemap(m, 60, 12), "\n") {
+		if w := lipgloss.Width(l); w != 60 {
+			t.Errorf("wide names: line %d is %d cells wide, want 60", i, w)
+		}
+	}
+}
+
+func TestTreemapNeighbor(t *testing.T) {
+	// Two columns: a tall rectangle left, two stacked on the right
+	cells := []tmCell{
+		{index: 0, x0: 0, y0: 0, x1: 10, y1: 10},
+		{index: 1, x0: 10, y0: 0, x1: 20, y1: 5},
+		{index: 2, x0: 10, y0: 5, x1: 20, y1: 10},
+	}
+	if got := treemapNeighbor(cells, 0, 1, 0); got != 1 && got != 2 {
+		t.Errorf("right of 0: got %d", got)
+	}
+	if got := treemapNeighbor(cells, 1, 0, 1); got != 2 {
+		t.Errorf("below 1: got %d, want 2", got)
+	}
+	if got := treemapNeighbor(cells, 2, -1, 0); got != 0 {
+		t.Errorf("left of 2: got %d, want 0", got)
+	}
+	if got := treemapNeighbor(cells, 0, -1, 0); got != 0 {
+		t.Errorf("nothing left of 0: got %d, want 0", got)
+	}
+}
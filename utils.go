@@ -1,12 +1,11 @@
 package main
 
 import (
-	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -33,6 +32,53 @@ func formatSize(b int64) string {
 	}
 }
 
+// sizeUnits are the suffixes parseSize accepts, in the 1024-based units of
+// formatSize.
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+}
+
+// parseSize parses a size such as "512", "64K", "100MB", "1.5 GiB" or "2t".
+func parseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	num = strings.TrimSuffix(strings.TrimSuffix(num, "B"), "I")
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = num[:len(num)-1], u.mult
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || f < 0 || strings.ContainsAny(num, "eEnN") {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
+
+// formatSizeExact formats b in the largest unit that divides it, e.g. "100M",
+// so that parseSize reads it back unchanged.
+func formatSizeExact(b int64) string {
+	for _, u := range sizeUnits {
+		if b != 0 && b%u.mult == 0 {
+			return strconv.FormatInt(b/u.mult, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(b, 10)
+}
+
+// countNoun formats n with the singular or plural noun, e.g. "1 group",
+// "3 groups".
+func countNoun(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return strconv.Itoa(n) + " " + many
+}
+
 // shortenPath replaces the home directory prefix with ~.
 func shortenPath(path string) string {
 	home, err := os.UserHomeDir()
@@ -45,6 +91,32 @@ func shortenPath(path string) string {
 	return path
 }
 
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// expandVars replaces $VAR and ${VAR} in path with environment variables,
+// leaving unset ones as written.
+func expandVars(path string) string {
+	if !strings.Contains(path, "$") {
+		return path
+	}
+	return os.Expand(path, func(name string) string {
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return "$" + name
+	})
+}
+
 // truncateStr truncates a string to max length with ellipsis.
 func truncateStr(s string, max int) string {
 	if max <= 0 {
@@ -113,83 +185,9 @@ func truncatePath(path string, maxWidth int) string {
 	return truncateStrVisual(path, maxWidth)
 }
 
-// bufPool reuses read buffers for line counting to avoid per-call allocations.
-var bufPool = sync.Pool{
-	New: func() interface{} { return make([]byte, 64*1024) },
-}
-
-// isBinaryContent checks whether a byte slice looks like binary data.
-// Only checks the first 512 bytes (sufficient for detection, matches HTTP sniffing).
-func isBinaryContent(data []byte) bool {
-	check := data
-	if len(check) > 512 {
-		check = check[:512]
-	}
-	return bytes.IndexByte(check, 0) >= 0
-}
-
-// countLines counts newlines using chunked reads + bytes.Count.
-// Returns (lineCount, isBinary, error).
-// The first read chunk doubles as the binary check — no seek needed.
-func countLines(path string, maxSize int64) (int, bool, error) {
-	info, err := os.Stat(path)
-	if err != nil || info.IsDir() || info.Size() == 0 {
-		return 0, false, err
-	}
-	if info.Size() > maxSize {
-		return 0, false, nil
-	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return 0, false, err
-	}
-	defer f.Close()
-
-	buf := bufPool.Get().([]byte)
-	defer bufPool.Put(buf)
-
-	// First chunk: binary check + start counting
-	n, err := f.Read(buf)
-	if n > 0 && isBinaryContent(buf[:n]) {
-		return 0, true, nil
-	}
-
-	count := 0
-	if n > 0 {
-		count = bytes.Count(buf[:n], []byte{'\n'})
-	}
-	totalRead := int64(n)
-
-	for totalRead < maxSize && err == nil {
-		n, err = f.Read(buf)
-		if n > 0 {
-			count += bytes.Count(buf[:n], []byte{'\n'})
-			totalRead += int64(n)
-		}
-	}
-	return count, false, nil
-}
-
-// isBinaryExt returns true if the file extension suggests a binary file.
-func isBinaryExt(name string) bool {
-	ext := strings.ToLower(filepath.Ext(name))
-	switch ext {
-	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".webp", ".svg",
-		".mp3", ".mp4", ".wav", ".avi", ".mov", ".mkv", ".flac", ".ogg",
-		".zip", ".tar", ".gz", ".bz2", ".xz", ".7z", ".rar", ".zst",
-		".exe", ".dll", ".so", ".dylib", ".bin", ".o", ".a",
-		".pdf", ".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
-		".ttf", ".otf", ".woff", ".woff2", ".eot",
-		".pyc", ".pyo", ".class", ".wasm",
-		".db", ".sqlite", ".sqlite3":
-		return true
-	}
-	return false
-}
-
-// barString generates a proportional bar using block characters.
-func barString(percentage float64, maxWidth int) string {
+// barString generates a proportional bar using block characters, drawn with
+// the glyph of level (only monochrome themes vary it).
+func barString(percentage float64, maxWidth int, level barLevel) string {
 	if maxWidth <= 0 {
 		return ""
 	}
@@ -200,10 +198,11 @@ func barString(percentage float64, maxWidth int) string {
 	if filled > maxWidth {
 		filled = maxWidth
 	}
+	glyph := barGlyphs[level]
 	var b strings.Builder
-	b.Grow(maxWidth*3 + (maxWidth - filled)) // █ is 3 bytes UTF-8
+	b.Grow(filled*len(glyph) + (maxWidth - filled)) // block glyphs are 3 bytes UTF-8
 	for i := 0; i < filled; i++ {
-		b.WriteRune('█')
+		b.WriteString(glyph)
 	}
 	for i := filled; i < maxWidth; i++ {
 		b.WriteByte(' ')
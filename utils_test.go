@@ -2,7 +2,6 @@ package main
 
 import (
 	"os"
-	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -68,82 +67,8 @@ func TestTruncateStr(t *testing.T) {
 	}
 }
 
-func TestCountLines(t *testing.T) {
-	dir := t.TempDir()
-
-	// Text file with 5 lines
-	textFile := filepath.Join(dir, "text.txt")
-	os.WriteFile(textFile, []byte("a\nb\nc\nd\ne\n"), 0o644)
-	lines, isBin, err := countLines(textFile, 1024*1024)
-	if err != nil {
-		t.Fatal(err)
-	}
-	if isBin {
-		t.Error("text file detected as binary")
-	}
-	if lines != 5 {
-		t.Errorf("expected 5 lines, got %d", lines)
-	}
-
-	// Binary file
-	binFile := filepath.Join(dir, "binary.bin")
-	data := make([]byte, 100)
-	data[50] = 0 // null byte
-	os.WriteFile(binFile, data, 0o644)
-	lines, isBin, _ = countLines(binFile, 1024*1024)
-	if !isBin {
-		t.Error("binary file not detected")
-	}
-	if lines != 0 {
-		t.Errorf("expected 0 lines for binary, got %d", lines)
-	}
-
-	// Empty file
-	emptyFile := filepath.Join(dir, "empty.txt")
-	os.WriteFile(emptyFile, []byte{}, 0o644)
-	lines, isBin, _ = countLines(emptyFile, 1024*1024)
-	if isBin {
-		t.Error("empty file detected as binary")
-	}
-	if lines != 0 {
-		t.Errorf("expected 0 lines for empty file, got %d", lines)
-	}
-
-	// File over maxSize should return 0
-	bigFile := filepath.Join(dir, "big.txt")
-	os.WriteFile(bigFile, []byte(strings.Repeat("line\n", 100)), 0o644)
-	lines, _, _ = countLines(bigFile, 10) // maxSize = 10 bytes
-	if lines != 0 {
-		t.Errorf("expected 0 for oversized file, got %d", lines)
-	}
-}
-
-func TestIsBinaryContent(t *testing.T) {
-	if isBinaryContent([]byte("hello world")) {
-		t.Error("text should not be binary")
-	}
-	if !isBinaryContent([]byte{0x48, 0x65, 0x00, 0x6c}) {
-		t.Error("data with null byte should be binary")
-	}
-}
-
-func TestIsBinaryExt(t *testing.T) {
-	binExts := []string{".png", ".jpg", ".zip", ".exe", ".pdf", ".wasm"}
-	for _, ext := range binExts {
-		if !isBinaryExt("file" + ext) {
-			t.Errorf("expected %s to be binary", ext)
-		}
-	}
-	textExts := []string{".go", ".txt", ".md", ".json", ".yaml"}
-	for _, ext := range textExts {
-		if isBinaryExt("file" + ext) {
-			t.Errorf("expected %s to be text", ext)
-		}
-	}
-}
-
 func TestBarString(t *testing.T) {
-	bar := barString(50.0, 10)
+	bar := barString(50.0, 10, barRed)
 	runes := []rune(bar)
 	if len(runes) != 10 {
 		t.Errorf("expected 10 runes, got %d", len(runes))
@@ -154,32 +79,6 @@ func TestBarString(t *testing.T) {
 	}
 }
 
-func TestFuzzyMatch(t *testing.T) {
-	tests := []struct {
-		name, pattern string
-		want          bool
-	}{
-		{"README.md", "read", true},
-		{"main.go", "MAIN", true},
-		{"scanner.go", "xyz", false},
-		{"test", "testing", false},
-		// Subsequence matching (new behavior)
-		{"model.go", "mgo", true},
-		{"README.md", "rdm", true},
-		{"scanner_test.go", "stg", true},
-		{"abcdef", "ace", true},
-		{"abcdef", "afc", false},
-		{"", "a", false},
-		{"a", "", true},
-	}
-	for _, tt := range tests {
-		got := fuzzyMatch(tt.name, tt.pattern)
-		if got != tt.want {
-			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.name, tt.pattern, got, tt.want)
-		}
-	}
-}
-
 func TestPadLeftRight(t *testing.T) {
 	if got := padLeft("hi", 5); got != "   hi" {
 		t.Errorf("padLeft: got %q", got)
@@ -195,44 +94,57 @@ func TestPadLeftRight(t *testing.T) {
 
 // --- Benchmarks ---
 
-func BenchmarkCountLines(b *testing.B) {
-	dir := b.TempDir()
-	// Create a ~1MB text file
-	data := strings.Repeat("this is a line of text for benchmarking\n", 25000)
-	path := filepath.Join(dir, "bench.txt")
-	os.WriteFile(path, []byte(data), 0o644)
-	b.ResetTimer()
+func BenchmarkFormatSize(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		countLines(path, 10*1024*1024)
+		formatSize(1234567890)
 	}
 }
 
-func BenchmarkCountLinesBinary(b *testing.B) {
-	dir := b.TempDir()
-	data := make([]byte, 1024)
-	data[100] = 0 // null byte triggers early exit
-	path := filepath.Join(dir, "binary.bin")
-	os.WriteFile(path, data, 0o644)
-	b.ResetTimer()
+func BenchmarkBarString(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		countLines(path, 10*1024*1024)
+		barString(42.5, 20, barRed)
 	}
 }
 
-func BenchmarkFormatSize(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		formatSize(1234567890)
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"512", 512},
+		{"64K", 64 << 10},
+		{"100MB", 100 << 20},
+		{"1.5 GiB", 3 << 29},
+		{"2t", 2 << 40},
+		{"10b", 10},
 	}
-}
-
-func BenchmarkBarString(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		barString(42.5, 20)
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+		if back, _ := parseSize(formatSizeExact(got)); back != got {
+			t.Errorf("formatSizeExact(%d) = %q does not read back", got, formatSizeExact(got))
+		}
+	}
+	for _, bad := range []string{"", "MB", "-1K", "1e9", "inf", "12 parsecs"} {
+		if _, err := parseSize(bad); err == nil {
+			t.Errorf("parseSize(%q) accepted", bad)
+		}
 	}
 }
 
-func BenchmarkFuzzyMatch(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		fuzzyMatch("some_long_filename_for_testing.go", "test")
+func TestExpandVars(t *testing.T) {
+	t.Setenv("DIRGO_DATA", "/srv/data")
+	tests := []struct{ in, want string }{
+		{"$DIRGO_DATA/logs", "/srv/data/logs"},
+		{"${DIRGO_DATA}/x", "/srv/data/x"},
+		{"/tmp/$DIRGO_UNSET_VAR/x", "/tmp/$DIRGO_UNSET_VAR/x"},
+		{"/plain", "/plain"},
+	}
+	for _, tt := range tests {
+		if got := expandVars(tt.in); got != tt.want {
+			t.Errorf("expandVars(%q) = %q, want %q", tt.in, got, tt.want)
+		}
 	}
 }